@@ -0,0 +1,167 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuthProvider adds credentials to requests sent to a broker.  Unlike
+// BasicAuthConfig and BearerConfig, whose values are fixed when the client is
+// created, an AuthProvider is called for every request, so it can supply
+// credentials that change while the client is in use.
+type AuthProvider interface {
+	// Authenticate adds credentials to the request, typically by setting its
+	// Authorization header.  If it returns an error, the request is not
+	// sent and the error is returned to the caller.  Authenticate may be
+	// called concurrently.
+	Authenticate(request *http.Request) error
+}
+
+// BasicAuthFunc is an AuthProvider that calls a function for the basic auth
+// credentials to send with each request.
+type BasicAuthFunc func() (username, password string, err error)
+
+// Authenticate implements AuthProvider.
+func (f BasicAuthFunc) Authenticate(request *http.Request) error {
+	username, password, err := f()
+	if err != nil {
+		return err
+	}
+
+	request.SetBasicAuth(username, password)
+	return nil
+}
+
+// BearerTokenFunc is an AuthProvider that calls a function for the bearer
+// token to send with each request.
+type BearerTokenFunc func() (token string, err error)
+
+// Authenticate implements AuthProvider.
+func (f BearerTokenFunc) Authenticate(request *http.Request) error {
+	token, err := f()
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// NewBasicAuthFileProvider returns an AuthProvider that sends the basic auth
+// credentials held in the files at the given paths, such as the keys of a
+// mounted Kubernetes secret.  The files are read again whenever they change,
+// so rotated credentials are used without recreating the client.  Leading and
+// trailing whitespace in the files is ignored.
+func NewBasicAuthFileProvider(usernamePath, passwordPath string) (AuthProvider, error) {
+	username, err := newWatchedFile(usernamePath)
+	if err != nil {
+		return nil, err
+	}
+	password, err := newWatchedFile(passwordPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return BasicAuthFunc(func() (string, string, error) {
+		u, err := username.readCredential()
+		if err != nil {
+			return "", "", err
+		}
+		p, err := password.readCredential()
+		if err != nil {
+			return "", "", err
+		}
+		return u, p, nil
+	}), nil
+}
+
+// NewBearerTokenFileProvider returns an AuthProvider that sends the bearer
+// token held in the file at the given path, such as a key of a mounted
+// Kubernetes secret or a projected service account token.  The file is read
+// again whenever it changes, so a rotated token is used without recreating
+// the client.  Leading and trailing whitespace in the file is ignored.
+func NewBearerTokenFileProvider(tokenPath string) (AuthProvider, error) {
+	token, err := newWatchedFile(tokenPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return BearerTokenFunc(token.readCredential), nil
+}
+
+// watchedFile caches the contents of a file, reading it again when its size
+// or modification time changes.  Because it stats the path on each read, it
+// also follows the symlink swaps Kubernetes uses to update mounted secrets.
+type watchedFile struct {
+	path string
+
+	mu      sync.Mutex
+	size    int64
+	modTime time.Time
+	data    []byte
+}
+
+// newWatchedFile returns a watchedFile for the given path, failing if the
+// file cannot be read so that misconfigured paths are caught early.
+func newWatchedFile(path string) (*watchedFile, error) {
+	f := &watchedFile{path: path}
+	if _, err := f.read(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// read returns the current contents of the file.
+func (f *watchedFile) read() ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return nil, err
+	}
+	if f.data != nil && info.Size() == f.size && info.ModTime().Equal(f.modTime) {
+		return f.data, nil
+	}
+
+	data, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return nil, err
+	}
+
+	f.size = info.Size()
+	f.modTime = info.ModTime()
+	f.data = data
+	return f.data, nil
+}
+
+// readCredential returns the contents of the file without surrounding
+// whitespace.
+func (f *watchedFile) readCredential() (string, error) {
+	data, err := f.read()
+	if err != nil {
+		return "", fmt.Errorf("reading credentials: %v", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newAuthProviderTestClient returns a test client using the given
+// AuthProvider, and a pointer to the Authorization header of the last request
+// it sent.
+func newAuthProviderTestClient(t *testing.T, name string, provider AuthProvider) (*client, *string) {
+	authorization := new(string)
+	klient := newTestClient(t, name, Version2_14(), false, httpChecks{}, httpReaction{})
+	klient.AuthConfig = &AuthConfig{AuthProvider: provider}
+	klient.doRequestFunc = func(request *http.Request) (*http.Response, error) {
+		*authorization = request.Header.Get("Authorization")
+		return &http.Response{StatusCode: http.StatusOK, Body: closer(`{"services":[]}`)}, nil
+	}
+	return klient, authorization
+}
+
+func TestAuthProviderFuncs(t *testing.T) {
+	token := "first"
+	klient, authorization := newAuthProviderTestClient(t, "bearer func", BearerTokenFunc(func() (string, error) {
+		return token, nil
+	}))
+
+	for _, token = range []string{"first", "rotated"} {
+		if _, err := klient.GetCatalog(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if e, a := "Bearer "+token, *authorization; e != a {
+			t.Errorf("unexpected Authorization header; expected %v, got %v", e, a)
+		}
+	}
+
+	klient, authorization = newAuthProviderTestClient(t, "basic func", BasicAuthFunc(func() (string, string, error) {
+		return "user", "pass", nil
+	}))
+	if _, err := klient.GetCatalog(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := "Basic dXNlcjpwYXNz", *authorization; e != a {
+		t.Errorf("unexpected Authorization header; expected %v, got %v", e, a)
+	}
+}
+
+func TestAuthProviderError(t *testing.T) {
+	errRotation := errors.New("secret is being rotated")
+	klient := newTestClient(t, "auth provider error", Version2_14(), false, httpChecks{}, httpReaction{})
+	klient.AuthConfig = &AuthConfig{AuthProvider: BearerTokenFunc(func() (string, error) {
+		return "", errRotation
+	})}
+	klient.doRequestFunc = func(request *http.Request) (*http.Response, error) {
+		t.Error("expected no request to the broker without credentials")
+		return nil, errors.New("unexpected request")
+	}
+
+	if _, err := klient.GetCatalog(); !errors.Is(err, errRotation) {
+		t.Errorf("expected the AuthProvider error, got %v", err)
+	}
+}
+
+func writeCredentialFile(t *testing.T, path, value string, modTime time.Time) {
+	if err := ioutil.WriteFile(path, []byte(value), 0600); err != nil {
+		t.Fatalf("unexpected error writing %v: %v", path, err)
+	}
+	// Set the modification time explicitly, since rewrites within the
+	// filesystem's timestamp granularity would otherwise look unchanged.
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("unexpected error setting times of %v: %v", path, err)
+	}
+}
+
+func TestFileAuthProviders(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auth-provider")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	modTime := time.Now().Add(-time.Hour)
+	tokenPath := filepath.Join(dir, "token")
+	usernamePath := filepath.Join(dir, "username")
+	passwordPath := filepath.Join(dir, "password")
+	writeCredentialFile(t, tokenPath, "first-token\n", modTime)
+	writeCredentialFile(t, usernamePath, "user", modTime)
+	writeCredentialFile(t, passwordPath, "first", modTime)
+
+	bearer, err := NewBearerTokenFileProvider(tokenPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	basic, err := NewBasicAuthFileProvider(usernamePath, passwordPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	bearerClient, bearerAuthorization := newAuthProviderTestClient(t, "bearer file", bearer)
+	basicClient, basicAuthorization := newAuthProviderTestClient(t, "basic file", basic)
+
+	check := func(expectedBearer, expectedBasic string) {
+		t.Helper()
+		if _, err := bearerClient.GetCatalog(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if e, a := expectedBearer, *bearerAuthorization; e != a {
+			t.Errorf("unexpected bearer Authorization header; expected %v, got %v", e, a)
+		}
+		if _, err := basicClient.GetCatalog(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if e, a := expectedBasic, *basicAuthorization; e != a {
+			t.Errorf("unexpected basic Authorization header; expected %v, got %v", e, a)
+		}
+	}
+
+	check("Bearer first-token", "Basic dXNlcjpmaXJzdA==")
+
+	modTime = modTime.Add(time.Minute)
+	writeCredentialFile(t, tokenPath, "second-token\n", modTime)
+	writeCredentialFile(t, passwordPath, "second", modTime)
+	check("Bearer second-token", "Basic dXNlcjpzZWNvbmQ=")
+
+	if err := os.Remove(tokenPath); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := bearerClient.GetCatalog(); err == nil {
+		t.Error("expected an error once the token file is removed")
+	}
+
+	if _, err := NewBearerTokenFileProvider(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected an error for a missing token file")
+	}
+}
+
+func TestNewClientAuthProvider(t *testing.T) {
+	config := DefaultClientConfiguration()
+	config.URL = "https://example.com"
+	config.AuthConfig = &AuthConfig{
+		BasicAuthConfig: &BasicAuthConfig{Username: "user", Password: "pass"},
+		AuthProvider:    BearerTokenFunc(func() (string, error) { return "token", nil }),
+	}
+	if _, err := NewClient(config); err == nil {
+		t.Error("expected error for AuthProvider combined with BasicAuthConfig")
+	}
+
+	config.AuthConfig.BasicAuthConfig = nil
+	if _, err := NewClient(config); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
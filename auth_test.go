@@ -17,6 +17,7 @@ limitations under the License.
 package v2
 
 import (
+	"context"
 	"net/http"
 	"strings"
 	"testing"
@@ -45,7 +46,7 @@ func TestBasicAuth(t *testing.T) {
 			BasicAuthConfig: tc.BasicAuthConfig,
 		}
 		client.doRequestFunc = addBasicAuthCheck(t, tc.name, tc.BasicAuthConfig, client.doRequestFunc)
-		_, _ = client.prepareAndDo(http.MethodGet, client.URL, nil, nil, nil)
+		_, _ = client.prepareAndDo(context.Background(), OperationGetCatalog, http.MethodGet, client.URL, nil, nil, nil, "")
 	}
 }
 
@@ -71,7 +72,7 @@ func TestBearerAuth(t *testing.T) {
 			BearerConfig: tc.BearerConfig,
 		}
 		client.doRequestFunc = addBearerAuthCheck(t, tc.name, tc.BearerConfig, client.doRequestFunc)
-		_, _ = client.prepareAndDo(http.MethodGet, client.URL, nil, nil, nil)
+		_, _ = client.prepareAndDo(context.Background(), OperationGetCatalog, http.MethodGet, client.URL, nil, nil, nil, "")
 	}
 }
 
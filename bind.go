@@ -17,10 +17,9 @@ limitations under the License.
 package v2
 
 import (
+	"context"
 	"fmt"
 	"net/http"
-
-	"k8s.io/klog/v2"
 )
 
 // internal message body types
@@ -47,6 +46,22 @@ const (
 )
 
 func (c *client) Bind(r *BindRequest) (*BindResponse, error) {
+	return c.BindWithContext(context.Background(), r)
+}
+
+func (c *client) BindWithContext(ctx context.Context, r *BindRequest) (_ *BindResponse, err error) {
+	ctx, span := c.startSpan(ctx, OperationBind, spanIDs{instanceID: r.InstanceID, bindingID: r.BindingID, serviceID: r.ServiceID, planID: r.PlanID})
+	defer func() { endSpan(span, err) }()
+
+	response, err := c.bind(ctx, r)
+	if err != nil && c.EnableOrphanMitigation && isOrphanMitigationRequired(err) {
+		return nil, c.mitigateOrphanedBinding(r, err)
+	}
+
+	return response, err
+}
+
+func (c *client) bind(ctx context.Context, r *BindRequest) (*BindResponse, error) {
 	if r.AcceptsIncomplete {
 		if err := c.validateClientVersionIsAtLeast(Version2_14()); err != nil {
 			return nil, AsyncBindingOperationsNotAllowedError{
@@ -58,6 +73,11 @@ func (c *client) Bind(r *BindRequest) (*BindResponse, error) {
 	if err := validateBindRequest(r); err != nil {
 		return nil, err
 	}
+	if c.RequestValidator != nil {
+		if err := c.RequestValidator.ValidateBindRequest(r); err != nil {
+			return nil, err
+		}
+	}
 
 	fullURL := fmt.Sprintf(bindingURLFmt, c.URL, r.InstanceID, r.BindingID)
 
@@ -86,7 +106,7 @@ func (c *client) Bind(r *BindRequest) (*BindResponse, error) {
 		}
 	}
 
-	response, err := c.prepareAndDo(http.MethodPut, fullURL, params, requestBody, r.OriginatingIdentity)
+	response, err := c.prepareAndDo(ctx, OperationBind, http.MethodPut, fullURL, params, requestBody, r.OriginatingIdentity, r.RequestIdentity)
 	if err != nil {
 		return nil, err
 	}
@@ -100,13 +120,17 @@ func (c *client) Bind(r *BindRequest) (*BindResponse, error) {
 	case http.StatusOK, http.StatusCreated:
 		userResponse := &BindResponse{}
 		if err := c.unmarshalResponse(response, userResponse); err != nil {
-			return nil, HTTPStatusCodeError{StatusCode: response.StatusCode, ResponseError: err}
+			return nil, unmarshalError(response, err)
 		}
 
 		if !c.EnableAlphaFeatures {
 			userResponse.Endpoints = nil
 		}
+		if c.APIVersion.IsLessThan(Version2_15()) {
+			userResponse.Metadata = nil
+		}
 
+		userResponse.RequestIdentity = requestIdentity(response)
 		return userResponse, nil
 	case http.StatusAccepted:
 		if !r.AcceptsIncomplete {
@@ -115,7 +139,7 @@ func (c *client) Bind(r *BindRequest) (*BindResponse, error) {
 
 		responseBodyObj := &bindSuccessResponseBody{}
 		if err := c.unmarshalResponse(response, responseBodyObj); err != nil {
-			return nil, HTTPStatusCodeError{StatusCode: response.StatusCode, ResponseError: err}
+			return nil, unmarshalError(response, err)
 		}
 
 		var opPtr *OperationKey
@@ -133,12 +157,11 @@ func (c *client) Bind(r *BindRequest) (*BindResponse, error) {
 			OperationKey:    opPtr,
 		}
 		if response.StatusCode == http.StatusAccepted {
-			if c.Verbose {
-				klog.Infof("broker %q: received asynchronous response", c.Name)
-			}
+			c.logAt(LogLevelRequests).Info("received asynchronous response")
 			userResponse.Async = true
 		}
 
+		userResponse.RequestIdentity = requestIdentity(response)
 		return userResponse, nil
 	default:
 		return nil, c.handleFailureResponse(response)
@@ -0,0 +1,283 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ChangeType is the way in which a service or plan changed between two
+// catalogs.
+type ChangeType string
+
+const (
+	// ChangeAdded is the type of change of a service or plan that is only in
+	// the new catalog.
+	ChangeAdded ChangeType = "added"
+	// ChangeRemoved is the type of change of a service or plan that is only
+	// in the old catalog.
+	ChangeRemoved ChangeType = "removed"
+	// ChangeModified is the type of change of a service or plan that is in
+	// both catalogs with different fields.
+	ChangeModified ChangeType = "modified"
+)
+
+// CatalogDiff is the set of changes between two catalogs.  Services are
+// matched by ID, and plans by ID within their service.
+type CatalogDiff struct {
+	// Services are the services that were added, removed or modified: those
+	// of the new catalog in order, followed by those removed from the old.
+	Services []ServiceChange
+}
+
+// ServiceChange is a change to a service between two catalogs.
+type ServiceChange struct {
+	Type ChangeType
+	// ID is the ID of the service.
+	ID string
+	// Old is the service in the old catalog, or nil if it was added.
+	Old *Service
+	// New is the service in the new catalog, or nil if it was removed.
+	New *Service
+	// Fields are the fields of a modified service that changed, other than
+	// its plans.
+	Fields []FieldChange
+	// Plans are the plans of a modified service that were added, removed or
+	// modified.
+	Plans []PlanChange
+}
+
+// PlanChange is a change to a plan between two catalogs.
+type PlanChange struct {
+	Type ChangeType
+	// ID is the ID of the plan.
+	ID string
+	// Old is the plan in the old catalog, or nil if it was added.
+	Old *Plan
+	// New is the plan in the new catalog, or nil if it was removed.
+	New *Plan
+	// Fields are the fields of a modified plan that changed.
+	Fields []FieldChange
+}
+
+// FieldChange is a change to a field of a service or plan.
+type FieldChange struct {
+	// Field is the JSON path of the field within its service or plan, such
+	// as "maintenance_info.version".
+	Field string
+	// Old is the value of the field in the old catalog, or nil if it was
+	// unset.
+	Old interface{}
+	// New is the value of the field in the new catalog, or nil if it was
+	// unset.
+	New interface{}
+}
+
+// DiffCatalogs returns the changes from the old catalog to the new one.  A nil
+// catalog is treated as one without services.
+func DiffCatalogs(old, new *CatalogResponse) *CatalogDiff {
+	diff := &CatalogDiff{}
+
+	oldServices := map[string]*Service{}
+	if old != nil {
+		for i := range old.Services {
+			oldServices[old.Services[i].ID] = &old.Services[i]
+		}
+	}
+	newServices := map[string]*Service{}
+	if new != nil {
+		for i := range new.Services {
+			service := &new.Services[i]
+			newServices[service.ID] = service
+
+			oldService, ok := oldServices[service.ID]
+			if !ok {
+				diff.Services = append(diff.Services, ServiceChange{Type: ChangeAdded, ID: service.ID, New: service})
+				continue
+			}
+			change := ServiceChange{
+				Type:   ChangeModified,
+				ID:     service.ID,
+				Old:    oldService,
+				New:    service,
+				Fields: diffFields(reflect.ValueOf(*oldService), reflect.ValueOf(*service)),
+				Plans:  diffPlans(oldService.Plans, service.Plans),
+			}
+			if len(change.Fields) > 0 || len(change.Plans) > 0 {
+				diff.Services = append(diff.Services, change)
+			}
+		}
+	}
+	if old != nil {
+		for i := range old.Services {
+			service := &old.Services[i]
+			if _, ok := newServices[service.ID]; !ok {
+				diff.Services = append(diff.Services, ServiceChange{Type: ChangeRemoved, ID: service.ID, Old: service})
+			}
+		}
+	}
+
+	return diff
+}
+
+func diffPlans(old, new []Plan) []PlanChange {
+	var changes []PlanChange
+
+	oldPlans := map[string]*Plan{}
+	for i := range old {
+		oldPlans[old[i].ID] = &old[i]
+	}
+	newPlans := map[string]*Plan{}
+	for i := range new {
+		plan := &new[i]
+		newPlans[plan.ID] = plan
+
+		oldPlan, ok := oldPlans[plan.ID]
+		if !ok {
+			changes = append(changes, PlanChange{Type: ChangeAdded, ID: plan.ID, New: plan})
+			continue
+		}
+		if fields := diffFields(reflect.ValueOf(*oldPlan), reflect.ValueOf(*plan)); len(fields) > 0 {
+			changes = append(changes, PlanChange{Type: ChangeModified, ID: plan.ID, Old: oldPlan, New: plan, Fields: fields})
+		}
+	}
+	for i := range old {
+		if _, ok := newPlans[old[i].ID]; !ok {
+			changes = append(changes, PlanChange{Type: ChangeRemoved, ID: old[i].ID, Old: &old[i]})
+		}
+	}
+
+	return changes
+}
+
+// diffFields returns the changes between two values of a struct type, with the
+// fields of nested structs compared individually.  The plans of a service are
+// compared by diffPlans instead.
+func diffFields(old, new reflect.Value) []FieldChange {
+	var changes []FieldChange
+	appendFieldChanges(&changes, "", old, new)
+	return changes
+}
+
+func appendFieldChanges(changes *[]FieldChange, prefix string, old, new reflect.Value) {
+	for i := 0; i < old.NumField(); i++ {
+		field := old.Type().Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" || name == "" || field.Type == reflect.TypeOf([]Plan{}) {
+			continue
+		}
+		path := prefix + name
+
+		oldField, newField := old.Field(i), new.Field(i)
+		if field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct && !oldField.IsNil() && !newField.IsNil() {
+			appendFieldChanges(changes, path+".", oldField.Elem(), newField.Elem())
+			continue
+		}
+		if oldValue, newValue := fieldValue(oldField), fieldValue(newField); !reflect.DeepEqual(oldValue, newValue) {
+			*changes = append(*changes, FieldChange{Field: path, Old: oldValue, New: newValue})
+		}
+	}
+}
+
+// fieldValue returns the value of a field for comparison: nil if it is unset,
+// which includes empty slices and maps, and the value it points to if it is a
+// pointer.
+func fieldValue(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return v.Elem().Interface()
+	case reflect.Slice, reflect.Map:
+		if v.Len() == 0 {
+			return nil
+		}
+	}
+	return v.Interface()
+}
+
+// IsEmpty returns whether the catalogs compared are equivalent.
+func (d *CatalogDiff) IsEmpty() bool {
+	return len(d.Services) == 0
+}
+
+// String renders the diff for review, one line per change: services and plans
+// prefixed with "+" if they were added, "-" if they were removed and "~" if
+// they were modified, followed by the fields that changed as JSON.
+func (d *CatalogDiff) String() string {
+	var buf bytes.Buffer
+	for _, service := range d.Services {
+		fmt.Fprintf(&buf, "%v service %q (%v)\n", service.Type.symbol(), service.Name(), service.ID)
+		writeFieldChanges(&buf, "    ", service.Fields)
+		for _, plan := range service.Plans {
+			fmt.Fprintf(&buf, "  %v plan %q (%v)\n", plan.Type.symbol(), plan.Name(), plan.ID)
+			writeFieldChanges(&buf, "      ", plan.Fields)
+		}
+	}
+	return buf.String()
+}
+
+// Name returns the name of the service in the new catalog, or in the old one
+// if it was removed.
+func (c *ServiceChange) Name() string {
+	if c.New != nil {
+		return c.New.Name
+	}
+	return c.Old.Name
+}
+
+// Name returns the name of the plan in the new catalog, or in the old one if
+// it was removed.
+func (c *PlanChange) Name() string {
+	if c.New != nil {
+		return c.New.Name
+	}
+	return c.Old.Name
+}
+
+func (t ChangeType) symbol() string {
+	switch t {
+	case ChangeAdded:
+		return "+"
+	case ChangeRemoved:
+		return "-"
+	default:
+		return "~"
+	}
+}
+
+func writeFieldChanges(buf *bytes.Buffer, indent string, changes []FieldChange) {
+	for _, change := range changes {
+		fmt.Fprintf(buf, "%v%v: %v -> %v\n", indent, change.Field, renderFieldValue(change.Old), renderFieldValue(change.New))
+	}
+}
+
+func renderFieldValue(value interface{}) string {
+	if value == nil {
+		return "<unset>"
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
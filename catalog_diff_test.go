@@ -0,0 +1,128 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffCatalogsUnchanged(t *testing.T) {
+	diff := DiffCatalogs(testValidCatalog(), testValidCatalog())
+	if !diff.IsEmpty() {
+		t.Errorf("expected no changes, got %+v", diff.Services)
+	}
+	if e, a := "", diff.String(); e != a {
+		t.Errorf("unexpected rendering; expected %q, got %q", e, a)
+	}
+}
+
+func TestDiffCatalogs(t *testing.T) {
+	old := testValidCatalog()
+	old.Services = append(old.Services, Service{
+		ID:    "cache-id",
+		Name:  "cache",
+		Plans: []Plan{{ID: "cache-small-id", Name: "small"}},
+	})
+
+	new := testValidCatalog()
+	new.Services[0].Name = "db"
+	new.Services[0].Tags = []string{}
+	new.Services[0].Plans[0].MaintenanceInfo.Version = "1.3.0"
+	new.Services[0].Plans[0].Free = falsePtr()
+	new.Services[0].Plans[0].Schemas.ServiceInstance.Create.Parameters = map[string]interface{}{
+		"$schema":  "http://json-schema.org/draft-04/schema#",
+		"type":     "object",
+		"required": []interface{}{"region"},
+	}
+	new.Services[0].Plans = append(new.Services[0].Plans, Plan{ID: "database-large-id", Name: "large"})
+	new.Services[1].Plans = nil
+	new.Services = append(new.Services, Service{ID: "search-id", Name: "search"})
+
+	diff := DiffCatalogs(old, new)
+
+	var types []ChangeType
+	var ids []string
+	for _, service := range diff.Services {
+		types = append(types, service.Type)
+		ids = append(ids, service.ID)
+	}
+	if e, a := []ChangeType{ChangeModified, ChangeModified, ChangeAdded, ChangeRemoved}, types; !reflect.DeepEqual(e, a) {
+		t.Errorf("unexpected service change types; expected %v, got %v", e, a)
+	}
+	if e, a := []string{"database-id", "queue-id", "search-id", "cache-id"}, ids; !reflect.DeepEqual(e, a) {
+		t.Errorf("unexpected service change IDs; expected %v, got %v", e, a)
+	}
+
+	database := diff.Services[0]
+	if e, a := []FieldChange{{Field: "name", Old: "database", New: "db"}}, database.Fields; !reflect.DeepEqual(e, a) {
+		t.Errorf("unexpected service field changes;\nexpected %+v\ngot      %+v", e, a)
+	}
+	if e, a := 2, len(database.Plans); e != a {
+		t.Fatalf("expected %v plan changes, got %+v", e, database.Plans)
+	}
+	var fields []string
+	for _, field := range database.Plans[0].Fields {
+		fields = append(fields, field.Field)
+	}
+	if e, a := []string{"free", "schemas.service_instance.create.parameters", "maintenance_info.version"}, fields; !reflect.DeepEqual(e, a) {
+		t.Errorf("unexpected plan field changes; expected %v, got %v", e, a)
+	}
+	if e, a := ChangeAdded, database.Plans[1].Type; e != a {
+		t.Errorf("unexpected plan change type; expected %v, got %v", e, a)
+	}
+
+	queue := diff.Services[1]
+	if len(queue.Fields) != 0 || len(queue.Plans) != 1 || queue.Plans[0].Type != ChangeRemoved || queue.Plans[0].Name() != "small" {
+		t.Errorf("expected only the removal of the small queue plan, got %+v", queue)
+	}
+
+	expected := `~ service "db" (database-id)
+    name: "database" -> "db"
+  ~ plan "small" (database-small-id)
+      free: <unset> -> false
+      schemas.service_instance.create.parameters: {"$schema":"http://json-schema.org/draft-04/schema#","type":"object"} -> {"$schema":"http://json-schema.org/draft-04/schema#","required":["region"],"type":"object"}
+      maintenance_info.version: "1.2.3-rc.1+build.5" -> "1.3.0"
+  + plan "large" (database-large-id)
+~ service "queue" (queue-id)
+  - plan "small" (queue-small-id)
++ service "search" (search-id)
+- service "cache" (cache-id)
+`
+	if e, a := expected, diff.String(); e != a {
+		t.Errorf("unexpected rendering;\nexpected:\n%v\ngot:\n%v", e, a)
+	}
+}
+
+func TestDiffCatalogsNil(t *testing.T) {
+	diff := DiffCatalogs(nil, testValidCatalog())
+	if e, a := 2, len(diff.Services); e != a {
+		t.Fatalf("expected %v service changes, got %+v", e, diff.Services)
+	}
+	for _, service := range diff.Services {
+		if service.Type != ChangeAdded {
+			t.Errorf("expected service %v to be added, got %v", service.ID, service.Type)
+		}
+	}
+
+	diff = DiffCatalogs(testValidCatalog(), nil)
+	for _, service := range diff.Services {
+		if service.Type != ChangeRemoved {
+			t.Errorf("expected service %v to be removed, got %v", service.ID, service.Type)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+// CatalogIndex indexes the services and plans of a catalog for lookups by ID,
+// name and tag.  It refers to the services and plans of the catalog it was
+// built from, which must not be modified while the index is in use.
+type CatalogIndex struct {
+	services       []*Service
+	plans          []*CatalogPlan
+	servicesByID   map[string]*Service
+	servicesByName map[string]*Service
+	servicesByTag  map[string][]*Service
+	plansByID      map[string]*CatalogPlan
+	plansByName    map[catalogPlanName]*CatalogPlan
+}
+
+type catalogPlanName struct {
+	serviceName string
+	planName    string
+}
+
+// CatalogPlan is a plan in a CatalogIndex and the service it belongs to.
+type CatalogPlan struct {
+	Plan    *Plan
+	Service *Service
+}
+
+// NewCatalogIndex returns a CatalogIndex of the given catalog.  If several
+// services or plans of a service have the same name or ID, lookups return the
+// first of them.
+func NewCatalogIndex(catalog *CatalogResponse) *CatalogIndex {
+	index := &CatalogIndex{
+		servicesByID:   map[string]*Service{},
+		servicesByName: map[string]*Service{},
+		servicesByTag:  map[string][]*Service{},
+		plansByID:      map[string]*CatalogPlan{},
+		plansByName:    map[catalogPlanName]*CatalogPlan{},
+	}
+
+	for i := range catalog.Services {
+		service := &catalog.Services[i]
+		index.services = append(index.services, service)
+		if _, ok := index.servicesByID[service.ID]; !ok {
+			index.servicesByID[service.ID] = service
+		}
+		if _, ok := index.servicesByName[service.Name]; !ok {
+			index.servicesByName[service.Name] = service
+		}
+		for _, tag := range service.Tags {
+			index.servicesByTag[tag] = append(index.servicesByTag[tag], service)
+		}
+
+		for j := range service.Plans {
+			plan := &CatalogPlan{Plan: &service.Plans[j], Service: service}
+			index.plans = append(index.plans, plan)
+			if _, ok := index.plansByID[plan.Plan.ID]; !ok {
+				index.plansByID[plan.Plan.ID] = plan
+			}
+			name := catalogPlanName{serviceName: service.Name, planName: plan.Plan.Name}
+			if _, ok := index.plansByName[name]; !ok {
+				index.plansByName[name] = plan
+			}
+		}
+	}
+
+	return index
+}
+
+// Services returns the services of the catalog in order.
+func (i *CatalogIndex) Services() []*Service {
+	return i.services
+}
+
+// Plans returns the plans of every service of the catalog in order.
+func (i *CatalogIndex) Plans() []*CatalogPlan {
+	return i.plans
+}
+
+// ServiceByID returns the service with the given ID.
+func (i *CatalogIndex) ServiceByID(id string) (*Service, bool) {
+	service, ok := i.servicesByID[id]
+	return service, ok
+}
+
+// ServiceByName returns the service with the given name.
+func (i *CatalogIndex) ServiceByName(name string) (*Service, bool) {
+	service, ok := i.servicesByName[name]
+	return service, ok
+}
+
+// ServicesByTag returns the services with the given tag.
+func (i *CatalogIndex) ServicesByTag(tag string) []*Service {
+	return i.servicesByTag[tag]
+}
+
+// PlanByID returns the plan with the given ID.  Plan IDs are unique across
+// the services of a catalog.
+func (i *CatalogIndex) PlanByID(id string) (*CatalogPlan, bool) {
+	plan, ok := i.plansByID[id]
+	return plan, ok
+}
+
+// PlanByName returns the plan with the given name of the service with the
+// given name.  Plan names are only unique within a service.
+func (i *CatalogIndex) PlanByName(serviceName, planName string) (*CatalogPlan, bool) {
+	plan, ok := i.plansByName[catalogPlanName{serviceName: serviceName, planName: planName}]
+	return plan, ok
+}
+
+// FreePlans returns the plans that are available without charge.
+func (i *CatalogIndex) FreePlans() []*CatalogPlan {
+	return i.filterPlans(true)
+}
+
+// PaidPlans returns the plans that are not available without charge.
+func (i *CatalogIndex) PaidPlans() []*CatalogPlan {
+	return i.filterPlans(false)
+}
+
+func (i *CatalogIndex) filterPlans(free bool) []*CatalogPlan {
+	var plans []*CatalogPlan
+	for _, plan := range i.plans {
+		if plan.Free() == free {
+			plans = append(plans, plan)
+		}
+	}
+	return plans
+}
+
+// Bindable returns whether instances of the plan can be bound to: the
+// plan's Bindable field if it is set, and its service's otherwise.
+func (p *CatalogPlan) Bindable() bool {
+	if p.Plan.Bindable != nil {
+		return *p.Plan.Bindable
+	}
+	return p.Service.Bindable
+}
+
+// PlanUpdateable returns whether instances of the plan can be updated to
+// another plan: the plan's PlanUpdateable field if it is set, and its
+// service's PlanUpdatable field otherwise, which defaults to false.
+func (p *CatalogPlan) PlanUpdateable() bool {
+	if p.Plan.PlanUpdateable != nil {
+		return *p.Plan.PlanUpdateable
+	}
+	return p.Service.PlanUpdatable != nil && *p.Service.PlanUpdatable
+}
+
+// InstancesRetrievable returns whether instances of the plan can be fetched,
+// which is decided by its service for all of its plans.
+func (p *CatalogPlan) InstancesRetrievable() bool {
+	return p.Service.InstancesRetrievable
+}
+
+// BindingsRetrievable returns whether bindings to instances of the plan can
+// be fetched, which is decided by its service for all of its plans.
+func (p *CatalogPlan) BindingsRetrievable() bool {
+	return p.Service.BindingsRetrievable
+}
+
+// Free returns whether the plan is available without charge, which it is
+// unless its Free field is set to false.
+func (p *CatalogPlan) Free() bool {
+	return p.Plan.Free == nil || *p.Plan.Free
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+	"testing"
+)
+
+func testIndexCatalog() *CatalogResponse {
+	return &CatalogResponse{
+		Services: []Service{
+			{
+				ID:            "database-id",
+				Name:          "database",
+				Tags:          []string{"sql", "storage"},
+				Bindable:      true,
+				PlanUpdatable: truePtr(),
+				Plans: []Plan{
+					{ID: "database-small-id", Name: "small"},
+					{ID: "database-large-id", Name: "large", Free: falsePtr(), Bindable: falsePtr(), PlanUpdateable: falsePtr()},
+				},
+			},
+			{
+				ID:                   "queue-id",
+				Name:                 "queue",
+				Tags:                 []string{"storage"},
+				InstancesRetrievable: true,
+				Plans: []Plan{
+					{ID: "queue-small-id", Name: "small", Bindable: truePtr()},
+				},
+			},
+		},
+	}
+}
+
+func TestCatalogIndexLookups(t *testing.T) {
+	index := NewCatalogIndex(testIndexCatalog())
+
+	if e, a := 2, len(index.Services()); e != a {
+		t.Errorf("expected %v services, got %v", e, a)
+	}
+	if e, a := 3, len(index.Plans()); e != a {
+		t.Errorf("expected %v plans, got %v", e, a)
+	}
+
+	if service, ok := index.ServiceByID("queue-id"); !ok || service.Name != "queue" {
+		t.Errorf("unexpected service by ID: %+v, %v", service, ok)
+	}
+	if service, ok := index.ServiceByName("database"); !ok || service.ID != "database-id" {
+		t.Errorf("unexpected service by name: %+v, %v", service, ok)
+	}
+	if _, ok := index.ServiceByName("cache"); ok {
+		t.Error("expected no service named cache")
+	}
+
+	plan, ok := index.PlanByID("queue-small-id")
+	if !ok || plan.Plan.Name != "small" || plan.Service.ID != "queue-id" {
+		t.Errorf("unexpected plan by ID: %+v, %v", plan, ok)
+	}
+	plan, ok = index.PlanByName("database", "small")
+	if !ok || plan.Plan.ID != "database-small-id" {
+		t.Errorf("unexpected plan by name: %+v, %v", plan, ok)
+	}
+	if _, ok := index.PlanByName("queue", "large"); ok {
+		t.Error("expected no large queue plan")
+	}
+
+	var tagged []string
+	for _, service := range index.ServicesByTag("storage") {
+		tagged = append(tagged, service.ID)
+	}
+	if e, a := "[database-id queue-id]", fmt.Sprint(tagged); e != a {
+		t.Errorf("unexpected services by tag; expected %v, got %v", e, a)
+	}
+	if services := index.ServicesByTag("cache"); len(services) != 0 {
+		t.Errorf("expected no services tagged cache, got %v", services)
+	}
+}
+
+func TestCatalogIndexEffectivePlanAttributes(t *testing.T) {
+	index := NewCatalogIndex(testIndexCatalog())
+
+	cases := []struct {
+		planID               string
+		bindable             bool
+		planUpdateable       bool
+		instancesRetrievable bool
+		free                 bool
+	}{
+		{planID: "database-small-id", bindable: true, planUpdateable: true, free: true},
+		{planID: "database-large-id"},
+		{planID: "queue-small-id", bindable: true, instancesRetrievable: true, free: true},
+	}
+
+	for _, tc := range cases {
+		plan, ok := index.PlanByID(tc.planID)
+		if !ok {
+			t.Fatalf("%v: plan not found", tc.planID)
+		}
+		if e, a := tc.bindable, plan.Bindable(); e != a {
+			t.Errorf("%v: unexpected bindable; expected %v, got %v", tc.planID, e, a)
+		}
+		if e, a := tc.planUpdateable, plan.PlanUpdateable(); e != a {
+			t.Errorf("%v: unexpected plan updateable; expected %v, got %v", tc.planID, e, a)
+		}
+		if e, a := tc.instancesRetrievable, plan.InstancesRetrievable(); e != a {
+			t.Errorf("%v: unexpected instances retrievable; expected %v, got %v", tc.planID, e, a)
+		}
+		if e, a := tc.free, plan.Free(); e != a {
+			t.Errorf("%v: unexpected free; expected %v, got %v", tc.planID, e, a)
+		}
+	}
+
+	var free, paid []string
+	for _, plan := range index.FreePlans() {
+		free = append(free, plan.Plan.ID)
+	}
+	for _, plan := range index.PaidPlans() {
+		paid = append(paid, plan.Plan.ID)
+	}
+	if e, a := "[database-small-id queue-small-id]", fmt.Sprint(free); e != a {
+		t.Errorf("unexpected free plans; expected %v, got %v", e, a)
+	}
+	if e, a := "[database-large-id]", fmt.Sprint(paid); e != a {
+		t.Errorf("unexpected paid plans; expected %v, got %v", e, a)
+	}
+}
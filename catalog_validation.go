@@ -0,0 +1,211 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+
+	"sigs.k8s.io/go-open-service-broker-client/v2/internal/jsonschema"
+)
+
+// Severity is the severity of a CatalogViolation.
+type Severity string
+
+const (
+	// SeverityError is the severity of a violation of a requirement of the
+	// Open Service Broker API specification, which platforms may reject the
+	// catalog for.
+	SeverityError Severity = "error"
+	// SeverityWarning is the severity of a departure from a recommendation
+	// of the specification.
+	SeverityWarning Severity = "warning"
+)
+
+// CatalogViolation is a way in which a catalog does not conform to the Open
+// Service Broker API specification.
+type CatalogViolation struct {
+	// Severity is how severe the violation is.
+	Severity Severity
+	// Path is the JSON path of the violating field within the catalog, such
+	// as "services[0].plans[1].id".
+	Path string
+	// Message describes the violation.
+	Message string
+}
+
+func (v CatalogViolation) String() string {
+	return fmt.Sprintf("%v: %v: %v", v.Severity, v.Path, v.Message)
+}
+
+var (
+	// cliFriendlyNamePattern matches the lowercase names without spaces that
+	// the specification recommends for services and plans.
+	cliFriendlyNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9._-]*[a-z0-9])?$`)
+	// semanticVersionPattern matches a semantic version 2.0.0.
+	semanticVersionPattern = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(-(0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(\.(0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*)?(\+[0-9a-zA-Z-]+(\.[0-9a-zA-Z-]+)*)?$`)
+)
+
+// validServiceRequires are the permissions a service may require.
+var validServiceRequires = map[string]bool{
+	"syslog_drain":     true,
+	"route_forwarding": true,
+	"volume_mount":     true,
+}
+
+// ValidateCatalog validates a catalog against the Open Service Broker API
+// specification at the given version and returns its violations in the order
+// of the fields they are found in, or nil if it conforms.
+func ValidateCatalog(catalog *CatalogResponse, version APIVersion) []CatalogViolation {
+	v := &catalogValidation{}
+
+	serviceIDs := map[string]string{}
+	serviceNames := map[string]string{}
+	planIDs := map[string]string{}
+	for i := range catalog.Services {
+		service := &catalog.Services[i]
+		path := fmt.Sprintf("services[%d]", i)
+
+		v.validateID(path+".id", service.ID, serviceIDs, "service")
+		v.validateName(path+".name", service.Name, serviceNames, "service")
+		if service.Description == "" {
+			v.errorf(path+".description", "description is required")
+		}
+		for j, permission := range service.Requires {
+			if !validServiceRequires[permission] {
+				v.warningf(fmt.Sprintf("%v.requires[%d]", path, j), "unknown permission %q; valid permissions are syslog_drain, route_forwarding and volume_mount", permission)
+			}
+		}
+		if service.DashboardClient != nil {
+			v.validateDashboardClient(path+".dashboard_client", service.DashboardClient)
+		}
+
+		if len(service.Plans) == 0 {
+			v.errorf(path+".plans", "a service must have at least one plan")
+		}
+		planNames := map[string]string{}
+		for j := range service.Plans {
+			v.validatePlan(fmt.Sprintf("%v.plans[%d]", path, j), &service.Plans[j], version, planIDs, planNames)
+		}
+	}
+
+	return v.violations
+}
+
+type catalogValidation struct {
+	violations []CatalogViolation
+}
+
+func (v *catalogValidation) errorf(path, format string, args ...interface{}) {
+	v.violations = append(v.violations, CatalogViolation{Severity: SeverityError, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *catalogValidation) warningf(path, format string, args ...interface{}) {
+	v.violations = append(v.violations, CatalogViolation{Severity: SeverityWarning, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// validateID validates that an ID is set and unique among the IDs seen, which
+// map to the paths they were seen at.
+func (v *catalogValidation) validateID(path, id string, seen map[string]string, kind string) {
+	if id == "" {
+		v.errorf(path, "%v ID is required", kind)
+		return
+	}
+	if other, ok := seen[id]; ok {
+		v.errorf(path, "%v ID %q is not unique; it is also the ID at %v", kind, id, other)
+		return
+	}
+	seen[id] = path
+}
+
+// validateName validates that a name is set, unique among the names seen and
+// CLI-friendly.
+func (v *catalogValidation) validateName(path, name string, seen map[string]string, kind string) {
+	if name == "" {
+		v.errorf(path, "%v name is required", kind)
+		return
+	}
+	if other, ok := seen[name]; ok {
+		v.errorf(path, "%v name %q is not unique; it is also the name at %v", kind, name, other)
+	} else {
+		seen[name] = path
+	}
+	if !cliFriendlyNamePattern.MatchString(name) {
+		v.warningf(path, "%v name %q should be CLI-friendly: lowercase, without spaces", kind, name)
+	}
+}
+
+func (v *catalogValidation) validateDashboardClient(path string, client *DashboardClient) {
+	if client.ID == "" {
+		v.errorf(path+".id", "dashboard client ID is required")
+	}
+	if client.Secret == "" {
+		v.errorf(path+".secret", "dashboard client secret is required")
+	}
+	if client.RedirectURI != "" {
+		if u, err := url.Parse(client.RedirectURI); err != nil || !u.IsAbs() {
+			v.errorf(path+".redirect_uri", "dashboard client redirect URI %q is not an absolute URI", client.RedirectURI)
+		}
+	}
+}
+
+func (v *catalogValidation) validatePlan(path string, plan *Plan, version APIVersion, planIDs, planNames map[string]string) {
+	v.validateID(path+".id", plan.ID, planIDs, "plan")
+	v.validateName(path+".name", plan.Name, planNames, "plan")
+	if plan.Description == "" {
+		v.errorf(path+".description", "description is required")
+	}
+	if plan.MaximumPollingDuration != nil && *plan.MaximumPollingDuration <= 0 {
+		v.errorf(path+".maximum_polling_duration", "maximum polling duration must be positive, not %d", *plan.MaximumPollingDuration)
+	}
+	if plan.MaintenanceInfo != nil && version.AtLeast(Version2_15()) && !semanticVersionPattern.MatchString(plan.MaintenanceInfo.Version) {
+		v.errorf(path+".maintenance_info.version", "maintenance info version %q is not a semantic version", plan.MaintenanceInfo.Version)
+	}
+
+	if plan.Schemas == nil || !version.AtLeast(Version2_13()) {
+		return
+	}
+	if instance := plan.Schemas.ServiceInstance; instance != nil {
+		v.validateSchema(path+".schemas.service_instance.create", instance.Create)
+		v.validateSchema(path+".schemas.service_instance.update", instance.Update)
+	}
+	if binding := plan.Schemas.ServiceBinding; binding != nil {
+		v.validateSchema(path+".schemas.service_binding.create", binding.Create)
+	}
+}
+
+// validateSchema validates that the parameters schema of a plan is a
+// well-formed JSON Schema that declares its version.
+func (v *catalogValidation) validateSchema(path string, schema *InputParametersSchema) {
+	if schema == nil || schema.Parameters == nil {
+		return
+	}
+	path += ".parameters"
+
+	object, ok := schema.Parameters.(map[string]interface{})
+	if !ok {
+		v.errorf(path, "schema must be a JSON object")
+		return
+	}
+	if _, err := jsonschema.Compile(object); err != nil {
+		v.errorf(path, "invalid schema: %v", err)
+	}
+	if _, ok := object["$schema"].(string); !ok {
+		v.warningf(path+".$schema", "schema should declare the version of JSON Schema it uses in $schema; it is validated as draft-04")
+	}
+}
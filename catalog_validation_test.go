@@ -0,0 +1,231 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testValidCatalog() *CatalogResponse {
+	return &CatalogResponse{
+		Services: []Service{
+			{
+				ID:          "database-id",
+				Name:        "database",
+				Description: "a database",
+				Requires:    []string{"syslog_drain"},
+				DashboardClient: &DashboardClient{
+					ID:          "client-id",
+					Secret:      "client-secret",
+					RedirectURI: "https://dashboard.example.com",
+				},
+				Plans: []Plan{
+					{
+						ID:              "database-small-id",
+						Name:            "small",
+						Description:     "a small database",
+						MaintenanceInfo: &MaintenanceInfo{Version: "1.2.3-rc.1+build.5"},
+						Schemas: &Schemas{
+							ServiceInstance: &ServiceInstanceSchema{
+								Create: &InputParametersSchema{
+									Parameters: map[string]interface{}{
+										"$schema": "http://json-schema.org/draft-04/schema#",
+										"type":    "object",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			{
+				ID:          "queue-id",
+				Name:        "queue",
+				Description: "a queue",
+				Plans: []Plan{
+					{ID: "queue-small-id", Name: "small", Description: "a small queue"},
+				},
+			},
+		},
+	}
+}
+
+func TestValidateCatalogValid(t *testing.T) {
+	if violations := ValidateCatalog(testValidCatalog(), LatestAPIVersion()); violations != nil {
+		t.Errorf("unexpected violations: %v", violations)
+	}
+}
+
+func TestValidateCatalog(t *testing.T) {
+	cases := []struct {
+		name     string
+		version  APIVersion
+		modify   func(*CatalogResponse)
+		expected []CatalogViolation
+	}{
+		{
+			name: "missing service fields",
+			modify: func(c *CatalogResponse) {
+				c.Services[1] = Service{}
+			},
+			expected: []CatalogViolation{
+				{Severity: SeverityError, Path: "services[1].id", Message: "service ID is required"},
+				{Severity: SeverityError, Path: "services[1].name", Message: "service name is required"},
+				{Severity: SeverityError, Path: "services[1].description", Message: "description is required"},
+				{Severity: SeverityError, Path: "services[1].plans", Message: "a service must have at least one plan"},
+			},
+		},
+		{
+			name: "duplicate service ID and name",
+			modify: func(c *CatalogResponse) {
+				c.Services[1].ID = "database-id"
+				c.Services[1].Name = "database"
+			},
+			expected: []CatalogViolation{
+				{Severity: SeverityError, Path: "services[1].id", Message: `service ID "database-id" is not unique; it is also the ID at services[0].id`},
+				{Severity: SeverityError, Path: "services[1].name", Message: `service name "database" is not unique; it is also the name at services[0].name`},
+			},
+		},
+		{
+			name: "duplicate plan ID across services",
+			modify: func(c *CatalogResponse) {
+				c.Services[1].Plans[0].ID = "database-small-id"
+			},
+			expected: []CatalogViolation{
+				{Severity: SeverityError, Path: "services[1].plans[0].id", Message: `plan ID "database-small-id" is not unique; it is also the ID at services[0].plans[0].id`},
+			},
+		},
+		{
+			name: "duplicate plan name within a service",
+			modify: func(c *CatalogResponse) {
+				c.Services[1].Plans = append(c.Services[1].Plans, Plan{ID: "queue-other-id", Name: "small", Description: "another small queue"})
+			},
+			expected: []CatalogViolation{
+				{Severity: SeverityError, Path: "services[1].plans[1].name", Message: `plan name "small" is not unique; it is also the name at services[1].plans[0].name`},
+			},
+		},
+		{
+			name: "names that are not CLI-friendly",
+			modify: func(c *CatalogResponse) {
+				c.Services[1].Name = "My Queue"
+				c.Services[1].Plans[0].Name = "Small"
+			},
+			expected: []CatalogViolation{
+				{Severity: SeverityWarning, Path: "services[1].name", Message: `service name "My Queue" should be CLI-friendly: lowercase, without spaces`},
+				{Severity: SeverityWarning, Path: "services[1].plans[0].name", Message: `plan name "Small" should be CLI-friendly: lowercase, without spaces`},
+			},
+		},
+		{
+			name: "unknown permission",
+			modify: func(c *CatalogResponse) {
+				c.Services[0].Requires = append(c.Services[0].Requires, "network")
+			},
+			expected: []CatalogViolation{
+				{Severity: SeverityWarning, Path: "services[0].requires[1]", Message: `unknown permission "network"; valid permissions are syslog_drain, route_forwarding and volume_mount`},
+			},
+		},
+		{
+			name: "invalid dashboard client",
+			modify: func(c *CatalogResponse) {
+				c.Services[0].DashboardClient = &DashboardClient{RedirectURI: "/dashboard"}
+			},
+			expected: []CatalogViolation{
+				{Severity: SeverityError, Path: "services[0].dashboard_client.id", Message: "dashboard client ID is required"},
+				{Severity: SeverityError, Path: "services[0].dashboard_client.secret", Message: "dashboard client secret is required"},
+				{Severity: SeverityError, Path: "services[0].dashboard_client.redirect_uri", Message: `dashboard client redirect URI "/dashboard" is not an absolute URI`},
+			},
+		},
+		{
+			name: "invalid plan fields",
+			modify: func(c *CatalogResponse) {
+				duration := int64(0)
+				c.Services[0].Plans[0].Description = ""
+				c.Services[0].Plans[0].MaximumPollingDuration = &duration
+				c.Services[0].Plans[0].MaintenanceInfo.Version = "1.2"
+			},
+			expected: []CatalogViolation{
+				{Severity: SeverityError, Path: "services[0].plans[0].description", Message: "description is required"},
+				{Severity: SeverityError, Path: "services[0].plans[0].maximum_polling_duration", Message: "maximum polling duration must be positive, not 0"},
+				{Severity: SeverityError, Path: "services[0].plans[0].maintenance_info.version", Message: `maintenance info version "1.2" is not a semantic version`},
+			},
+		},
+		{
+			name:    "maintenance info version before 2.15",
+			version: Version2_14(),
+			modify: func(c *CatalogResponse) {
+				c.Services[0].Plans[0].MaintenanceInfo.Version = "1.2"
+			},
+		},
+		{
+			name: "invalid schemas",
+			modify: func(c *CatalogResponse) {
+				c.Services[0].Plans[0].Schemas.ServiceInstance.Update = &InputParametersSchema{
+					Parameters: map[string]interface{}{"type": "object"},
+				}
+				c.Services[0].Plans[0].Schemas.ServiceBinding = &ServiceBindingSchema{
+					Create: &InputParametersSchema{Parameters: "object"},
+				}
+			},
+			expected: []CatalogViolation{
+				{Severity: SeverityWarning, Path: "services[0].plans[0].schemas.service_instance.update.parameters.$schema", Message: "schema should declare the version of JSON Schema it uses in $schema; it is validated as draft-04"},
+				{Severity: SeverityError, Path: "services[0].plans[0].schemas.service_binding.create.parameters", Message: "schema must be a JSON object"},
+			},
+		},
+		{
+			name:    "schemas before 2.13",
+			version: Version2_12(),
+			modify: func(c *CatalogResponse) {
+				c.Services[0].Plans[0].Schemas.ServiceBinding = &ServiceBindingSchema{
+					Create: &InputParametersSchema{Parameters: "object"},
+				}
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		version := tc.version
+		if version.label == "" {
+			version = LatestAPIVersion()
+		}
+		catalog := testValidCatalog()
+		tc.modify(catalog)
+
+		if e, a := tc.expected, ValidateCatalog(catalog, version); !reflect.DeepEqual(e, a) {
+			t.Errorf("%v: unexpected violations;\nexpected %+v\ngot      %+v", tc.name, e, a)
+		}
+	}
+}
+
+func TestValidateCatalogMalformedSchema(t *testing.T) {
+	catalog := testValidCatalog()
+	catalog.Services[0].Plans[0].Schemas.ServiceInstance.Create.Parameters = map[string]interface{}{
+		"$schema": "http://json-schema.org/draft-04/schema#",
+		"pattern": "(",
+	}
+
+	violations := ValidateCatalog(catalog, LatestAPIVersion())
+	if len(violations) != 1 {
+		t.Fatalf("expected one violation, got %v", violations)
+	}
+	if e, a := SeverityError, violations[0].Severity; e != a {
+		t.Errorf("unexpected severity; expected %v, got %v", e, a)
+	}
+	if e, a := "services[0].plans[0].schemas.service_instance.create.parameters", violations[0].Path; e != a {
+		t.Errorf("unexpected path; expected %v, got %v", e, a)
+	}
+}
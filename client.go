@@ -18,6 +18,7 @@ package v2
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
@@ -31,7 +32,7 @@ import (
 	"strings"
 	"time"
 
-	"k8s.io/klog/v2"
+	"github.com/go-logr/logr"
 )
 
 const (
@@ -41,6 +42,9 @@ const (
 	// OriginatingIdentityHeader is the header associated with originating
 	// identity.
 	OriginatingIdentityHeader = "X-Broker-API-Originating-Identity"
+	// RequestIdentityHeader is the header used to correlate the logs of a
+	// request on the platform and on the broker.
+	RequestIdentityHeader = "X-Broker-API-Request-Identity"
 	// PollingDelayHeader is the header used by the brokers to tell the clients
 	// how many seconds they should wait before retrying the polling
 	PollingDelayHeader = "Retry-After"
@@ -59,6 +63,101 @@ func NewClient(config *ClientConfiguration) (Client, error) {
 		Timeout: time.Duration(config.TimeoutSeconds) * time.Second,
 	}
 
+	if config.Transport != nil {
+		if config.TLSConfig != nil || config.Insecure || len(config.CAData) != 0 || config.CertFile != "" || config.KeyFile != "" || config.CAFile != "" {
+			return nil, errors.New("Cannot specify a Transport together with TLS configuration")
+		}
+		httpClient.Transport = config.Transport
+	} else {
+		transport, err := newTransport(config)
+		if err != nil {
+			return nil, err
+		}
+		httpClient.Transport = transport
+	}
+	httpClient.Transport = chainMiddleware(httpClient.Transport, config.Middleware)
+
+	if config.RetryPolicy != nil && (config.RetryPolicy.Jitter < 0 || config.RetryPolicy.Jitter > 1) {
+		return nil, errors.New("RetryPolicy jitter must be between 0 and 1")
+	}
+
+	c := &client{
+		Name:                   config.Name,
+		URL:                    strings.TrimRight(config.URL, "/"),
+		APIVersion:             config.APIVersion,
+		EnableAlphaFeatures:    config.EnableAlphaFeatures,
+		Verbose:                config.Verbose,
+		RetryPolicy:            config.RetryPolicy,
+		EnableOrphanMitigation: config.EnableOrphanMitigation,
+		MetricsRecorder:        config.MetricsRecorder,
+		Tracer:                 config.Tracer,
+		RequestValidator:       config.RequestValidator,
+		Logger:                 config.Logger,
+		httpClient:             httpClient,
+	}
+	c.doRequestFunc = c.doRequest
+
+	logRedactPaths, err := parseLogRedactPaths(config.LogRedactPaths)
+	if err != nil {
+		return nil, err
+	}
+	c.logRedactPaths = logRedactPaths
+
+	if config.AuthConfig != nil {
+		configured := 0
+		if config.AuthConfig.BasicAuthConfig != nil {
+			configured++
+		}
+		if config.AuthConfig.BearerConfig != nil {
+			configured++
+		}
+		if config.AuthConfig.OAuth2ClientCredentialsConfig != nil {
+			configured++
+		}
+		if config.AuthConfig.AuthProvider != nil {
+			configured++
+		}
+		if configured == 0 {
+			return nil, errors.New("Non-nil AuthConfig cannot be empty")
+		}
+		if configured > 1 {
+			return nil, errors.New("Only one AuthConfig implementation must be set at a time")
+		}
+
+		if oauth2 := config.AuthConfig.OAuth2ClientCredentialsConfig; oauth2 != nil {
+			if oauth2.TokenURL == "" {
+				return nil, errors.New("OAuth2ClientCredentialsConfig requires a TokenURL")
+			}
+			if oauth2.ClientID == "" {
+				return nil, errors.New("OAuth2ClientCredentialsConfig requires a ClientID")
+			}
+			tokenClient, err := newOAuth2HTTPClient(oauth2, httpClient.Timeout)
+			if err != nil {
+				return nil, err
+			}
+			c.tokenSource = newOAuth2TokenSource(oauth2, tokenClient.Do)
+		}
+
+		c.AuthConfig = config.AuthConfig
+	}
+
+	if config.NegotiateAPIVersion {
+		version, err := c.negotiateAPIVersion(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		c.APIVersion = version
+	}
+
+	return c, nil
+}
+
+var _ CreateFunc = NewClient
+
+// newTransport returns the http.RoundTripper used by a client created without
+// a Transport in its configuration.  It is an *http.Transport unless the root
+// CAs are loaded from CAFile.
+func newTransport(config *ClientConfiguration) (http.RoundTripper, error) {
 	// use default values lifted from DefaultTransport
 	transport := &http.Transport{
 		Proxy: http.ProxyFromEnvironment,
@@ -73,70 +172,82 @@ func NewClient(config *ClientConfiguration) (Client, error) {
 		ExpectContinueTimeout: 1 * time.Second,
 	}
 
+	// The TLSConfig may be shared by the clients of several brokers, so it
+	// is copied before the settings of this client are applied to it.
 	if config.TLSConfig != nil {
-		transport.TLSClientConfig = config.TLSConfig
+		transport.TLSClientConfig = config.TLSConfig.Clone()
 	} else {
 		transport.TLSClientConfig = &tls.Config{}
 	}
 	if config.Insecure {
 		transport.TLSClientConfig.InsecureSkipVerify = true
 	}
-	if len(config.CAData) != 0 {
-		if transport.TLSClientConfig.RootCAs == nil {
-			transport.TLSClientConfig.RootCAs = x509.NewCertPool()
+	if len(config.CAData) != 0 && config.CAFile == "" {
+		// A copy of the TLSConfig shares its pool of root CAs, which cannot
+		// be copied, so CAData cannot be added to it.
+		if transport.TLSClientConfig.RootCAs != nil {
+			return nil, errors.New("Cannot specify CAData together with TLSConfig.RootCAs")
 		}
+		transport.TLSClientConfig.RootCAs = x509.NewCertPool()
 		transport.TLSClientConfig.RootCAs.AppendCertsFromPEM(config.CAData)
 	}
 	if transport.TLSClientConfig.InsecureSkipVerify && transport.TLSClientConfig.RootCAs != nil {
 		return nil, errors.New("Cannot specify root CAs and to skip TLS verification")
 	}
-	httpClient.Transport = transport
-
-	c := &client{
-		Name:                config.Name,
-		URL:                 strings.TrimRight(config.URL, "/"),
-		APIVersion:          config.APIVersion,
-		EnableAlphaFeatures: config.EnableAlphaFeatures,
-		Verbose:             config.Verbose,
-		httpClient:          httpClient,
+	if err := configureCertificateFiles(config, transport.TLSClientConfig); err != nil {
+		return nil, err
 	}
-	c.doRequestFunc = c.doRequest
 
-	if config.AuthConfig != nil {
-		if config.AuthConfig.BasicAuthConfig == nil && config.AuthConfig.BearerConfig == nil {
-			return nil, errors.New("Non-nil AuthConfig cannot be empty")
+	if config.CAFile != "" {
+		if transport.TLSClientConfig.InsecureSkipVerify {
+			return nil, errors.New("Cannot specify root CAs and to skip TLS verification")
 		}
-		if config.AuthConfig.BasicAuthConfig != nil && config.AuthConfig.BearerConfig != nil {
-			return nil, errors.New("Only one AuthConfig implementation must be set at a time")
+		if transport.TLSClientConfig.RootCAs != nil {
+			return nil, errors.New("Cannot specify CAFile together with TLSConfig.RootCAs")
 		}
-
-		c.AuthConfig = config.AuthConfig
+		reloading, err := newRootCAReloadingTransport(config.CAFile, config.CAData, transport)
+		if err != nil {
+			return nil, err
+		}
+		return reloading, nil
 	}
 
-	return c, nil
+	return transport, nil
 }
 
-var _ CreateFunc = NewClient
-
 type doRequestFunc func(request *http.Request) (*http.Response, error)
 
 // client provides a functional implementation of the Client interface.
 type client struct {
-	Name                string
-	URL                 string
-	APIVersion          APIVersion
-	AuthConfig          *AuthConfig
-	EnableAlphaFeatures bool
-	Verbose             bool
-
-	httpClient    *http.Client
-	doRequestFunc doRequestFunc
+	Name                   string
+	URL                    string
+	APIVersion             APIVersion
+	AuthConfig             *AuthConfig
+	EnableAlphaFeatures    bool
+	Verbose                bool
+	RetryPolicy            *RetryPolicy
+	EnableOrphanMitigation bool
+	MetricsRecorder        MetricsRecorder
+	Tracer                 Tracer
+	RequestValidator       RequestValidator
+	Logger                 logr.Logger
+
+	logRedactPaths [][]string
+	httpClient     *http.Client
+	doRequestFunc  doRequestFunc
+	tokenSource    *oauth2TokenSource
+	// requestIdentityFunc, if set, replaces newRequestIdentity, so that
+	// tests can expect a known request identity.
+	requestIdentityFunc func() (string, error)
 }
 
 var _ Client = &client{}
+var _ ClientWithContext = &client{}
+var _ APIVersionGetter = &client{}
 
 // This file contains shared methods used by each interface method of the
-// Client interface.  Individual interface methods are in the following files:
+// Client interface.  Individual interface methods, along with their
+// ClientWithContext variants, are in the following files:
 //
 // GetCatalog: get_catalog.go
 // ProvisionInstance: provision_instance.go
@@ -151,15 +262,20 @@ const (
 	jsonType    = "application/json"
 )
 
-// prepareAndDo prepares a request for the given method, URL, and
-// message body, and executes the request, returning an http.Response or an
+// prepareAndDo prepares a request for the given operation, method, URL, and
+// message body, identified by the given request identity or a new one if it
+// is empty, and executes the request, returning an http.Response or an
 // error.  Errors returned from this function represent http-layer errors and
-// not errors in the Open Service Broker API.
-func (c *client) prepareAndDo(method, URL string, params map[string]string, body interface{}, originatingIdentity *OriginatingIdentity) (*http.Response, error) {
+// not errors in the Open Service Broker API.  If the request fails because
+// the given context was canceled or its deadline expired, a ContextError is
+// returned.
+func (c *client) prepareAndDo(ctx context.Context, operation Operation, method, URL string, params map[string]string, body interface{}, originatingIdentity *OriginatingIdentity, requestIdentity string) (*http.Response, error) {
 	var bodyReader io.Reader
+	var bodyBytes []byte
 
 	if body != nil {
-		bodyBytes, err := json.Marshal(body)
+		var err error
+		bodyBytes, err = json.Marshal(body)
 		if err != nil {
 			return nil, err
 		}
@@ -167,7 +283,7 @@ func (c *client) prepareAndDo(method, URL string, params map[string]string, body
 		bodyReader = bytes.NewReader(bodyBytes)
 	}
 
-	request, err := http.NewRequest(method, URL, bodyReader)
+	request, err := http.NewRequestWithContext(withOperation(ctx, operation), method, URL, bodyReader)
 	if err != nil {
 		return nil, err
 	}
@@ -184,6 +300,19 @@ func (c *client) prepareAndDo(method, URL string, params map[string]string, body
 		} else if c.AuthConfig.BearerConfig != nil {
 			bearer := c.AuthConfig.BearerConfig
 			request.Header.Set("Authorization", "Bearer "+bearer.Token)
+		} else if c.tokenSource != nil {
+			token, err := c.tokenSource.Token(ctx, false)
+			if err != nil {
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					return nil, ContextError{Err: ctxErr}
+				}
+				return nil, err
+			}
+			request.Header.Set("Authorization", "Bearer "+token)
+		} else if c.AuthConfig.AuthProvider != nil {
+			if err := c.AuthConfig.AuthProvider.Authenticate(request); err != nil {
+				return nil, err
+			}
 		}
 	}
 
@@ -195,6 +324,18 @@ func (c *client) prepareAndDo(method, URL string, params map[string]string, body
 		request.Header.Set(OriginatingIdentityHeader, headerValue)
 	}
 
+	if c.APIVersion.AtLeast(Version2_15()) {
+		if requestIdentity == "" {
+			requestIdentity, err = c.generateRequestIdentity()
+			if err != nil {
+				return nil, err
+			}
+		}
+		request.Header.Set(RequestIdentityHeader, requestIdentity)
+	}
+
+	injectTraceContext(request)
+
 	if params != nil {
 		q := request.URL.Query()
 		for k, v := range params {
@@ -203,11 +344,34 @@ func (c *client) prepareAndDo(method, URL string, params map[string]string, body
 		request.URL.RawQuery = q.Encode()
 	}
 
-	if c.Verbose {
-		klog.Infof("broker %q: doing request to %q", c.Name, URL)
+	c.logAt(LogLevelRequests).Info("doing request", "method", method, "url", URL)
+	if logger, ok := c.bodyLogger(); ok {
+		keysAndValues := []interface{}{"headers", redactHeaderForLog(request.Header)}
+		if bodyBytes != nil {
+			keysAndValues = append(keysAndValues, "body", c.redactBodyForLog(bodyBytes))
+		}
+		logger.Info("request", keysAndValues...)
+	}
+
+	response, err := c.doWithRetry(request)
+	if err == nil && response.StatusCode == http.StatusUnauthorized && c.tokenSource != nil {
+		c.logAt(LogLevelRequests).Info("request was unauthorized; retrying with a new OAuth2 token", "url", URL)
+		response, err = c.retryWithRefreshedToken(request, response)
+	}
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ContextError{Err: ctxErr}
+		}
+		return nil, err
+	}
+	if response.Request == nil {
+		response.Request = request
 	}
 
-	return c.doRequestFunc(request)
+	c.logAt(LogLevelResponses).Info("received response", "method", method, "url", URL, "status", response.StatusCode)
+	spanFromContext(ctx).SetAttribute(AttributeHTTPStatusCode, response.StatusCode)
+
+	return response, nil
 }
 
 func (c *client) doRequest(request *http.Request) (*http.Response, error) {
@@ -215,15 +379,22 @@ func (c *client) doRequest(request *http.Request) (*http.Response, error) {
 }
 
 // unmarshalResponse unmarshals the response body of the given response into
-// the given object or returns an error.
+// the given object or returns an error.  If reading the body fails because the
+// context of the request was canceled or its deadline expired, a ContextError
+// is returned.
 func (c *client) unmarshalResponse(response *http.Response, obj interface{}) error {
 	body, err := ioutil.ReadAll(response.Body)
 	if err != nil {
+		if response.Request != nil {
+			if ctxErr := response.Request.Context().Err(); ctxErr != nil {
+				return ContextError{Err: ctxErr}
+			}
+		}
 		return err
 	}
 
-	if c.Verbose {
-		klog.Infof("broker %q: response body: %v, type: %T", c.Name, string(body), obj)
+	if logger, ok := c.bodyLogger(); ok {
+		logger.Info("response body", "body", c.redactBodyForLog(body), "type", fmt.Sprintf("%T", obj))
 	}
 
 	err = json.Unmarshal(body, obj)
@@ -234,17 +405,29 @@ func (c *client) unmarshalResponse(response *http.Response, obj interface{}) err
 	return nil
 }
 
+// unmarshalError returns the error to return for a response whose body could
+// not be unmarshaled: an HTTPStatusCodeError, or the ContextError returned by
+// unmarshalResponse if the request was abandoned while reading the body.
+func unmarshalError(response *http.Response, err error) error {
+	if contextErr, ok := err.(ContextError); ok {
+		return contextErr
+	}
+	return HTTPStatusCodeError{StatusCode: response.StatusCode, ResponseError: err, RequestIdentity: requestIdentity(response)}
+}
+
 // handleFailureResponse returns an HTTPStatusCodeError for the given
 // response.
 func (c *client) handleFailureResponse(response *http.Response) error {
-	klog.Info("handling failure responses")
-
 	httpErr := HTTPStatusCodeError{
-		StatusCode: response.StatusCode,
+		StatusCode:      response.StatusCode,
+		RequestIdentity: requestIdentity(response),
 	}
 
 	brokerResponse := make(map[string]interface{})
 	if err := c.unmarshalResponse(response, &brokerResponse); err != nil {
+		if contextErr, ok := err.(ContextError); ok {
+			return contextErr
+		}
 		httpErr.ResponseError = err
 		return httpErr
 	}
@@ -299,6 +482,13 @@ func (c *client) validateClientVersionIsAtLeast(version APIVersion) error {
 	return nil
 }
 
+// maintenanceInfoAllowed returns whether maintenance_info may be sent to and
+// returned from the broker: it became part of the spec in 2.15 and is
+// available as an alpha feature before that.
+func (c *client) maintenanceInfoAllowed() bool {
+	return c.APIVersion.AtLeast(Version2_15()) || c.EnableAlphaFeatures
+}
+
 // drainReader reads and discards the remaining data in reader (for example
 // response body data) For HTTP this ensures that the http connection
 // could be reused for another request if the keepalive is enabled.
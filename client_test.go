@@ -18,12 +18,16 @@ package v2
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"reflect"
 	"testing"
+	"time"
 )
 
 const malformedResponse = `{`
@@ -54,11 +58,24 @@ func testHTTPStatusCodeError() error {
 	}
 }
 
+// testHTTPStatusCodeErrorWithRequestIdentity returns the error of
+// testHTTPStatusCodeError for a request sent with testRequestIdentity.
+func testHTTPStatusCodeErrorWithRequestIdentity() error {
+	err := testHTTPStatusCodeError().(HTTPStatusCodeError)
+	err.RequestIdentity = testRequestIdentity
+	return err
+}
+
 func truePtr() *bool {
 	b := true
 	return &b
 }
 
+func falsePtr() *bool {
+	b := false
+	return &b
+}
+
 func closer(s string) io.ReadCloser {
 	return nopCloser{bytes.NewBufferString(s)}
 }
@@ -83,6 +100,10 @@ type httpReaction struct {
 	header http.Header
 }
 
+// testRequestIdentity is the request identity test clients send with
+// requests of API version 2.15 or later made without one.
+const testRequestIdentity = "test-request-identity"
+
 func newTestClient(t *testing.T, name string, version APIVersion, enableAlpha bool, httpChecks httpChecks, httpReaction httpReaction) *client {
 	return &client{
 		Name:                "test client",
@@ -91,6 +112,9 @@ func newTestClient(t *testing.T, name string, version APIVersion, enableAlpha bo
 		URL:                 "https://example.com",
 		EnableAlphaFeatures: enableAlpha,
 		doRequestFunc:       doHTTP(t, name, httpChecks, httpReaction),
+		requestIdentityFunc: func() (string, error) {
+			return testRequestIdentity, nil
+		},
 	}
 }
 
@@ -134,9 +158,19 @@ func doHTTP(t *testing.T, name string, checks httpChecks, reaction httpReaction)
 			return nil, errWalkingGhost
 		}
 
+		// Like a broker, echo the request identity unless the reaction
+		// sets one.
+		header := reaction.header.Clone()
+		if identity := request.Header.Get(RequestIdentityHeader); identity != "" && header.Get(RequestIdentityHeader) == "" {
+			if header == nil {
+				header = http.Header{}
+			}
+			header.Set(RequestIdentityHeader, identity)
+		}
+
 		return &http.Response{
 			StatusCode: reaction.status,
-			Header:     reaction.header,
+			Header:     header,
 			Body:       closer(reaction.body),
 		}, reaction.err
 	}
@@ -292,3 +326,150 @@ func TestHandleFailureResponse(t *testing.T) {
 		}
 	}
 }
+
+// doContextAwareHTTP returns a doRequestFunc that behaves like an HTTP
+// transport blocked on a slow broker: it returns only when the request's
+// context is done.
+func doContextAwareHTTP() doRequestFunc {
+	return func(request *http.Request) (*http.Response, error) {
+		<-request.Context().Done()
+		return nil, &url.Error{Op: request.Method, URL: request.URL.String(), Err: request.Context().Err()}
+	}
+}
+
+func TestPrepareAndDoWithContext(t *testing.T) {
+	cases := []struct {
+		name             string
+		ctx              func() (context.Context, context.CancelFunc)
+		canceled         bool
+		deadlineExceeded bool
+	}{
+		{
+			name: "canceled",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx, cancel
+			},
+			canceled: true,
+		},
+		{
+			name: "deadline exceeded",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), time.Millisecond)
+			},
+			deadlineExceeded: true,
+		},
+	}
+
+	for _, tc := range cases {
+		klient := newTestClient(t, tc.name, Version2_11(), false, httpChecks{}, httpReaction{})
+		klient.doRequestFunc = doContextAwareHTTP()
+
+		ctx, cancel := tc.ctx()
+		_, err := klient.GetCatalogWithContext(ctx)
+		cancel()
+
+		if err == nil {
+			t.Errorf("%v: expected error, got none", tc.name)
+			continue
+		}
+		if e, a := tc.canceled, IsCanceledError(err); e != a {
+			t.Errorf("%v: unexpected IsCanceledError result; expected %v, got %v (error: %v)", tc.name, e, a, err)
+		}
+		if e, a := tc.deadlineExceeded, IsDeadlineExceededError(err); e != a {
+			t.Errorf("%v: unexpected IsDeadlineExceededError result; expected %v, got %v (error: %v)", tc.name, e, a, err)
+		}
+	}
+}
+
+// canceledBodyReader is a response body whose read fails because the context
+// of the request was canceled while it was being read.
+type canceledBodyReader struct {
+	cancel context.CancelFunc
+}
+
+func (r canceledBodyReader) Read(p []byte) (int, error) {
+	r.cancel()
+	return 0, errors.New("read on closed response body")
+}
+
+func (r canceledBodyReader) Close() error {
+	return nil
+}
+
+func TestUnmarshalResponseWithContext(t *testing.T) {
+	cases := []struct {
+		name       string
+		statusCode int
+	}{
+		{name: "success", statusCode: http.StatusOK},
+		{name: "failure", statusCode: http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		ctx, cancel := context.WithCancel(context.Background())
+		klient := newTestClient(t, tc.name, Version2_11(), false, httpChecks{}, httpReaction{})
+		klient.doRequestFunc = func(request *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: tc.statusCode, Body: canceledBodyReader{cancel: cancel}}, nil
+		}
+
+		_, err := klient.GetCatalogWithContext(ctx)
+		cancel()
+
+		if _, ok := err.(ContextError); !ok {
+			t.Errorf("%v: expected a ContextError, got %#v", tc.name, err)
+		}
+		if !IsCanceledError(err) {
+			t.Errorf("%v: expected a canceled error, got %v", tc.name, err)
+		}
+	}
+}
+
+func TestPrepareAndDoPropagatesContext(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+
+	klient := newTestClient(t, "propagates context", Version2_14(), false, httpChecks{}, httpReaction{})
+	klient.doRequestFunc = func(request *http.Request) (*http.Response, error) {
+		if e, a := "value", request.Context().Value(key{}); e != a {
+			t.Errorf("unexpected context value; expected %v, got %v", e, a)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: closer(`{}`)}, nil
+	}
+
+	if _, err := klient.GetInstanceWithContext(ctx, &GetInstanceRequest{InstanceID: testInstanceID}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestNewClientTransport(t *testing.T) {
+	var requested string
+	transport := RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		requested = request.URL.String()
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(`{"services":[]}`)),
+		}, nil
+	})
+
+	config := DefaultClientConfiguration()
+	config.URL = "https://example.com"
+	config.Transport = transport
+
+	klient, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := klient.GetCatalog(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := "https://example.com/v2/catalog", requested; e != a {
+		t.Errorf("expected request to %v through the transport, got %v", e, a)
+	}
+
+	config.Insecure = true
+	if _, err := NewClient(config); err == nil {
+		t.Error("expected error for Transport combined with TLS configuration")
+	}
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// contextPlatformKey is the key of the platform in a request context.
+const contextPlatformKey = "platform"
+
+// KubernetesContext is the Kubernetes profile of the Context of provision,
+// update and bind requests.
+type KubernetesContext struct {
+	// Platform must be PlatformKubernetes.
+	Platform string `json:"platform"`
+	// Namespace is the name of the namespace the instance or binding is
+	// created in.
+	Namespace string `json:"namespace"`
+	// NamespaceUID is the UID of the namespace.  Optional.
+	NamespaceUID string `json:"namespace_uid,omitempty"`
+	// ClusterID is the ID of the cluster.
+	ClusterID string `json:"clusterid"`
+	// InstanceName is the name of the instance.  Optional.
+	InstanceName string `json:"instance_name,omitempty"`
+	// NamespaceAnnotations are the annotations of the namespace.  Optional.
+	NamespaceAnnotations map[string]string `json:"namespace_annotations,omitempty"`
+	// InstanceAnnotations are the annotations of the instance.  Optional.
+	InstanceAnnotations map[string]string `json:"instance_annotations,omitempty"`
+}
+
+// CloudFoundryContext is the Cloud Foundry profile of the Context of
+// provision, update and bind requests.
+type CloudFoundryContext struct {
+	// Platform must be PlatformCloudFoundry.
+	Platform string `json:"platform"`
+	// OrganizationGUID is the GUID of the organization the instance or
+	// binding is created in.
+	OrganizationGUID string `json:"organization_guid"`
+	// OrganizationName is the name of the organization.  Optional.
+	OrganizationName string `json:"organization_name,omitempty"`
+	// SpaceGUID is the GUID of the space the instance or binding is created
+	// in.
+	SpaceGUID string `json:"space_guid"`
+	// SpaceName is the name of the space.  Optional.
+	SpaceName string `json:"space_name,omitempty"`
+	// InstanceName is the name of the instance.  Optional.
+	InstanceName string `json:"instance_name,omitempty"`
+	// OrganizationAnnotations are the annotations of the organization.
+	// Optional.
+	OrganizationAnnotations map[string]string `json:"organization_annotations,omitempty"`
+	// SpaceAnnotations are the annotations of the space.  Optional.
+	SpaceAnnotations map[string]string `json:"space_annotations,omitempty"`
+	// InstanceAnnotations are the annotations of the instance.  Optional.
+	InstanceAnnotations map[string]string `json:"instance_annotations,omitempty"`
+}
+
+// Validate returns an error if the context does not have the Kubernetes
+// platform or is missing a required field.
+func (c *KubernetesContext) Validate() error {
+	if err := validateContextPlatform(c.Platform, PlatformKubernetes); err != nil {
+		return err
+	}
+	if c.Namespace == "" {
+		return required("context namespace")
+	}
+	if c.ClusterID == "" {
+		return required("context clusterid")
+	}
+	return nil
+}
+
+// ToMap validates the context and returns it in the form of the Context
+// field of a request.
+func (c *KubernetesContext) ToMap() (map[string]interface{}, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return contextToMap(c)
+}
+
+// KubernetesContextFromMap returns the Kubernetes profile of the Context of a
+// request, or an error if the context is not a valid Kubernetes context.
+func KubernetesContextFromMap(context map[string]interface{}) (*KubernetesContext, error) {
+	c := &KubernetesContext{}
+	if err := contextFromMap(context, c); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate returns an error if the context does not have the Cloud Foundry
+// platform or is missing a required field.
+func (c *CloudFoundryContext) Validate() error {
+	if err := validateContextPlatform(c.Platform, PlatformCloudFoundry); err != nil {
+		return err
+	}
+	if c.OrganizationGUID == "" {
+		return required("context organization_guid")
+	}
+	if c.SpaceGUID == "" {
+		return required("context space_guid")
+	}
+	return nil
+}
+
+// ToMap validates the context and returns it in the form of the Context
+// field of a request.
+func (c *CloudFoundryContext) ToMap() (map[string]interface{}, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return contextToMap(c)
+}
+
+// CloudFoundryContextFromMap returns the Cloud Foundry profile of the Context
+// of a request, or an error if the context is not a valid Cloud Foundry
+// context.
+func CloudFoundryContextFromMap(context map[string]interface{}) (*CloudFoundryContext, error) {
+	c := &CloudFoundryContext{}
+	if err := contextFromMap(context, c); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ContextPlatform returns the platform of the Context of a request, or an
+// empty string if it has none.
+func ContextPlatform(context map[string]interface{}) string {
+	platform, _ := context[contextPlatformKey].(string)
+	return platform
+}
+
+func validateContextPlatform(platform, expected string) error {
+	if platform != expected {
+		return fmt.Errorf("context platform is %q, not %q", platform, expected)
+	}
+	return nil
+}
+
+// contextToMap converts a typed context to a map by way of its JSON form.
+func contextToMap(c interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	context := map[string]interface{}{}
+	if err := json.Unmarshal(data, &context); err != nil {
+		return nil, err
+	}
+	return context, nil
+}
+
+// contextFromMap converts a map to a typed context by way of its JSON form.
+func contextFromMap(context map[string]interface{}, c interface{}) error {
+	data, err := json.Marshal(context)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("invalid context: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKubernetesContext(t *testing.T) {
+	context := &KubernetesContext{
+		Platform:            PlatformKubernetes,
+		Namespace:           "test-namespace",
+		NamespaceUID:        "test-namespace-uid",
+		ClusterID:           "test-cluster-id",
+		InstanceName:        "test-instance",
+		InstanceAnnotations: map[string]string{"team": "storage"},
+	}
+	expectedMap := map[string]interface{}{
+		"platform":             "kubernetes",
+		"namespace":            "test-namespace",
+		"namespace_uid":        "test-namespace-uid",
+		"clusterid":            "test-cluster-id",
+		"instance_name":        "test-instance",
+		"instance_annotations": map[string]interface{}{"team": "storage"},
+	}
+
+	m, err := context.ToMap()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := expectedMap, m; !reflect.DeepEqual(e, a) {
+		t.Errorf("unexpected map;\nexpected %v\ngot      %v", e, a)
+	}
+	if e, a := PlatformKubernetes, ContextPlatform(m); e != a {
+		t.Errorf("unexpected platform; expected %v, got %v", e, a)
+	}
+
+	parsed, err := KubernetesContextFromMap(m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := context, parsed; !reflect.DeepEqual(e, a) {
+		t.Errorf("unexpected context;\nexpected %+v\ngot      %+v", e, a)
+	}
+}
+
+func TestCloudFoundryContext(t *testing.T) {
+	context := &CloudFoundryContext{
+		Platform:         PlatformCloudFoundry,
+		OrganizationGUID: "test-organization-guid",
+		OrganizationName: "test-organization",
+		SpaceGUID:        "test-space-guid",
+		SpaceName:        "test-space",
+		InstanceName:     "test-instance",
+	}
+	expectedMap := map[string]interface{}{
+		"platform":          "cloudfoundry",
+		"organization_guid": "test-organization-guid",
+		"organization_name": "test-organization",
+		"space_guid":        "test-space-guid",
+		"space_name":        "test-space",
+		"instance_name":     "test-instance",
+	}
+
+	m, err := context.ToMap()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := expectedMap, m; !reflect.DeepEqual(e, a) {
+		t.Errorf("unexpected map;\nexpected %v\ngot      %v", e, a)
+	}
+
+	parsed, err := CloudFoundryContextFromMap(m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := context, parsed; !reflect.DeepEqual(e, a) {
+		t.Errorf("unexpected context;\nexpected %+v\ngot      %+v", e, a)
+	}
+}
+
+func TestContextValidation(t *testing.T) {
+	cases := []struct {
+		name    string
+		context interface {
+			ToMap() (map[string]interface{}, error)
+		}
+	}{
+		{
+			name:    "Kubernetes context without platform",
+			context: &KubernetesContext{Namespace: "ns", ClusterID: "cluster"},
+		},
+		{
+			name:    "Kubernetes context with Cloud Foundry platform",
+			context: &KubernetesContext{Platform: PlatformCloudFoundry, Namespace: "ns", ClusterID: "cluster"},
+		},
+		{
+			name:    "Kubernetes context without namespace",
+			context: &KubernetesContext{Platform: PlatformKubernetes, ClusterID: "cluster"},
+		},
+		{
+			name:    "Kubernetes context without cluster ID",
+			context: &KubernetesContext{Platform: PlatformKubernetes, Namespace: "ns"},
+		},
+		{
+			name:    "Cloud Foundry context with Kubernetes platform",
+			context: &CloudFoundryContext{Platform: PlatformKubernetes, OrganizationGUID: "org", SpaceGUID: "space"},
+		},
+		{
+			name:    "Cloud Foundry context without space GUID",
+			context: &CloudFoundryContext{Platform: PlatformCloudFoundry, OrganizationGUID: "org"},
+		},
+	}
+
+	for _, tc := range cases {
+		if _, err := tc.context.ToMap(); err == nil {
+			t.Errorf("%v: expected an error", tc.name)
+		}
+	}
+}
+
+func TestContextFromMapErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		context map[string]interface{}
+	}{
+		{
+			name:    "other platform",
+			context: map[string]interface{}{"platform": "cloudfoundry", "namespace": "ns", "clusterid": "cluster"},
+		},
+		{
+			name:    "misspelled key",
+			context: map[string]interface{}{"platform": "kubernetes", "namespace": "ns", "cluster_id": "cluster"},
+		},
+		{
+			name:    "wrong type",
+			context: map[string]interface{}{"platform": "kubernetes", "namespace": 1, "clusterid": "cluster"},
+		},
+	}
+
+	for _, tc := range cases {
+		if _, err := KubernetesContextFromMap(tc.context); err == nil {
+			t.Errorf("%v: expected an error", tc.name)
+		}
+	}
+}
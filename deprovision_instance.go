@@ -17,11 +17,19 @@ limitations under the License.
 package v2
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 )
 
 func (c *client) DeprovisionInstance(r *DeprovisionRequest) (*DeprovisionResponse, error) {
+	return c.DeprovisionInstanceWithContext(context.Background(), r)
+}
+
+func (c *client) DeprovisionInstanceWithContext(ctx context.Context, r *DeprovisionRequest) (_ *DeprovisionResponse, err error) {
+	ctx, span := c.startSpan(ctx, OperationDeprovisionInstance, spanIDs{instanceID: r.InstanceID, serviceID: r.ServiceID, planID: r.PlanID})
+	defer func() { endSpan(span, err) }()
+
 	if err := validateDeprovisionRequest(r); err != nil {
 		return nil, err
 	}
@@ -36,7 +44,7 @@ func (c *client) DeprovisionInstance(r *DeprovisionRequest) (*DeprovisionRespons
 		params[AcceptsIncomplete] = "true"
 	}
 
-	response, err := c.prepareAndDo(http.MethodDelete, fullURL, params, nil, r.OriginatingIdentity)
+	response, err := c.prepareAndDo(ctx, OperationDeprovisionInstance, http.MethodDelete, fullURL, params, nil, r.OriginatingIdentity, r.RequestIdentity)
 	if err != nil {
 		return nil, err
 	}
@@ -48,7 +56,7 @@ func (c *client) DeprovisionInstance(r *DeprovisionRequest) (*DeprovisionRespons
 
 	switch response.StatusCode {
 	case http.StatusOK, http.StatusGone:
-		return &DeprovisionResponse{}, nil
+		return &DeprovisionResponse{RequestIdentity: requestIdentity(response)}, nil
 	case http.StatusAccepted:
 		if !r.AcceptsIncomplete {
 			// If the client did not signify that it could handle asynchronous
@@ -69,8 +77,9 @@ func (c *client) DeprovisionInstance(r *DeprovisionRequest) (*DeprovisionRespons
 		}
 
 		userResponse := &DeprovisionResponse{
-			Async:        true,
-			OperationKey: opPtr,
+			Async:           true,
+			OperationKey:    opPtr,
+			RequestIdentity: requestIdentity(response),
 		}
 
 		return userResponse, nil
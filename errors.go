@@ -17,8 +17,35 @@ limitations under the License.
 package v2
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
+)
+
+// Sentinel errors for the conditions the Open Service Broker API specification
+// gives special meaning to.  HTTPStatusCodeErrors match them with errors.Is,
+// even when wrapped:
+//
+//	if errors.Is(err, ErrConcurrency) {
+//		// retry later
+//	}
+var (
+	// ErrGone matches HTTPStatusCodeErrors with a 410 Gone status.
+	ErrGone = errors.New("gone")
+	// ErrConflict matches HTTPStatusCodeErrors with a 409 Conflict status.
+	ErrConflict = errors.New("conflict")
+	// ErrAsyncRequired matches the errors matched by IsAsyncRequiredError.
+	ErrAsyncRequired = errors.New("asynchronous operation required")
+	// ErrAppGUIDRequired matches the errors matched by
+	// IsAppGUIDRequiredError.
+	ErrAppGUIDRequired = errors.New("app GUID required")
+	// ErrConcurrency matches the errors matched by IsConcurrencyError.
+	ErrConcurrency = errors.New("concurrent modification not supported")
+	// ErrMaintenanceInfoConflict matches the errors matched by
+	// IsMaintenanceInfoConflictError.
+	ErrMaintenanceInfoConflict = errors.New("maintenance info conflict")
 )
 
 // HTTPStatusCodeError is an error type that provides additional information
@@ -39,6 +66,11 @@ import (
 // - IsConflictError
 // - IsAsyncRequiredError
 // - IsAppGUIDRequiredError
+// - IsConcurrencyError
+// - IsMaintenanceInfoConflictError
+//
+// The same checks can be made with errors.Is and the corresponding sentinel
+// errors, such as ErrGone.
 type HTTPStatusCodeError struct {
 	// StatusCode is the HTTP status code returned by the broker.
 	StatusCode int
@@ -51,6 +83,9 @@ type HTTPStatusCodeError struct {
 	// ResponseError is set to the error that occurred when unmarshalling a
 	// response body from the broker.
 	ResponseError error
+	// RequestIdentity is the identity of the request that failed, if the
+	// client API version is >= 2.15.
+	RequestIdentity string
 }
 
 func (e HTTPStatusCodeError) Error() string {
@@ -66,123 +101,119 @@ func (e HTTPStatusCodeError) Error() string {
 	return fmt.Sprintf("Status: %v; ErrorMessage: %v; Description: %v; ResponseError: %v", e.StatusCode, errorMessage, description, e.ResponseError)
 }
 
+// Unwrap returns the error that occurred when unmarshalling the response
+// body, if any.
+func (e HTTPStatusCodeError) Unwrap() error {
+	return e.ResponseError
+}
+
+// Is reports whether the error matches target, which should be one of the
+// sentinel errors such as ErrGone.  It is used by errors.Is.
+func (e HTTPStatusCodeError) Is(target error) bool {
+	switch target {
+	case ErrGone:
+		return e.StatusCode == http.StatusGone
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrAsyncRequired:
+		return e.isUnprocessableEntity(AsyncErrorMessage, AsyncErrorDescription)
+	case ErrAppGUIDRequired:
+		return e.isUnprocessableEntity(AppGUIDRequiredErrorMessage, AppGUIDRequiredErrorDescription)
+	case ErrConcurrency:
+		return e.isUnprocessableEntity(ConcurrencyErrorMessage, ConcurrencyErrorDescription)
+	case ErrMaintenanceInfoConflict:
+		// The spec does not mandate a description for this error.
+		return e.isUnprocessableEntity(MaintenanceInfoConflictErrorMessage, "")
+	}
+
+	return false
+}
+
+// isUnprocessableEntity returns whether the error is a 422 Unprocessable
+// Entity response with the given error message and, if description is not
+// empty, the given description.
+func (e HTTPStatusCodeError) isUnprocessableEntity(errorMessage, description string) bool {
+	if e.StatusCode != http.StatusUnprocessableEntity {
+		return false
+	}
+
+	if e.ErrorMessage == nil || *e.ErrorMessage != errorMessage {
+		return false
+	}
+
+	if description == "" {
+		return true
+	}
+
+	return e.Description != nil && *e.Description == description
+}
+
 // IsHTTPError returns whether the error represents an HTTPStatusCodeError.  A
 // client method returning an HTTP error indicates that the broker returned an
 // error code and a correctly formed response body.
 func IsHTTPError(err error) (*HTTPStatusCodeError, bool) {
-	statusCodeError, ok := err.(HTTPStatusCodeError)
-	if ok {
-		return &statusCodeError, ok
+	var statusCodeError HTTPStatusCodeError
+	if errors.As(err, &statusCodeError) {
+		return &statusCodeError, true
 	}
 
-	statusCodeErrorPointer, ok := err.(*HTTPStatusCodeError)
-	if ok {
-		return statusCodeErrorPointer, ok
+	var statusCodeErrorPointer *HTTPStatusCodeError
+	if errors.As(err, &statusCodeErrorPointer) {
+		return statusCodeErrorPointer, true
 	}
 
-	return nil, ok
+	return nil, false
 }
 
 // IsGoneError returns whether the error represents an HTTP GONE status.
 func IsGoneError(err error) bool {
-	statusCodeError, ok := err.(HTTPStatusCodeError)
-	if !ok {
-		return false
-	}
-
-	return statusCodeError.StatusCode == http.StatusGone
+	return errors.Is(err, ErrGone)
 }
 
 // IsConflictError returns whether the error represents a conflict.
 func IsConflictError(err error) bool {
-	statusCodeError, ok := err.(HTTPStatusCodeError)
-	if !ok {
-		return false
-	}
-
-	return statusCodeError.StatusCode == http.StatusConflict
+	return errors.Is(err, ErrConflict)
 }
 
 // Constants are used to check for spec-mandated errors and their messages
 const (
-	AsyncErrorMessage               = "AsyncRequired"
-	AsyncErrorDescription           = "This service plan requires client support for asynchronous service operations."
-	AppGUIDRequiredErrorMessage     = "RequiresApp"
-	AppGUIDRequiredErrorDescription = "This service supports generation of credentials through binding an application only."
-	ConcurrencyErrorMessage         = "ConcurrencyError"
-	ConcurrencyErrorDescription     = "The Service Broker does not support concurrent requests that mutate the same resource."
+	AsyncErrorMessage                   = "AsyncRequired"
+	AsyncErrorDescription               = "This service plan requires client support for asynchronous service operations."
+	AppGUIDRequiredErrorMessage         = "RequiresApp"
+	AppGUIDRequiredErrorDescription     = "This service supports generation of credentials through binding an application only."
+	ConcurrencyErrorMessage             = "ConcurrencyError"
+	ConcurrencyErrorDescription         = "The Service Broker does not support concurrent requests that mutate the same resource."
+	MaintenanceInfoConflictErrorMessage = "MaintenanceInfoConflict"
 )
 
 // IsAsyncRequiredError returns whether the error corresponds to the
 // conventional way of indicating that a service requires asynchronous
 // operations to perform an action.
 func IsAsyncRequiredError(err error) bool {
-	statusCodeError, ok := err.(HTTPStatusCodeError)
-	if !ok {
-		return false
-	}
-
-	if statusCodeError.StatusCode != http.StatusUnprocessableEntity {
-		return false
-	}
-
-	if statusCodeError.ErrorMessage == nil || statusCodeError.Description == nil {
-		return false
-	}
-
-	if *statusCodeError.ErrorMessage != AsyncErrorMessage {
-		return false
-	}
-
-	return *statusCodeError.Description == AsyncErrorDescription
+	return errors.Is(err, ErrAsyncRequired)
 }
 
 // IsAppGUIDRequiredError returns whether the error corresponds to the
 // conventional way of indicating that a service only supports credential-type
 // bindings.
 func IsAppGUIDRequiredError(err error) bool {
-	statusCodeError, ok := err.(HTTPStatusCodeError)
-	if !ok {
-		return false
-	}
-
-	if statusCodeError.StatusCode != http.StatusUnprocessableEntity {
-		return false
-	}
-
-	if statusCodeError.ErrorMessage == nil || statusCodeError.Description == nil {
-		return false
-	}
-
-	if *statusCodeError.ErrorMessage != AppGUIDRequiredErrorMessage {
-		return false
-	}
-
-	return *statusCodeError.Description == AppGUIDRequiredErrorDescription
+	return errors.Is(err, ErrAppGUIDRequired)
 }
 
 // IsConcurrencyError returns whether the error corresponds to the
 // conventional way of indicating that a service broker does not support
 // concurrent requests to modify the same resource
 func IsConcurrencyError(err error) bool {
-	statusCodeError, ok := err.(HTTPStatusCodeError)
-	if !ok {
-		return false
-	}
-
-	if statusCodeError.StatusCode != http.StatusUnprocessableEntity {
-		return false
-	}
-
-	if statusCodeError.ErrorMessage == nil || statusCodeError.Description == nil {
-		return false
-	}
-
-	if *statusCodeError.ErrorMessage != ConcurrencyErrorMessage {
-		return false
-	}
+	return errors.Is(err, ErrConcurrency)
+}
 
-	return *statusCodeError.Description == ConcurrencyErrorDescription
+// IsMaintenanceInfoConflictError returns whether the error corresponds to the
+// conventional way of indicating that the maintenance_info sent with a
+// provision or update request does not match the broker's catalog. The spec
+// does not mandate a description for this error, so only the error code is
+// checked.
+func IsMaintenanceInfoConflictError(err error) bool {
+	return errors.Is(err, ErrMaintenanceInfoConflict)
 }
 
 // AlphaAPIMethodsNotAllowedError is an error type signifying that alpha API
@@ -250,6 +281,121 @@ func (e AsyncBindingOperationsNotAllowedError) Error() string {
 // IsAsyncBindingOperationsNotAllowedError returns whether the error represents asynchronous
 // binding operations (bind/unbind/poll) not being allowed for this client.
 func IsAsyncBindingOperationsNotAllowedError(err error) bool {
-	_, ok := err.(AsyncBindingOperationsNotAllowedError)
-	return ok
+	var asyncErr AsyncBindingOperationsNotAllowedError
+	return errors.As(err, &asyncErr)
+}
+
+// ContextError is an error type signifying that a request to the broker was
+// abandoned because the context passed to one of the ClientWithContext
+// methods was canceled or its deadline expired before the broker responded.
+type ContextError struct {
+	// Err is the error reported by the context: either context.Canceled or
+	// context.DeadlineExceeded.
+	Err error
+}
+
+func (e ContextError) Error() string {
+	return fmt.Sprintf("request to broker aborted: %v", e.Err)
+}
+
+// Unwrap returns the underlying context error, so that errors.Is(err,
+// context.Canceled) and errors.Is(err, context.DeadlineExceeded) work for
+// ContextErrors.
+func (e ContextError) Unwrap() error {
+	return e.Err
+}
+
+// IsCanceledError returns whether the error represents a request that was
+// abandoned because its context was canceled.
+func IsCanceledError(err error) bool {
+	var contextErr ContextError
+	if !errors.As(err, &contextErr) {
+		return false
+	}
+
+	return contextErr.Err == context.Canceled
+}
+
+// IsDeadlineExceededError returns whether the error represents a request that
+// was abandoned because the deadline of its context expired.
+func IsDeadlineExceededError(err error) bool {
+	var contextErr ContextError
+	if !errors.As(err, &contextErr) {
+		return false
+	}
+
+	return contextErr.Err == context.DeadlineExceeded
+}
+
+// OrphanMitigationError is returned by ProvisionInstance and Bind when orphan
+// mitigation is enabled and the request failed in a way that may have left
+// an orphaned instance or binding on the broker.  The client has already
+// attempted to clean up the resource by sending a DeprovisionInstance or
+// Unbind request; the fields of the error report the outcome.
+type OrphanMitigationError struct {
+	// Err is the error returned for the original provision or bind request.
+	Err error
+	// MitigationSucceeded is whether the broker accepted the cleanup
+	// request.
+	MitigationSucceeded bool
+	// MitigationAsync is whether the broker is performing the cleanup
+	// asynchronously.  If true, callers should poll the last operation of
+	// the instance or binding using MitigationOperationKey to find out when
+	// the cleanup has finished.
+	MitigationAsync bool
+	// MitigationOperationKey is the operation key returned by the broker for
+	// an asynchronous cleanup, if any.
+	MitigationOperationKey *OperationKey
+	// MitigationErr is the error returned for the cleanup request, if it
+	// failed.
+	MitigationErr error
+}
+
+func (e OrphanMitigationError) Error() string {
+	if e.MitigationSucceeded {
+		return fmt.Sprintf("orphan mitigation succeeded after error: %v", e.Err)
+	}
+	return fmt.Sprintf("orphan mitigation failed after error: %v; mitigation error: %v", e.Err, e.MitigationErr)
+}
+
+// Unwrap returns the error returned for the original request.
+func (e OrphanMitigationError) Unwrap() error {
+	return e.Err
+}
+
+// IsOrphanMitigationError returns whether the error represents a request
+// that was followed by orphan mitigation.
+func IsOrphanMitigationError(err error) (*OrphanMitigationError, bool) {
+	var orphanMitigationError OrphanMitigationError
+	if errors.As(err, &orphanMitigationError) {
+		return &orphanMitigationError, true
+	}
+
+	var orphanMitigationErrorPointer *OrphanMitigationError
+	if errors.As(err, &orphanMitigationErrorPointer) {
+		return orphanMitigationErrorPointer, true
+	}
+
+	return nil, false
+}
+
+// WaitTimeoutError is an error type signifying that an asynchronous
+// operation did not finish within its maximum polling duration.
+type WaitTimeoutError struct {
+	// MaximumPollingDuration is the maximum polling duration that was
+	// exceeded.
+	MaximumPollingDuration time.Duration
+	// LastState is the last state of the operation reported by the broker.
+	LastState LastOperationState
+}
+
+func (e WaitTimeoutError) Error() string {
+	return fmt.Sprintf("operation did not finish within %v; last state: %q", e.MaximumPollingDuration, e.LastState)
+}
+
+// IsWaitTimeoutError returns whether the error represents an asynchronous
+// operation that did not finish within its maximum polling duration.
+func IsWaitTimeoutError(err error) bool {
+	var timeoutErr WaitTimeoutError
+	return errors.As(err, &timeoutErr)
 }
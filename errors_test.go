@@ -17,7 +17,10 @@ limitations under the License.
 package v2
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"testing"
 )
@@ -47,6 +50,12 @@ func TestIsHTTPError(t *testing.T) {
 			expected: true,
 			result:   &HTTPStatusCodeError{StatusCode: http.StatusGone},
 		},
+		{
+			name:     "wrapped http error",
+			err:      fmt.Errorf("wrapped: %w", HTTPStatusCodeError{StatusCode: http.StatusGone}),
+			expected: true,
+			result:   &HTTPStatusCodeError{StatusCode: http.StatusGone},
+		},
 		{
 			name:     "nil",
 			err:      nil,
@@ -350,6 +359,208 @@ func TestConcurrencyError(t *testing.T) {
 	}
 }
 
+func TestIsMaintenanceInfoConflictError(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "non-http error",
+			err:      errors.New("some error"),
+			expected: false,
+		},
+		{
+			name: "other http error",
+			err: HTTPStatusCodeError{
+				StatusCode:   http.StatusBadRequest,
+				ErrorMessage: strPtr(MaintenanceInfoConflictErrorMessage),
+			},
+			expected: false,
+		},
+		{
+			name: "concurrency error",
+			err: HTTPStatusCodeError{
+				StatusCode:   http.StatusUnprocessableEntity,
+				ErrorMessage: strPtr(ConcurrencyErrorMessage),
+				Description:  strPtr(ConcurrencyErrorDescription),
+			},
+			expected: false,
+		},
+		{
+			name: "maintenance info conflict error",
+			err: HTTPStatusCodeError{
+				StatusCode:   http.StatusUnprocessableEntity,
+				ErrorMessage: strPtr(MaintenanceInfoConflictErrorMessage),
+				Description:  strPtr("some description"),
+			},
+			expected: true,
+		},
+		{
+			name: "no description",
+			err: HTTPStatusCodeError{
+				StatusCode:   http.StatusUnprocessableEntity,
+				ErrorMessage: strPtr(MaintenanceInfoConflictErrorMessage),
+			},
+			expected: true,
+		},
+		{
+			name: "no error message",
+			err: HTTPStatusCodeError{
+				StatusCode: http.StatusUnprocessableEntity,
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range cases {
+		if e, a := tc.expected, IsMaintenanceInfoConflictError(tc.err); e != a {
+			t.Errorf("%v: expected %v, got %v", tc.name, e, a)
+		}
+	}
+}
+
+func TestWrappedHTTPStatusCodeError(t *testing.T) {
+	sentinels := []error{
+		ErrGone,
+		ErrConflict,
+		ErrAsyncRequired,
+		ErrAppGUIDRequired,
+		ErrConcurrency,
+		ErrMaintenanceInfoConflict,
+	}
+	helpers := map[error]func(error) bool{
+		ErrGone:                    IsGoneError,
+		ErrConflict:                IsConflictError,
+		ErrAsyncRequired:           IsAsyncRequiredError,
+		ErrAppGUIDRequired:         IsAppGUIDRequiredError,
+		ErrConcurrency:             IsConcurrencyError,
+		ErrMaintenanceInfoConflict: IsMaintenanceInfoConflictError,
+	}
+
+	cases := []struct {
+		name     string
+		err      HTTPStatusCodeError
+		expected error
+	}{
+		{
+			name:     "gone",
+			err:      HTTPStatusCodeError{StatusCode: http.StatusGone},
+			expected: ErrGone,
+		},
+		{
+			name:     "conflict",
+			err:      HTTPStatusCodeError{StatusCode: http.StatusConflict},
+			expected: ErrConflict,
+		},
+		{
+			name: "async required",
+			err: HTTPStatusCodeError{
+				StatusCode:   http.StatusUnprocessableEntity,
+				ErrorMessage: strPtr(AsyncErrorMessage),
+				Description:  strPtr(AsyncErrorDescription),
+			},
+			expected: ErrAsyncRequired,
+		},
+		{
+			name: "app guid required",
+			err: HTTPStatusCodeError{
+				StatusCode:   http.StatusUnprocessableEntity,
+				ErrorMessage: strPtr(AppGUIDRequiredErrorMessage),
+				Description:  strPtr(AppGUIDRequiredErrorDescription),
+			},
+			expected: ErrAppGUIDRequired,
+		},
+		{
+			name: "concurrency",
+			err: HTTPStatusCodeError{
+				StatusCode:   http.StatusUnprocessableEntity,
+				ErrorMessage: strPtr(ConcurrencyErrorMessage),
+				Description:  strPtr(ConcurrencyErrorDescription),
+			},
+			expected: ErrConcurrency,
+		},
+		{
+			name: "maintenance info conflict",
+			err: HTTPStatusCodeError{
+				StatusCode:   http.StatusUnprocessableEntity,
+				ErrorMessage: strPtr(MaintenanceInfoConflictErrorMessage),
+			},
+			expected: ErrMaintenanceInfoConflict,
+		},
+		{
+			name: "other error",
+			err:  HTTPStatusCodeError{StatusCode: http.StatusInternalServerError},
+		},
+	}
+
+	for _, tc := range cases {
+		for _, err := range []error{
+			tc.err,
+			&tc.err,
+			fmt.Errorf("wrapped: %w", tc.err),
+			fmt.Errorf("wrapped twice: %w", fmt.Errorf("wrapped: %w", &tc.err)),
+		} {
+			for _, sentinel := range sentinels {
+				if e, a := sentinel == tc.expected, errors.Is(err, sentinel); e != a {
+					t.Errorf("%v: errors.Is(%v, %v): expected %v, got %v", tc.name, err, sentinel, e, a)
+				}
+				if e, a := sentinel == tc.expected, helpers[sentinel](err); e != a {
+					t.Errorf("%v: helper for %v on %v: expected %v, got %v", tc.name, sentinel, err, e, a)
+				}
+			}
+		}
+	}
+}
+
+func TestHTTPStatusCodeErrorUnwrap(t *testing.T) {
+	responseErr := &json.SyntaxError{Offset: 1}
+	err := fmt.Errorf("wrapped: %w", HTTPStatusCodeError{
+		StatusCode:    http.StatusOK,
+		ResponseError: responseErr,
+	})
+
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr != responseErr {
+		t.Errorf("expected errors.As to find the response error, got %v", syntaxErr)
+	}
+
+	if errors.Unwrap(HTTPStatusCodeError{StatusCode: http.StatusGone}) != nil {
+		t.Error("expected an error without a response error to unwrap to nil")
+	}
+}
+
+func TestWrappedErrorHelpers(t *testing.T) {
+	wrap := func(err error) error {
+		return fmt.Errorf("wrapped: %w", err)
+	}
+
+	if !IsAsyncBindingOperationsNotAllowedError(wrap(AsyncBindingOperationsNotAllowedError{})) {
+		t.Error("expected wrapped AsyncBindingOperationsNotAllowedError to be recognized")
+	}
+	if !IsCanceledError(wrap(ContextError{Err: context.Canceled})) {
+		t.Error("expected wrapped canceled ContextError to be recognized")
+	}
+	if !IsDeadlineExceededError(wrap(ContextError{Err: context.DeadlineExceeded})) {
+		t.Error("expected wrapped deadline ContextError to be recognized")
+	}
+	if _, ok := IsOrphanMitigationError(wrap(OrphanMitigationError{})); !ok {
+		t.Error("expected wrapped OrphanMitigationError to be recognized")
+	}
+	if _, ok := IsOrphanMitigationError(wrap(&OrphanMitigationError{})); !ok {
+		t.Error("expected wrapped *OrphanMitigationError to be recognized")
+	}
+	if !IsWaitTimeoutError(wrap(WaitTimeoutError{})) {
+		t.Error("expected wrapped WaitTimeoutError to be recognized")
+	}
+
+	// An orphan mitigation error unwraps to the error for the original
+	// request.
+	if !IsConflictError(OrphanMitigationError{Err: HTTPStatusCodeError{StatusCode: http.StatusConflict}}) {
+		t.Error("expected OrphanMitigationError to unwrap to the original error")
+	}
+}
+
 func TestHttpStatusCodeError(t *testing.T) {
 	cases := []struct {
 		name           string
@@ -424,3 +635,68 @@ func TestIsAsyncBindingOperationsNotAllowedError(t *testing.T) {
 		}
 	}
 }
+
+func TestIsCanceledError(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "canceled",
+			err:      ContextError{Err: context.Canceled},
+			expected: true,
+		},
+		{
+			name:     "deadline exceeded",
+			err:      ContextError{Err: context.DeadlineExceeded},
+			expected: false,
+		},
+		{
+			name:     "bare context error",
+			err:      context.Canceled,
+			expected: false,
+		},
+		{
+			name:     "other error",
+			err:      errors.New("other error"),
+			expected: false,
+		},
+	}
+
+	for _, tc := range cases {
+		if e, a := tc.expected, IsCanceledError(tc.err); e != a {
+			t.Errorf("%v: expected %v, got %v", tc.name, e, a)
+		}
+	}
+}
+
+func TestIsDeadlineExceededError(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "deadline exceeded",
+			err:      ContextError{Err: context.DeadlineExceeded},
+			expected: true,
+		},
+		{
+			name:     "canceled",
+			err:      ContextError{Err: context.Canceled},
+			expected: false,
+		},
+		{
+			name:     "other error",
+			err:      errors.New("other error"),
+			expected: false,
+		},
+	}
+
+	for _, tc := range cases {
+		if e, a := tc.expected, IsDeadlineExceededError(tc.err); e != a {
+			t.Errorf("%v: expected %v, got %v", tc.name, e, a)
+		}
+	}
+}
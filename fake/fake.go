@@ -17,6 +17,7 @@ limitations under the License.
 package fake
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"sync"
@@ -76,7 +77,9 @@ type FakeClientConfiguration struct {
 
 // Action is a record of a method call on the FakeClient.
 type Action struct {
-	Type    ActionType
+	Type ActionType
+	// Request is the request the method was called with, including its
+	// RequestIdentity, or nil for GetCatalog.
 	Request interface{}
 }
 
@@ -120,6 +123,7 @@ type FakeClient struct {
 }
 
 var _ v2.Client = &FakeClient{}
+var _ v2.ClientWithContext = &FakeClient{}
 
 // Actions is a method defined on FakeClient that returns the actions taken on
 // it.
@@ -190,11 +194,11 @@ func (c *FakeClient) DeprovisionInstance(r *v2.DeprovisionRequest) (*v2.Deprovis
 }
 
 // GetInstance implements the Client.GetInstance method for the FakeClient.
-func (c *FakeClient) GetInstance(*v2.GetInstanceRequest) (*v2.GetInstanceResponse, error) {
+func (c *FakeClient) GetInstance(r *v2.GetInstanceRequest) (*v2.GetInstanceResponse, error) {
 	c.Mutex.Lock()
 	defer c.Mutex.Unlock()
 
-	c.actions = append(c.actions, Action{Type: GetInstance})
+	c.actions = append(c.actions, Action{GetInstance, r})
 
 	if c.GetInstanceReaction != nil {
 		return c.GetInstanceReaction.react()
@@ -264,11 +268,11 @@ func (c *FakeClient) Unbind(r *v2.UnbindRequest) (*v2.UnbindResponse, error) {
 }
 
 // GetBinding implements the Client.GetBinding method for the FakeClient.
-func (c *FakeClient) GetBinding(*v2.GetBindingRequest) (*v2.GetBindingResponse, error) {
+func (c *FakeClient) GetBinding(r *v2.GetBindingRequest) (*v2.GetBindingResponse, error) {
 	c.Mutex.Lock()
 	defer c.Mutex.Unlock()
 
-	c.actions = append(c.actions, Action{Type: GetBinding})
+	c.actions = append(c.actions, Action{GetBinding, r})
 
 	if c.GetBindingReaction != nil {
 		return c.GetBindingReaction.react()
@@ -277,6 +281,111 @@ func (c *FakeClient) GetBinding(*v2.GetBindingRequest) (*v2.GetBindingResponse,
 	return nil, UnexpectedActionError()
 }
 
+// The ClientWithContext methods of the FakeClient run the same reactions as
+// their Client counterparts.  If the given context is already done, they
+// return a v2.ContextError without recording an action, mirroring a real
+// client that never reaches the broker.
+
+// GetCatalogWithContext implements the ClientWithContext.GetCatalogWithContext
+// method for the FakeClient.
+func (c *FakeClient) GetCatalogWithContext(ctx context.Context) (*v2.CatalogResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, v2.ContextError{Err: err}
+	}
+
+	return c.GetCatalog()
+}
+
+// ProvisionInstanceWithContext implements the ClientWithContext.ProvisionInstanceWithContext
+// method for the FakeClient.
+func (c *FakeClient) ProvisionInstanceWithContext(ctx context.Context, r *v2.ProvisionRequest) (*v2.ProvisionResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, v2.ContextError{Err: err}
+	}
+
+	return c.ProvisionInstance(r)
+}
+
+// UpdateInstanceWithContext implements the ClientWithContext.UpdateInstanceWithContext
+// method for the FakeClient.
+func (c *FakeClient) UpdateInstanceWithContext(ctx context.Context, r *v2.UpdateInstanceRequest) (*v2.UpdateInstanceResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, v2.ContextError{Err: err}
+	}
+
+	return c.UpdateInstance(r)
+}
+
+// DeprovisionInstanceWithContext implements the ClientWithContext.DeprovisionInstanceWithContext
+// method for the FakeClient.
+func (c *FakeClient) DeprovisionInstanceWithContext(ctx context.Context, r *v2.DeprovisionRequest) (*v2.DeprovisionResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, v2.ContextError{Err: err}
+	}
+
+	return c.DeprovisionInstance(r)
+}
+
+// GetInstanceWithContext implements the ClientWithContext.GetInstanceWithContext
+// method for the FakeClient.
+func (c *FakeClient) GetInstanceWithContext(ctx context.Context, r *v2.GetInstanceRequest) (*v2.GetInstanceResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, v2.ContextError{Err: err}
+	}
+
+	return c.GetInstance(r)
+}
+
+// PollLastOperationWithContext implements the ClientWithContext.PollLastOperationWithContext
+// method for the FakeClient.
+func (c *FakeClient) PollLastOperationWithContext(ctx context.Context, r *v2.LastOperationRequest) (*v2.LastOperationResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, v2.ContextError{Err: err}
+	}
+
+	return c.PollLastOperation(r)
+}
+
+// PollBindingLastOperationWithContext implements the ClientWithContext.PollBindingLastOperationWithContext
+// method for the FakeClient.
+func (c *FakeClient) PollBindingLastOperationWithContext(ctx context.Context, r *v2.BindingLastOperationRequest) (*v2.LastOperationResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, v2.ContextError{Err: err}
+	}
+
+	return c.PollBindingLastOperation(r)
+}
+
+// BindWithContext implements the ClientWithContext.BindWithContext
+// method for the FakeClient.
+func (c *FakeClient) BindWithContext(ctx context.Context, r *v2.BindRequest) (*v2.BindResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, v2.ContextError{Err: err}
+	}
+
+	return c.Bind(r)
+}
+
+// UnbindWithContext implements the ClientWithContext.UnbindWithContext
+// method for the FakeClient.
+func (c *FakeClient) UnbindWithContext(ctx context.Context, r *v2.UnbindRequest) (*v2.UnbindResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, v2.ContextError{Err: err}
+	}
+
+	return c.Unbind(r)
+}
+
+// GetBindingWithContext implements the ClientWithContext.GetBindingWithContext
+// method for the FakeClient.
+func (c *FakeClient) GetBindingWithContext(ctx context.Context, r *v2.GetBindingRequest) (*v2.GetBindingResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, v2.ContextError{Err: err}
+	}
+
+	return c.GetBinding(r)
+}
+
 // UnexpectedActionError returns an error message when an action is not found
 // in the FakeClient's action array.
 func UnexpectedActionError() error {
@@ -556,3 +665,14 @@ func ConcurrencyError() error {
 		Description:  strPtr(v2.ConcurrencyErrorDescription),
 	}
 }
+
+// MaintenanceInfoConflictError returns error for when the maintenance_info
+// sent with a provision or update request does not match the broker's
+// catalog.
+func MaintenanceInfoConflictError() error {
+	return v2.HTTPStatusCodeError{
+		StatusCode:   http.StatusUnprocessableEntity,
+		ErrorMessage: strPtr(v2.MaintenanceInfoConflictErrorMessage),
+		Description:  strPtr("The maintenance_info.version field provided in the request does not match the maintenance_info.version field provided in the catalog."),
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package fake_test
 
 import (
+	"context"
 	"errors"
 	"reflect"
 	"testing"
@@ -463,7 +464,8 @@ func TestGetInstance(t *testing.T) {
 			GetInstanceReaction: tc.reaction,
 		}
 
-		response, err := fakeClient.GetInstance(&v2.GetInstanceRequest{})
+		request := &v2.GetInstanceRequest{RequestIdentity: "test-request-identity"}
+		response, err := fakeClient.GetInstance(request)
 
 		if !reflect.DeepEqual(tc.response, response) {
 			t.Errorf("%v: unexpected response; expected %+v, got %+v", tc.name, tc.response, response)
@@ -480,6 +482,9 @@ func TestGetInstance(t *testing.T) {
 		if e, a := fake.GetInstance, actions[0].Type; e != a {
 			t.Errorf("%v: unexpected action type; expected %v, got %v", tc.name, e, a)
 		}
+		if e, a := request, actions[0].Request; e != a {
+			t.Errorf("%v: unexpected action request; expected %+v, got %+v", tc.name, e, a)
+		}
 	}
 }
 
@@ -948,7 +953,8 @@ func TestGetBinding(t *testing.T) {
 			GetBindingReaction: tc.reaction,
 		}
 
-		response, err := fakeClient.GetBinding(&v2.GetBindingRequest{})
+		request := &v2.GetBindingRequest{RequestIdentity: "test-request-identity"}
+		response, err := fakeClient.GetBinding(request)
 
 		if !reflect.DeepEqual(tc.response, response) {
 			t.Errorf("%v: unexpected response; expected %+v, got %+v", tc.name, tc.response, response)
@@ -965,6 +971,9 @@ func TestGetBinding(t *testing.T) {
 		if e, a := fake.GetBinding, actions[0].Type; e != a {
 			t.Errorf("%v: unexpected action type; expected %v, got %v", tc.name, e, a)
 		}
+		if e, a := request, actions[0].Request; e != a {
+			t.Errorf("%v: unexpected action request; expected %+v, got %+v", tc.name, e, a)
+		}
 	}
 }
 
@@ -1058,6 +1067,31 @@ func TestFakeConcurrencyError(t *testing.T) {
 	}
 }
 
+func TestFakeMaintenanceInfoConflictError(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "concurrency error",
+			err:      fake.ConcurrencyError(),
+			expected: false,
+		},
+		{
+			name:     "maintenance info conflict error",
+			err:      fake.MaintenanceInfoConflictError(),
+			expected: true,
+		},
+	}
+
+	for _, tc := range cases {
+		if e, a := tc.expected, v2.IsMaintenanceInfoConflictError(tc.err); e != a {
+			t.Errorf("%v: expected %v, got %v", tc.name, e, a)
+		}
+	}
+}
+
 func TestNewFakeClient(t *testing.T) {
 	newfakeClient := fake.NewFakeClient(fake.FakeClientConfiguration{
 		BindReaction: &fake.BindReaction{
@@ -1093,3 +1127,41 @@ func TestNewFakeClient(t *testing.T) {
 	}
 
 }
+
+func TestWithContext(t *testing.T) {
+	fakeClient := fake.NewFakeClient(fake.FakeClientConfiguration{
+		CatalogReaction: &fake.CatalogReaction{
+			Response: catalogResponse(),
+		},
+		ProvisionReaction: &fake.ProvisionReaction{
+			Response: provisionResponse(),
+		},
+	})
+
+	catalog, err := fakeClient.GetCatalogWithContext(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := catalogResponse(), catalog; !reflect.DeepEqual(e, a) {
+		t.Errorf("unexpected response; expected %+v, got %+v", e, a)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	response, err := fakeClient.ProvisionInstanceWithContext(ctx, provisionRequest())
+	if response != nil {
+		t.Errorf("unexpected response; expected nil, got %+v", response)
+	}
+	if !v2.IsCanceledError(err) {
+		t.Errorf("expected canceled error, got %v", err)
+	}
+
+	actions := fakeClient.Actions()
+	if e, a := 1, len(actions); e != a {
+		t.Fatalf("unexpected actions; expected %v, got %v; actions = %+v", e, a, actions)
+	}
+	if e, a := fake.GetCatalog, actions[0].Type; e != a {
+		t.Errorf("unexpected action type; expected %v, got %v", e, a)
+	}
+}
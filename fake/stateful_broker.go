@@ -0,0 +1,735 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+
+	v2 "sigs.k8s.io/go-open-service-broker-client/v2"
+)
+
+// NewStatefulBroker returns a new StatefulBroker with the given
+// StatefulBrokerConfiguration and no instances or bindings.
+func NewStatefulBroker(config StatefulBrokerConfiguration) *StatefulBroker {
+	return &StatefulBroker{
+		Catalog:       config.Catalog,
+		AsyncSteps:    config.AsyncSteps,
+		Credentials:   config.Credentials,
+		FailOperation: config.FailOperation,
+	}
+}
+
+// ReturnStatefulBrokerFunc returns a v2.CreateFunc that returns the given
+// StatefulBroker.
+func ReturnStatefulBrokerFunc(b *StatefulBroker) v2.CreateFunc {
+	return func(_ *v2.ClientConfiguration) (v2.Client, error) {
+		return b, nil
+	}
+}
+
+// StatefulBrokerConfiguration models the configuration of a StatefulBroker.
+type StatefulBrokerConfiguration struct {
+	// Catalog is returned by GetCatalog.  If set, ProvisionInstance,
+	// UpdateInstance and Bind reject requests for services and plans that
+	// are not in it.
+	Catalog *v2.CatalogResponse
+	// AsyncSteps is the number of times polling the last operation of an
+	// instance or binding reports an operation as in progress before it
+	// succeeds.  If zero, every operation completes synchronously; otherwise
+	// every operation is asynchronous, and requests that do not accept
+	// incomplete operations fail with an AsyncRequiredError.
+	AsyncSteps int
+	// Credentials are the credentials returned for every binding.
+	Credentials map[string]interface{}
+	// FailOperation, if set, is called with the type and request of every
+	// operation the broker starts: a provision, update, deprovision, bind or
+	// unbind.  If it returns a non-empty description, the operation fails
+	// with it.  An asynchronous operation reports the failed state and the
+	// description once it finishes, and a synchronous one fails with a 500
+	// Internal Server Error.  A failed operation leaves the instance or
+	// binding as it was, except that an instance or binding whose creation
+	// failed asynchronously remains until it is deleted, as it would on a
+	// broker, but is not found.
+	FailOperation func(action ActionType, request interface{}) string
+}
+
+// StatefulBroker is a fake implementation of the v2.Client interface that
+// behaves like a broker: it keeps the instances and bindings created through
+// it in memory, keyed by ID, and enforces the semantics of the Open Service
+// Broker API specification.  Repeating a provision or bind request with
+// identical fields succeeds, while a request with different fields for an
+// existing ID fails with a 409 Conflict.  Deleting an instance or binding
+// that does not exist succeeds, as it does with a real client, which turns
+// the 410 Gone response of a broker into a successful response; polling the
+// last operation of an instance or binding that does not exist fails with a
+// 410 Gone, as it does with a real client.  Deprovisioning an instance that
+// has bindings fails with a 422 Unprocessable Entity.  Requests to change an instance or binding
+// while an asynchronous operation on it is in progress fail with a
+// ConcurrencyError.  Operations can be made to fail with FailOperation.
+//
+// Like the FakeClient, StatefulBroker records the actions taken on it and is
+// threadsafe.
+type StatefulBroker struct {
+	Catalog       *v2.CatalogResponse
+	AsyncSteps    int
+	Credentials   map[string]interface{}
+	FailOperation func(action ActionType, request interface{}) string
+
+	sync.Mutex
+	actions     []Action
+	instances   map[string]*statefulInstance
+	bindings    map[statefulBindingKey]*statefulBinding
+	operationID int
+}
+
+var _ v2.Client = &StatefulBroker{}
+var _ v2.ClientWithContext = &StatefulBroker{}
+
+type statefulInstance struct {
+	serviceID  string
+	planID     string
+	parameters map[string]interface{}
+	operation  *statefulOperation
+}
+
+type statefulBindingKey struct {
+	instanceID string
+	bindingID  string
+}
+
+type statefulBinding struct {
+	serviceID    string
+	planID       string
+	appGUID      *string
+	bindResource *v2.BindResource
+	parameters   map[string]interface{}
+	operation    *statefulOperation
+}
+
+// statefulOperation is the last operation performed on an instance or
+// binding.
+type statefulOperation struct {
+	action    ActionType
+	key       v2.OperationKey
+	remaining int
+	state     v2.LastOperationState
+	// failure is the description of the failure of an asynchronous
+	// operation that fails once it finishes.
+	failure string
+}
+
+func (o *statefulOperation) inProgress() bool {
+	return o != nil && o.state == v2.StateInProgress
+}
+
+// creating returns whether the operation is the creation of an instance or
+// binding, with the given action, that has not succeeded: it is still in
+// progress or failed.
+func (o *statefulOperation) creating(action ActionType) bool {
+	return o != nil && o.action == action && o.state != v2.StateSucceeded
+}
+
+// Actions returns the actions taken on the StatefulBroker.
+func (b *StatefulBroker) Actions() []Action {
+	b.Mutex.Lock()
+	defer b.Mutex.Unlock()
+
+	return b.actions
+}
+
+// HasInstance returns whether the StatefulBroker has an instance with the
+// given ID, including one that is still being provisioned or whose
+// provisioning failed.  Unlike GetInstance, it does not record an action.
+func (b *StatefulBroker) HasInstance(instanceID string) bool {
+	b.Mutex.Lock()
+	defer b.Mutex.Unlock()
+
+	_, ok := b.instances[instanceID]
+	return ok
+}
+
+// HasBinding returns whether the StatefulBroker has a binding with the given
+// IDs, including one that is still being created or whose creation failed.
+// Unlike GetBinding, it does not record an action.
+func (b *StatefulBroker) HasBinding(instanceID, bindingID string) bool {
+	b.Mutex.Lock()
+	defer b.Mutex.Unlock()
+
+	_, ok := b.bindings[statefulBindingKey{instanceID: instanceID, bindingID: bindingID}]
+	return ok
+}
+
+// GetCatalog implements the Client.GetCatalog method for the StatefulBroker.
+func (b *StatefulBroker) GetCatalog() (*v2.CatalogResponse, error) {
+	b.Mutex.Lock()
+	defer b.Mutex.Unlock()
+
+	b.actions = append(b.actions, Action{Type: GetCatalog})
+
+	if b.Catalog == nil {
+		return &v2.CatalogResponse{}, nil
+	}
+	return b.Catalog, nil
+}
+
+// ProvisionInstance implements the Client.ProvisionInstance method for the
+// StatefulBroker.
+func (b *StatefulBroker) ProvisionInstance(r *v2.ProvisionRequest) (*v2.ProvisionResponse, error) {
+	b.Mutex.Lock()
+	defer b.Mutex.Unlock()
+
+	b.actions = append(b.actions, Action{ProvisionInstance, r})
+
+	if r.InstanceID == "" || r.ServiceID == "" || r.PlanID == "" {
+		return nil, RequiredFieldsMissingError()
+	}
+	if err := b.validatePlan(r.ServiceID, r.PlanID); err != nil {
+		return nil, err
+	}
+
+	if instance, ok := b.instances[r.InstanceID]; ok {
+		identical := instance.serviceID == r.ServiceID &&
+			instance.planID == r.PlanID &&
+			reflect.DeepEqual(instance.parameters, r.Parameters)
+		if !identical {
+			return nil, conflictError()
+		}
+		if instance.operation.inProgress() {
+			if instance.operation.action != ProvisionInstance {
+				return nil, ConcurrencyError()
+			}
+			key := instance.operation.key
+			return &v2.ProvisionResponse{Async: true, OperationKey: &key}, nil
+		}
+		return &v2.ProvisionResponse{}, nil
+	}
+
+	operation, err := b.startOperation(ProvisionInstance, r, r.AcceptsIncomplete)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.instances == nil {
+		b.instances = map[string]*statefulInstance{}
+	}
+	b.instances[r.InstanceID] = &statefulInstance{
+		serviceID:  r.ServiceID,
+		planID:     r.PlanID,
+		parameters: r.Parameters,
+		operation:  operation,
+	}
+
+	if operation.inProgress() {
+		key := operation.key
+		return &v2.ProvisionResponse{Async: true, OperationKey: &key}, nil
+	}
+	return &v2.ProvisionResponse{}, nil
+}
+
+// UpdateInstance implements the Client.UpdateInstance method for the
+// StatefulBroker.
+func (b *StatefulBroker) UpdateInstance(r *v2.UpdateInstanceRequest) (*v2.UpdateInstanceResponse, error) {
+	b.Mutex.Lock()
+	defer b.Mutex.Unlock()
+
+	b.actions = append(b.actions, Action{UpdateInstance, r})
+
+	if r.InstanceID == "" || r.ServiceID == "" {
+		return nil, RequiredFieldsMissingError()
+	}
+
+	instance, ok := b.instances[r.InstanceID]
+	if !ok {
+		return nil, notFoundError()
+	}
+	if instance.operation.inProgress() {
+		return nil, ConcurrencyError()
+	}
+
+	planID := instance.planID
+	if r.PlanID != nil {
+		planID = *r.PlanID
+	}
+	if err := b.validatePlan(r.ServiceID, planID); err != nil {
+		return nil, err
+	}
+
+	operation, err := b.startOperation(UpdateInstance, r, r.AcceptsIncomplete)
+	if err != nil {
+		return nil, err
+	}
+
+	if operation.failure == "" {
+		instance.planID = planID
+		if r.Parameters != nil {
+			instance.parameters = r.Parameters
+		}
+	}
+	instance.operation = operation
+
+	if operation.inProgress() {
+		key := operation.key
+		return &v2.UpdateInstanceResponse{Async: true, OperationKey: &key}, nil
+	}
+	return &v2.UpdateInstanceResponse{}, nil
+}
+
+// DeprovisionInstance implements the Client.DeprovisionInstance method for
+// the StatefulBroker.
+func (b *StatefulBroker) DeprovisionInstance(r *v2.DeprovisionRequest) (*v2.DeprovisionResponse, error) {
+	b.Mutex.Lock()
+	defer b.Mutex.Unlock()
+
+	b.actions = append(b.actions, Action{DeprovisionInstance, r})
+
+	if r.InstanceID == "" {
+		return nil, RequiredFieldsMissingError()
+	}
+
+	// A broker responds 410 Gone, which the client turns into a successful
+	// response.
+	instance, ok := b.instances[r.InstanceID]
+	if !ok {
+		return &v2.DeprovisionResponse{}, nil
+	}
+	if instance.operation.inProgress() {
+		if instance.operation.action == DeprovisionInstance {
+			key := instance.operation.key
+			return &v2.DeprovisionResponse{Async: true, OperationKey: &key}, nil
+		}
+		return nil, ConcurrencyError()
+	}
+	for key := range b.bindings {
+		if key.instanceID == r.InstanceID {
+			return nil, bindingsExistError()
+		}
+	}
+
+	operation, err := b.startOperation(DeprovisionInstance, r, r.AcceptsIncomplete)
+	if err != nil {
+		return nil, err
+	}
+
+	if !operation.inProgress() {
+		delete(b.instances, r.InstanceID)
+		return &v2.DeprovisionResponse{}, nil
+	}
+
+	instance.operation = operation
+	key := operation.key
+	return &v2.DeprovisionResponse{Async: true, OperationKey: &key}, nil
+}
+
+// GetInstance implements the Client.GetInstance method for the
+// StatefulBroker.  Instances that are still being provisioned or whose
+// provisioning failed are not found.
+func (b *StatefulBroker) GetInstance(r *v2.GetInstanceRequest) (*v2.GetInstanceResponse, error) {
+	b.Mutex.Lock()
+	defer b.Mutex.Unlock()
+
+	b.actions = append(b.actions, Action{GetInstance, r})
+
+	instance, ok := b.instances[r.InstanceID]
+	if !ok || instance.operation.creating(ProvisionInstance) {
+		return nil, notFoundError()
+	}
+
+	return &v2.GetInstanceResponse{
+		ServiceID:  instance.serviceID,
+		PlanID:     instance.planID,
+		Parameters: instance.parameters,
+	}, nil
+}
+
+// PollLastOperation implements the Client.PollLastOperation method for the
+// StatefulBroker.  Once an instance has been deprovisioned, polling its last
+// operation fails with a 410 Gone error.
+func (b *StatefulBroker) PollLastOperation(r *v2.LastOperationRequest) (*v2.LastOperationResponse, error) {
+	b.Mutex.Lock()
+	defer b.Mutex.Unlock()
+
+	b.actions = append(b.actions, Action{PollLastOperation, r})
+
+	instance, ok := b.instances[r.InstanceID]
+	if !ok {
+		return nil, goneError()
+	}
+
+	response := instance.operation.poll()
+	if response.State == v2.StateSucceeded && instance.operation.action == DeprovisionInstance {
+		delete(b.instances, r.InstanceID)
+	}
+	return response, nil
+}
+
+// PollBindingLastOperation implements the Client.PollBindingLastOperation
+// method for the StatefulBroker.  Once a binding has been deleted, polling
+// its last operation fails with a 410 Gone error.
+func (b *StatefulBroker) PollBindingLastOperation(r *v2.BindingLastOperationRequest) (*v2.LastOperationResponse, error) {
+	b.Mutex.Lock()
+	defer b.Mutex.Unlock()
+
+	b.actions = append(b.actions, Action{PollBindingLastOperation, r})
+
+	key := statefulBindingKey{instanceID: r.InstanceID, bindingID: r.BindingID}
+	binding, ok := b.bindings[key]
+	if !ok {
+		return nil, goneError()
+	}
+
+	response := binding.operation.poll()
+	if response.State == v2.StateSucceeded && binding.operation.action == Unbind {
+		delete(b.bindings, key)
+	}
+	return response, nil
+}
+
+// Bind implements the Client.Bind method for the StatefulBroker.
+func (b *StatefulBroker) Bind(r *v2.BindRequest) (*v2.BindResponse, error) {
+	b.Mutex.Lock()
+	defer b.Mutex.Unlock()
+
+	b.actions = append(b.actions, Action{Bind, r})
+
+	if r.InstanceID == "" || r.BindingID == "" || r.ServiceID == "" || r.PlanID == "" {
+		return nil, RequiredFieldsMissingError()
+	}
+
+	instance, ok := b.instances[r.InstanceID]
+	if !ok {
+		return nil, notFoundError()
+	}
+	if instance.operation.inProgress() {
+		return nil, ConcurrencyError()
+	}
+	if instance.operation.creating(ProvisionInstance) {
+		return nil, notFoundError()
+	}
+
+	key := statefulBindingKey{instanceID: r.InstanceID, bindingID: r.BindingID}
+	if binding, ok := b.bindings[key]; ok {
+		identical := binding.serviceID == r.ServiceID &&
+			binding.planID == r.PlanID &&
+			reflect.DeepEqual(binding.appGUID, r.AppGUID) &&
+			reflect.DeepEqual(binding.bindResource, r.BindResource) &&
+			reflect.DeepEqual(binding.parameters, r.Parameters)
+		if !identical {
+			return nil, conflictError()
+		}
+		if binding.operation.inProgress() {
+			if binding.operation.action != Bind {
+				return nil, ConcurrencyError()
+			}
+			operationKey := binding.operation.key
+			return &v2.BindResponse{Async: true, OperationKey: &operationKey}, nil
+		}
+		return &v2.BindResponse{Credentials: b.Credentials}, nil
+	}
+
+	operation, err := b.startOperation(Bind, r, r.AcceptsIncomplete)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.bindings == nil {
+		b.bindings = map[statefulBindingKey]*statefulBinding{}
+	}
+	b.bindings[key] = &statefulBinding{
+		serviceID:    r.ServiceID,
+		planID:       r.PlanID,
+		appGUID:      r.AppGUID,
+		bindResource: r.BindResource,
+		parameters:   r.Parameters,
+		operation:    operation,
+	}
+
+	if operation.inProgress() {
+		operationKey := operation.key
+		return &v2.BindResponse{Async: true, OperationKey: &operationKey}, nil
+	}
+	return &v2.BindResponse{Credentials: b.Credentials}, nil
+}
+
+// Unbind implements the Client.Unbind method for the StatefulBroker.
+func (b *StatefulBroker) Unbind(r *v2.UnbindRequest) (*v2.UnbindResponse, error) {
+	b.Mutex.Lock()
+	defer b.Mutex.Unlock()
+
+	b.actions = append(b.actions, Action{Unbind, r})
+
+	if r.InstanceID == "" || r.BindingID == "" {
+		return nil, RequiredFieldsMissingError()
+	}
+
+	key := statefulBindingKey{instanceID: r.InstanceID, bindingID: r.BindingID}
+	// A broker responds 410 Gone, which the client turns into a successful
+	// response.
+	binding, ok := b.bindings[key]
+	if !ok {
+		return &v2.UnbindResponse{}, nil
+	}
+	if binding.operation.inProgress() {
+		if binding.operation.action == Unbind {
+			operationKey := binding.operation.key
+			return &v2.UnbindResponse{Async: true, OperationKey: &operationKey}, nil
+		}
+		return nil, ConcurrencyError()
+	}
+
+	operation, err := b.startOperation(Unbind, r, r.AcceptsIncomplete)
+	if err != nil {
+		return nil, err
+	}
+
+	if !operation.inProgress() {
+		delete(b.bindings, key)
+		return &v2.UnbindResponse{}, nil
+	}
+
+	binding.operation = operation
+	operationKey := operation.key
+	return &v2.UnbindResponse{Async: true, OperationKey: &operationKey}, nil
+}
+
+// GetBinding implements the Client.GetBinding method for the StatefulBroker.
+// Bindings that are still being created or whose creation failed are not
+// found.
+func (b *StatefulBroker) GetBinding(r *v2.GetBindingRequest) (*v2.GetBindingResponse, error) {
+	b.Mutex.Lock()
+	defer b.Mutex.Unlock()
+
+	b.actions = append(b.actions, Action{GetBinding, r})
+
+	binding, ok := b.bindings[statefulBindingKey{instanceID: r.InstanceID, bindingID: r.BindingID}]
+	if !ok || binding.operation.creating(Bind) {
+		return nil, notFoundError()
+	}
+
+	return &v2.GetBindingResponse{
+		Credentials: b.Credentials,
+		Parameters:  binding.parameters,
+	}, nil
+}
+
+// The ClientWithContext methods of the StatefulBroker behave like their
+// Client counterparts.  If the given context is already done, they return a
+// v2.ContextError without recording an action or changing any state,
+// mirroring a real client that never reaches the broker.
+
+// GetCatalogWithContext implements the ClientWithContext.GetCatalogWithContext
+// method for the StatefulBroker.
+func (b *StatefulBroker) GetCatalogWithContext(ctx context.Context) (*v2.CatalogResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, v2.ContextError{Err: err}
+	}
+
+	return b.GetCatalog()
+}
+
+// ProvisionInstanceWithContext implements the ClientWithContext.ProvisionInstanceWithContext
+// method for the StatefulBroker.
+func (b *StatefulBroker) ProvisionInstanceWithContext(ctx context.Context, r *v2.ProvisionRequest) (*v2.ProvisionResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, v2.ContextError{Err: err}
+	}
+
+	return b.ProvisionInstance(r)
+}
+
+// UpdateInstanceWithContext implements the ClientWithContext.UpdateInstanceWithContext
+// method for the StatefulBroker.
+func (b *StatefulBroker) UpdateInstanceWithContext(ctx context.Context, r *v2.UpdateInstanceRequest) (*v2.UpdateInstanceResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, v2.ContextError{Err: err}
+	}
+
+	return b.UpdateInstance(r)
+}
+
+// DeprovisionInstanceWithContext implements the ClientWithContext.DeprovisionInstanceWithContext
+// method for the StatefulBroker.
+func (b *StatefulBroker) DeprovisionInstanceWithContext(ctx context.Context, r *v2.DeprovisionRequest) (*v2.DeprovisionResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, v2.ContextError{Err: err}
+	}
+
+	return b.DeprovisionInstance(r)
+}
+
+// GetInstanceWithContext implements the ClientWithContext.GetInstanceWithContext
+// method for the StatefulBroker.
+func (b *StatefulBroker) GetInstanceWithContext(ctx context.Context, r *v2.GetInstanceRequest) (*v2.GetInstanceResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, v2.ContextError{Err: err}
+	}
+
+	return b.GetInstance(r)
+}
+
+// PollLastOperationWithContext implements the ClientWithContext.PollLastOperationWithContext
+// method for the StatefulBroker.
+func (b *StatefulBroker) PollLastOperationWithContext(ctx context.Context, r *v2.LastOperationRequest) (*v2.LastOperationResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, v2.ContextError{Err: err}
+	}
+
+	return b.PollLastOperation(r)
+}
+
+// PollBindingLastOperationWithContext implements the ClientWithContext.PollBindingLastOperationWithContext
+// method for the StatefulBroker.
+func (b *StatefulBroker) PollBindingLastOperationWithContext(ctx context.Context, r *v2.BindingLastOperationRequest) (*v2.LastOperationResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, v2.ContextError{Err: err}
+	}
+
+	return b.PollBindingLastOperation(r)
+}
+
+// BindWithContext implements the ClientWithContext.BindWithContext
+// method for the StatefulBroker.
+func (b *StatefulBroker) BindWithContext(ctx context.Context, r *v2.BindRequest) (*v2.BindResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, v2.ContextError{Err: err}
+	}
+
+	return b.Bind(r)
+}
+
+// UnbindWithContext implements the ClientWithContext.UnbindWithContext
+// method for the StatefulBroker.
+func (b *StatefulBroker) UnbindWithContext(ctx context.Context, r *v2.UnbindRequest) (*v2.UnbindResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, v2.ContextError{Err: err}
+	}
+
+	return b.Unbind(r)
+}
+
+// GetBindingWithContext implements the ClientWithContext.GetBindingWithContext
+// method for the StatefulBroker.
+func (b *StatefulBroker) GetBindingWithContext(ctx context.Context, r *v2.GetBindingRequest) (*v2.GetBindingResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, v2.ContextError{Err: err}
+	}
+
+	return b.GetBinding(r)
+}
+
+// startOperation returns a new operation of the given type for the given
+// request, which is asynchronous if the broker is configured with AsyncSteps.
+// If FailOperation fails a synchronous operation, the error it fails with is
+// returned.
+func (b *StatefulBroker) startOperation(action ActionType, request interface{}, acceptsIncomplete bool) (*statefulOperation, error) {
+	if b.AsyncSteps > 0 && !acceptsIncomplete {
+		return nil, AsyncRequiredError()
+	}
+
+	var failure string
+	if b.FailOperation != nil {
+		failure = b.FailOperation(action, request)
+	}
+
+	if b.AsyncSteps <= 0 {
+		if failure != "" {
+			return nil, operationFailedError(failure)
+		}
+		return &statefulOperation{action: action, state: v2.StateSucceeded}, nil
+	}
+
+	b.operationID++
+	return &statefulOperation{
+		action:    action,
+		key:       v2.OperationKey(fmt.Sprintf("%s-%d", action, b.operationID)),
+		remaining: b.AsyncSteps,
+		state:     v2.StateInProgress,
+		failure:   failure,
+	}, nil
+}
+
+// poll advances the operation by one step and returns its state.
+func (o *statefulOperation) poll() *v2.LastOperationResponse {
+	if o.state == v2.StateInProgress {
+		if o.remaining > 0 {
+			o.remaining--
+		} else if o.failure != "" {
+			o.state = v2.StateFailed
+		} else {
+			o.state = v2.StateSucceeded
+		}
+	}
+
+	response := &v2.LastOperationResponse{State: o.state}
+	if o.state == v2.StateFailed {
+		response.Description = strPtr(o.failure)
+	}
+	return response
+}
+
+// validatePlan returns an error if the broker has a catalog and the given
+// service and plan are not in it.
+func (b *StatefulBroker) validatePlan(serviceID, planID string) error {
+	if b.Catalog == nil {
+		return nil
+	}
+
+	for _, service := range b.Catalog.Services {
+		if service.ID != serviceID {
+			continue
+		}
+		for _, plan := range service.Plans {
+			if plan.ID == planID {
+				return nil
+			}
+		}
+	}
+
+	return v2.HTTPStatusCodeError{
+		StatusCode:  http.StatusBadRequest,
+		Description: strPtr(fmt.Sprintf("service %q has no plan %q", serviceID, planID)),
+	}
+}
+
+func conflictError() error {
+	return v2.HTTPStatusCodeError{StatusCode: http.StatusConflict}
+}
+
+func notFoundError() error {
+	return v2.HTTPStatusCodeError{StatusCode: http.StatusNotFound}
+}
+
+func goneError() error {
+	return v2.HTTPStatusCodeError{StatusCode: http.StatusGone}
+}
+
+func operationFailedError(description string) error {
+	return v2.HTTPStatusCodeError{
+		StatusCode:  http.StatusInternalServerError,
+		Description: strPtr(description),
+	}
+}
+
+func bindingsExistError() error {
+	return v2.HTTPStatusCodeError{
+		StatusCode:  http.StatusUnprocessableEntity,
+		Description: strPtr("The service instance has bindings and cannot be deprovisioned."),
+	}
+}
@@ -0,0 +1,376 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake_test
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	v2 "sigs.k8s.io/go-open-service-broker-client/v2"
+	"sigs.k8s.io/go-open-service-broker-client/v2/fake"
+)
+
+const (
+	statefulServiceID  = "acb56d7c-XXXX-XXXX-XXXX-feb140a59a66"
+	statefulPlanID     = "d3031751-XXXX-XXXX-XXXX-a42377d3320e"
+	statefulInstanceID = "instance-1"
+	statefulBindingID  = "binding-1"
+)
+
+func statefulProvisionRequest() *v2.ProvisionRequest {
+	return &v2.ProvisionRequest{
+		InstanceID:        statefulInstanceID,
+		AcceptsIncomplete: true,
+		ServiceID:         statefulServiceID,
+		PlanID:            statefulPlanID,
+		Parameters:        map[string]interface{}{"size": "small"},
+	}
+}
+
+func statefulBindRequest() *v2.BindRequest {
+	return &v2.BindRequest{
+		InstanceID:        statefulInstanceID,
+		BindingID:         statefulBindingID,
+		AcceptsIncomplete: true,
+		ServiceID:         statefulServiceID,
+		PlanID:            statefulPlanID,
+	}
+}
+
+func statefulDeprovisionRequest() *v2.DeprovisionRequest {
+	return &v2.DeprovisionRequest{
+		InstanceID:        statefulInstanceID,
+		AcceptsIncomplete: true,
+		ServiceID:         statefulServiceID,
+		PlanID:            statefulPlanID,
+	}
+}
+
+func statefulUnbindRequest() *v2.UnbindRequest {
+	return &v2.UnbindRequest{
+		InstanceID:        statefulInstanceID,
+		BindingID:         statefulBindingID,
+		AcceptsIncomplete: true,
+		ServiceID:         statefulServiceID,
+		PlanID:            statefulPlanID,
+	}
+}
+
+func expectStatusCode(t *testing.T, step string, err error, statusCode int) {
+	t.Helper()
+	httpErr, ok := v2.IsHTTPError(err)
+	if !ok {
+		t.Fatalf("%v: expected HTTP error with status %v, got %v", step, statusCode, err)
+	}
+	if httpErr.StatusCode != statusCode {
+		t.Fatalf("%v: expected status %v, got %v", step, statusCode, httpErr.StatusCode)
+	}
+}
+
+func TestStatefulBrokerSynchronous(t *testing.T) {
+	credentials := map[string]interface{}{"password": "secret"}
+	broker := fake.NewStatefulBroker(fake.StatefulBrokerConfiguration{
+		Catalog:     catalogResponse(),
+		Credentials: credentials,
+	})
+
+	if _, err := broker.ProvisionInstance(statefulProvisionRequest()); err != nil {
+		t.Fatalf("provision: unexpected error: %v", err)
+	}
+
+	// Repeating an identical request succeeds; a different one conflicts.
+	if _, err := broker.ProvisionInstance(statefulProvisionRequest()); err != nil {
+		t.Fatalf("identical provision: unexpected error: %v", err)
+	}
+	conflicting := statefulProvisionRequest()
+	conflicting.Parameters = map[string]interface{}{"size": "large"}
+	_, err := broker.ProvisionInstance(conflicting)
+	if !v2.IsConflictError(err) {
+		t.Fatalf("conflicting provision: expected conflict error, got %v", err)
+	}
+
+	unknownPlan := statefulProvisionRequest()
+	unknownPlan.InstanceID = "instance-2"
+	unknownPlan.PlanID = "unknown-plan"
+	_, err = broker.ProvisionInstance(unknownPlan)
+	expectStatusCode(t, "unknown plan", err, http.StatusBadRequest)
+
+	instance, err := broker.GetInstance(&v2.GetInstanceRequest{InstanceID: statefulInstanceID})
+	if err != nil {
+		t.Fatalf("get instance: unexpected error: %v", err)
+	}
+	expectedInstance := &v2.GetInstanceResponse{
+		ServiceID:  statefulServiceID,
+		PlanID:     statefulPlanID,
+		Parameters: map[string]interface{}{"size": "small"},
+	}
+	if !reflect.DeepEqual(expectedInstance, instance) {
+		t.Fatalf("get instance: expected %+v, got %+v", expectedInstance, instance)
+	}
+
+	bindResponse, err := broker.Bind(statefulBindRequest())
+	if err != nil {
+		t.Fatalf("bind: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(credentials, bindResponse.Credentials) {
+		t.Fatalf("bind: expected credentials %v, got %v", credentials, bindResponse.Credentials)
+	}
+
+	_, err = broker.DeprovisionInstance(statefulDeprovisionRequest())
+	expectStatusCode(t, "deprovision with bindings", err, http.StatusUnprocessableEntity)
+
+	if _, err := broker.Unbind(statefulUnbindRequest()); err != nil {
+		t.Fatalf("unbind: unexpected error: %v", err)
+	}
+	_, err = broker.GetBinding(&v2.GetBindingRequest{InstanceID: statefulInstanceID, BindingID: statefulBindingID})
+	expectStatusCode(t, "get deleted binding", err, http.StatusNotFound)
+
+	if _, err := broker.DeprovisionInstance(statefulDeprovisionRequest()); err != nil {
+		t.Fatalf("deprovision: unexpected error: %v", err)
+	}
+	_, err = broker.GetInstance(&v2.GetInstanceRequest{InstanceID: statefulInstanceID})
+	expectStatusCode(t, "get deleted instance", err, http.StatusNotFound)
+
+	// Deleting what does not exist succeeds, as it does with a real client.
+	if _, err := broker.DeprovisionInstance(statefulDeprovisionRequest()); err != nil {
+		t.Errorf("repeated deprovision: unexpected error: %v", err)
+	}
+	if _, err := broker.Unbind(statefulUnbindRequest()); err != nil {
+		t.Errorf("repeated unbind: unexpected error: %v", err)
+	}
+
+	if e, a := 13, len(broker.Actions()); e != a {
+		t.Errorf("expected %v actions, got %v", e, a)
+	}
+}
+
+func TestStatefulBrokerAsynchronous(t *testing.T) {
+	broker := fake.NewStatefulBroker(fake.StatefulBrokerConfiguration{
+		AsyncSteps: 2,
+	})
+
+	syncRequest := statefulProvisionRequest()
+	syncRequest.AcceptsIncomplete = false
+	_, err := broker.ProvisionInstance(syncRequest)
+	if !v2.IsAsyncRequiredError(err) {
+		t.Fatalf("synchronous provision: expected async required error, got %v", err)
+	}
+
+	response, err := broker.ProvisionInstance(statefulProvisionRequest())
+	if err != nil {
+		t.Fatalf("provision: unexpected error: %v", err)
+	}
+	if !response.Async || response.OperationKey == nil {
+		t.Fatalf("provision: expected asynchronous response, got %+v", response)
+	}
+
+	// The instance cannot be read or changed until provisioning finishes.
+	_, err = broker.GetInstance(&v2.GetInstanceRequest{InstanceID: statefulInstanceID})
+	expectStatusCode(t, "get provisioning instance", err, http.StatusNotFound)
+	_, err = broker.Bind(statefulBindRequest())
+	if !v2.IsConcurrencyError(err) {
+		t.Fatalf("bind during provision: expected concurrency error, got %v", err)
+	}
+
+	pollRequest := &v2.LastOperationRequest{
+		InstanceID:   statefulInstanceID,
+		OperationKey: response.OperationKey,
+	}
+	for i, expected := range []v2.LastOperationState{v2.StateInProgress, v2.StateInProgress, v2.StateSucceeded, v2.StateSucceeded} {
+		lastOperation, err := broker.PollLastOperation(pollRequest)
+		if err != nil {
+			t.Fatalf("poll %v: unexpected error: %v", i, err)
+		}
+		if lastOperation.State != expected {
+			t.Fatalf("poll %v: expected state %q, got %q", i, expected, lastOperation.State)
+		}
+	}
+
+	if _, err := broker.GetInstance(&v2.GetInstanceRequest{InstanceID: statefulInstanceID}); err != nil {
+		t.Fatalf("get instance: unexpected error: %v", err)
+	}
+
+	// Deprovisioning runs for AsyncSteps polls, after which the instance is
+	// gone.
+	deprovisionResponse, err := broker.DeprovisionInstance(statefulDeprovisionRequest())
+	if err != nil {
+		t.Fatalf("deprovision: unexpected error: %v", err)
+	}
+	result, err := v2.WaitForInstanceOperation(context.Background(), broker, &v2.LastOperationRequest{
+		InstanceID:   statefulInstanceID,
+		OperationKey: deprovisionResponse.OperationKey,
+	}, &v2.WaitOptions{
+		PollInterval: time.Millisecond,
+		Delete:       true,
+	})
+	if err != nil {
+		t.Fatalf("wait for deprovision: unexpected error: %v", err)
+	}
+	if result.State != v2.StateSucceeded || result.Polls != 3 {
+		t.Fatalf("wait for deprovision: unexpected result %+v", result)
+	}
+
+	_, err = broker.PollLastOperation(pollRequest)
+	if !v2.IsGoneError(err) {
+		t.Fatalf("poll deleted instance: expected gone error, got %v", err)
+	}
+}
+
+func TestStatefulBrokerAsynchronousBinding(t *testing.T) {
+	broker := fake.NewStatefulBroker(fake.StatefulBrokerConfiguration{
+		AsyncSteps: 1,
+	})
+
+	response, err := broker.ProvisionInstance(statefulProvisionRequest())
+	if err != nil {
+		t.Fatalf("provision: unexpected error: %v", err)
+	}
+	_, err = v2.WaitForInstanceOperation(context.Background(), broker, &v2.LastOperationRequest{
+		InstanceID:   statefulInstanceID,
+		OperationKey: response.OperationKey,
+	}, &v2.WaitOptions{PollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("wait for provision: unexpected error: %v", err)
+	}
+
+	bindResponse, err := broker.Bind(statefulBindRequest())
+	if err != nil {
+		t.Fatalf("bind: unexpected error: %v", err)
+	}
+	if !bindResponse.Async {
+		t.Fatalf("bind: expected asynchronous response")
+	}
+
+	// Repeating the request while it is in progress returns the same
+	// operation.
+	repeated, err := broker.Bind(statefulBindRequest())
+	if err != nil {
+		t.Fatalf("repeated bind: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(bindResponse, repeated) {
+		t.Fatalf("repeated bind: expected %+v, got %+v", bindResponse, repeated)
+	}
+
+	_, err = broker.Unbind(statefulUnbindRequest())
+	if !v2.IsConcurrencyError(err) {
+		t.Fatalf("unbind during bind: expected concurrency error, got %v", err)
+	}
+
+	pollRequest := &v2.BindingLastOperationRequest{
+		InstanceID:   statefulInstanceID,
+		BindingID:    statefulBindingID,
+		OperationKey: bindResponse.OperationKey,
+	}
+	if _, err := broker.PollBindingLastOperation(pollRequest); err != nil {
+		t.Fatalf("poll bind: unexpected error: %v", err)
+	}
+	lastOperation, err := broker.PollBindingLastOperation(pollRequest)
+	if err != nil || lastOperation.State != v2.StateSucceeded {
+		t.Fatalf("poll bind: expected success, got %+v, %v", lastOperation, err)
+	}
+
+	if _, err := broker.GetBinding(&v2.GetBindingRequest{InstanceID: statefulInstanceID, BindingID: statefulBindingID}); err != nil {
+		t.Fatalf("get binding: unexpected error: %v", err)
+	}
+
+	if _, err := broker.Unbind(statefulUnbindRequest()); err != nil {
+		t.Fatalf("unbind: unexpected error: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := broker.PollBindingLastOperation(pollRequest); err != nil {
+			t.Fatalf("poll unbind: unexpected error: %v", err)
+		}
+	}
+	_, err = broker.PollBindingLastOperation(pollRequest)
+	if !v2.IsGoneError(err) {
+		t.Fatalf("poll deleted binding: expected gone error, got %v", err)
+	}
+}
+
+func TestStatefulBrokerFailOperation(t *testing.T) {
+	failProvision := func(action fake.ActionType, request interface{}) string {
+		if action == fake.ProvisionInstance {
+			return "out of capacity"
+		}
+		return ""
+	}
+
+	// A synchronous operation fails immediately, leaving no instance.
+	broker := fake.NewStatefulBroker(fake.StatefulBrokerConfiguration{
+		FailOperation: failProvision,
+	})
+	_, err := broker.ProvisionInstance(statefulProvisionRequest())
+	expectStatusCode(t, "synchronous provision", err, http.StatusInternalServerError)
+	_, err = broker.GetInstance(&v2.GetInstanceRequest{InstanceID: statefulInstanceID})
+	expectStatusCode(t, "get failed instance", err, http.StatusNotFound)
+
+	// An asynchronous operation fails once it finishes, leaving an instance
+	// that cannot be read or bound to until it is deprovisioned.
+	broker = fake.NewStatefulBroker(fake.StatefulBrokerConfiguration{
+		AsyncSteps:    1,
+		FailOperation: failProvision,
+	})
+	response, err := broker.ProvisionInstance(statefulProvisionRequest())
+	if err != nil {
+		t.Fatalf("provision: unexpected error: %v", err)
+	}
+	result, err := v2.WaitForInstanceOperation(context.Background(), broker, &v2.LastOperationRequest{
+		InstanceID:   statefulInstanceID,
+		OperationKey: response.OperationKey,
+	}, &v2.WaitOptions{PollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("wait for provision: unexpected error: %v", err)
+	}
+	if result.State != v2.StateFailed || result.Description == nil || *result.Description != "out of capacity" {
+		t.Fatalf("wait for provision: unexpected result %+v", result)
+	}
+
+	_, err = broker.GetInstance(&v2.GetInstanceRequest{InstanceID: statefulInstanceID})
+	expectStatusCode(t, "get failed instance", err, http.StatusNotFound)
+	_, err = broker.Bind(statefulBindRequest())
+	expectStatusCode(t, "bind to failed instance", err, http.StatusNotFound)
+
+	if _, err := broker.DeprovisionInstance(statefulDeprovisionRequest()); err != nil {
+		t.Fatalf("deprovision: unexpected error: %v", err)
+	}
+}
+
+func TestStatefulBrokerWithContext(t *testing.T) {
+	broker := fake.NewStatefulBroker(fake.StatefulBrokerConfiguration{})
+
+	if _, err := broker.ProvisionInstanceWithContext(context.Background(), statefulProvisionRequest()); err != nil {
+		t.Fatalf("provision: unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := broker.DeprovisionInstanceWithContext(ctx, statefulDeprovisionRequest())
+	if !v2.IsCanceledError(err) {
+		t.Fatalf("deprovision: expected canceled error, got %v", err)
+	}
+	if _, err := broker.GetInstance(&v2.GetInstanceRequest{InstanceID: statefulInstanceID}); err != nil {
+		t.Fatalf("get instance: unexpected error: %v", err)
+	}
+
+	if e, a := 2, len(broker.Actions()); e != a {
+		t.Errorf("expected %v actions, got %v", e, a)
+	}
+}
@@ -0,0 +1,514 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fakeserver contains a fake service broker that serves the Open
+// Service Broker API over HTTP.  Unlike the fake package, which replaces the
+// client, it is meant to be used with a real client created by
+// v2.NewClient, typically by serving the Handler with the net/http/httptest
+// package:
+//
+//	handler, err := fakeserver.NewHandler(fakeserver.Config{Catalog: catalog})
+//	server := httptest.NewServer(handler)
+//	defer server.Close()
+//	client, err := v2.NewClient(&v2.ClientConfiguration{URL: server.URL, ...})
+//
+// The Handler serves a fake.StatefulBroker, and responds as a broker does
+// where the StatefulBroker returns what a client would: deleting an instance
+// or binding that does not exist is a successful call of the StatefulBroker,
+// but the Handler responds to it with a 410 Gone.
+package fakeserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	v2 "sigs.k8s.io/go-open-service-broker-client/v2"
+	"sigs.k8s.io/go-open-service-broker-client/v2/fake"
+	"sigs.k8s.io/go-open-service-broker-client/v2/generator"
+)
+
+// Config is the configuration of a Handler.
+type Config struct {
+	// Catalog is the catalog served by the broker.  If set, requests for
+	// services and plans that are not in it are rejected.
+	Catalog *v2.CatalogResponse
+	// Generator, if set and Catalog is not, is used to generate the catalog
+	// served by the broker.
+	Generator *generator.Generator
+	// APIVersions are the values of the X-Broker-API-Version header the
+	// broker accepts.  Requests with a missing or different header are
+	// rejected with a 412 Precondition Failed response.  Defaults to every
+	// version supported by the client.
+	APIVersions []v2.APIVersion
+	// AuthConfig, if set, holds the credentials that requests must carry.
+	// Requests with missing or different credentials are rejected with a 401
+	// Unauthorized response.  The Handler does not issue OAuth2 tokens, so
+	// with an OAuth2ClientCredentialsConfig it requires a bearer token but
+	// does not check its value, and with an AuthProvider, whose credentials
+	// only the provider knows, it accepts every request.
+	AuthConfig *v2.AuthConfig
+	// AsyncSteps is the number of times polling the last operation of an
+	// instance or binding reports an operation as in progress before it
+	// succeeds.  If zero, every operation completes synchronously; otherwise
+	// every operation is asynchronous, and requests without the
+	// accepts_incomplete=true query parameter are rejected with an
+	// AsyncRequired error.
+	AsyncSteps int
+	// Credentials are the credentials returned for every binding.
+	Credentials map[string]interface{}
+	// FailOperation, if set, makes operations fail as described for the
+	// StatefulBrokerConfiguration of the fake package.  Synchronous
+	// operations that fail are rejected with a 500 Internal Server Error.
+	FailOperation func(action fake.ActionType, request interface{}) string
+}
+
+// Request is a record of a request received by a Handler.
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+// Handler is an http.Handler implementing the Open Service Broker API.  It
+// decodes the requests it receives, passes them to a fake.StatefulBroker,
+// which keeps the instances and bindings in memory and enforces the semantics
+// of the specification, and writes the status codes and error bodies a broker
+// would for its responses.  Handler records every request it receives and is
+// threadsafe.
+type Handler struct {
+	config Config
+	broker *fake.StatefulBroker
+
+	// The Mutex is held while a request is served, so that checking whether
+	// an instance or binding exists and creating it are atomic.
+	sync.Mutex
+	requests []Request
+}
+
+var _ http.Handler = &Handler{}
+
+// NewHandler returns a new Handler with the given Config and no instances or
+// bindings.
+func NewHandler(config Config) (*Handler, error) {
+	catalog := config.Catalog
+	if catalog == nil && config.Generator != nil {
+		generated, err := config.Generator.GetCatalog()
+		if err != nil {
+			return nil, err
+		}
+		catalog = generated
+	}
+	if len(config.APIVersions) == 0 {
+		config.APIVersions = v2.APIVersions()
+	}
+
+	return &Handler{
+		config: config,
+		broker: fake.NewStatefulBroker(fake.StatefulBrokerConfiguration{
+			Catalog:       catalog,
+			AsyncSteps:    config.AsyncSteps,
+			Credentials:   config.Credentials,
+			FailOperation: config.FailOperation,
+		}),
+	}, nil
+}
+
+// Requests returns the requests received by the Handler.
+func (h *Handler) Requests() []Request {
+	h.Mutex.Lock()
+	defer h.Mutex.Unlock()
+
+	return h.requests
+}
+
+// Broker returns the StatefulBroker that holds the state of the Handler.
+// Its actions are those of the requests that passed the authentication and
+// API version checks.
+func (h *Handler) Broker() *fake.StatefulBroker {
+	return h.broker
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	h.Mutex.Lock()
+	defer h.Mutex.Unlock()
+
+	h.requests = append(h.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+
+	// Brokers echo the request identity on their responses.
+	if requestIdentity := r.Header.Get(v2.RequestIdentityHeader); requestIdentity != "" {
+		w.Header().Set(v2.RequestIdentityHeader, requestIdentity)
+	}
+
+	if !h.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "", "missing or invalid credentials")
+		return
+	}
+	if !h.supportedAPIVersion(r.Header.Get(v2.APIVersionHeader)) {
+		writeError(w, http.StatusPreconditionFailed, "", fmt.Sprintf("unsupported API version %q", r.Header.Get(v2.APIVersionHeader)))
+		return
+	}
+
+	// The path is one of /v2/catalog, /v2/service_instances/:id,
+	// /v2/service_instances/:id/last_operation,
+	// /v2/service_instances/:id/service_bindings/:binding_id and
+	// /v2/service_instances/:id/service_bindings/:binding_id/last_operation.
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 2 && parts[0] == "v2" && parts[1] == "catalog":
+		h.serveCatalog(w, r)
+	case len(parts) == 3 && parts[0] == "v2" && parts[1] == "service_instances":
+		h.serveInstance(w, r, parts[2], body)
+	case len(parts) == 4 && parts[0] == "v2" && parts[1] == "service_instances" && parts[3] == "last_operation":
+		h.serveInstanceLastOperation(w, r, parts[2])
+	case len(parts) == 5 && parts[0] == "v2" && parts[1] == "service_instances" && parts[3] == "service_bindings":
+		h.serveBinding(w, r, parts[2], parts[4], body)
+	case len(parts) == 6 && parts[0] == "v2" && parts[1] == "service_instances" && parts[3] == "service_bindings" && parts[5] == "last_operation":
+		h.serveBindingLastOperation(w, r, parts[2], parts[4])
+	default:
+		writeError(w, http.StatusNotFound, "", fmt.Sprintf("unknown path %q", r.URL.Path))
+	}
+}
+
+func (h *Handler) authorized(r *http.Request) bool {
+	auth := h.config.AuthConfig
+	switch {
+	case auth == nil:
+		return true
+	case auth.BasicAuthConfig != nil:
+		username, password, ok := r.BasicAuth()
+		return ok && username == auth.BasicAuthConfig.Username && password == auth.BasicAuthConfig.Password
+	case auth.BearerConfig != nil:
+		return r.Header.Get("Authorization") == "Bearer "+auth.BearerConfig.Token
+	case auth.OAuth2ClientCredentialsConfig != nil:
+		header := r.Header.Get("Authorization")
+		return strings.HasPrefix(header, "Bearer ") && len(header) > len("Bearer ")
+	}
+
+	// An AuthProvider is accepted without checks.
+	return true
+}
+
+func (h *Handler) supportedAPIVersion(header string) bool {
+	for _, version := range h.config.APIVersions {
+		if version.HeaderValue() == header {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (h *Handler) serveCatalog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "", "")
+		return
+	}
+
+	catalog, err := h.broker.GetCatalog()
+	if err != nil {
+		writeBrokerError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, catalog)
+}
+
+func (h *Handler) serveInstance(w http.ResponseWriter, r *http.Request, instanceID string, body []byte) {
+	switch r.Method {
+	case http.MethodPut:
+		request := &v2.ProvisionRequest{}
+		if !decodeBody(w, body, request) {
+			return
+		}
+		request.InstanceID = instanceID
+		request.AcceptsIncomplete = acceptsIncomplete(r)
+
+		existed := h.broker.HasInstance(instanceID)
+		response, err := h.broker.ProvisionInstance(request)
+		if err != nil {
+			writeBrokerError(w, err)
+			return
+		}
+		writeOperationResponse(w, response.Async, response.OperationKey, createdStatus(existed), map[string]interface{}{})
+	case http.MethodPatch:
+		request := &v2.UpdateInstanceRequest{}
+		if !decodeBody(w, body, request) {
+			return
+		}
+		request.InstanceID = instanceID
+		request.AcceptsIncomplete = acceptsIncomplete(r)
+
+		response, err := h.broker.UpdateInstance(request)
+		if err != nil {
+			writeBrokerError(w, err)
+			return
+		}
+		writeOperationResponse(w, response.Async, response.OperationKey, http.StatusOK, map[string]interface{}{})
+	case http.MethodDelete:
+		serviceID, planID, ok := requiredQuery(w, r)
+		if !ok {
+			return
+		}
+
+		existed := h.broker.HasInstance(instanceID)
+		response, err := h.broker.DeprovisionInstance(&v2.DeprovisionRequest{
+			InstanceID:        instanceID,
+			AcceptsIncomplete: acceptsIncomplete(r),
+			ServiceID:         serviceID,
+			PlanID:            planID,
+		})
+		if err != nil {
+			writeBrokerError(w, err)
+			return
+		}
+		writeOperationResponse(w, response.Async, response.OperationKey, deletedStatus(existed), map[string]interface{}{})
+	case http.MethodGet:
+		response, err := h.broker.GetInstance(&v2.GetInstanceRequest{InstanceID: instanceID})
+		if err != nil {
+			writeBrokerError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, response)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "", "")
+	}
+}
+
+func (h *Handler) serveInstanceLastOperation(w http.ResponseWriter, r *http.Request, instanceID string) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "", "")
+		return
+	}
+
+	query := r.URL.Query()
+	response, err := h.broker.PollLastOperation(&v2.LastOperationRequest{
+		InstanceID:   instanceID,
+		ServiceID:    optionalString(query.Get(v2.VarKeyServiceID)),
+		PlanID:       optionalString(query.Get(v2.VarKeyPlanID)),
+		OperationKey: optionalOperationKey(query.Get(v2.VarKeyOperation)),
+	})
+	if err != nil {
+		writeBrokerError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) serveBinding(w http.ResponseWriter, r *http.Request, instanceID, bindingID string, body []byte) {
+	switch r.Method {
+	case http.MethodPut:
+		request := &v2.BindRequest{}
+		if !decodeBody(w, body, request) {
+			return
+		}
+		request.InstanceID = instanceID
+		request.BindingID = bindingID
+		request.AcceptsIncomplete = acceptsIncomplete(r)
+
+		existed := h.broker.HasBinding(instanceID, bindingID)
+		response, err := h.broker.Bind(request)
+		if err != nil {
+			writeBrokerError(w, err)
+			return
+		}
+		writeOperationResponse(w, response.Async, response.OperationKey, createdStatus(existed), map[string]interface{}{"credentials": response.Credentials})
+	case http.MethodDelete:
+		serviceID, planID, ok := requiredQuery(w, r)
+		if !ok {
+			return
+		}
+
+		existed := h.broker.HasBinding(instanceID, bindingID)
+		response, err := h.broker.Unbind(&v2.UnbindRequest{
+			InstanceID:        instanceID,
+			BindingID:         bindingID,
+			AcceptsIncomplete: acceptsIncomplete(r),
+			ServiceID:         serviceID,
+			PlanID:            planID,
+		})
+		if err != nil {
+			writeBrokerError(w, err)
+			return
+		}
+		writeOperationResponse(w, response.Async, response.OperationKey, deletedStatus(existed), map[string]interface{}{})
+	case http.MethodGet:
+		response, err := h.broker.GetBinding(&v2.GetBindingRequest{InstanceID: instanceID, BindingID: bindingID})
+		if err != nil {
+			writeBrokerError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, response)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "", "")
+	}
+}
+
+func (h *Handler) serveBindingLastOperation(w http.ResponseWriter, r *http.Request, instanceID, bindingID string) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "", "")
+		return
+	}
+
+	query := r.URL.Query()
+	response, err := h.broker.PollBindingLastOperation(&v2.BindingLastOperationRequest{
+		InstanceID:   instanceID,
+		BindingID:    bindingID,
+		ServiceID:    optionalString(query.Get(v2.VarKeyServiceID)),
+		PlanID:       optionalString(query.Get(v2.VarKeyPlanID)),
+		OperationKey: optionalOperationKey(query.Get(v2.VarKeyOperation)),
+	})
+	if err != nil {
+		writeBrokerError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func acceptsIncomplete(r *http.Request) bool {
+	return r.URL.Query().Get(v2.AcceptsIncomplete) == "true"
+}
+
+// requiredQuery returns the service_id and plan_id query parameters, which
+// are required on requests to delete instances and bindings.  If either is
+// missing, it writes a 400 Bad Request response and returns false.
+func requiredQuery(w http.ResponseWriter, r *http.Request) (serviceID, planID string, ok bool) {
+	serviceID = r.URL.Query().Get(v2.VarKeyServiceID)
+	planID = r.URL.Query().Get(v2.VarKeyPlanID)
+	if serviceID == "" || planID == "" {
+		writeError(w, http.StatusBadRequest, "", "service_id and plan_id are required")
+		return "", "", false
+	}
+
+	return serviceID, planID, true
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func optionalOperationKey(s string) *v2.OperationKey {
+	if s == "" {
+		return nil
+	}
+	key := v2.OperationKey(s)
+	return &key
+}
+
+// createdStatus returns the status of a successful synchronous provision or
+// bind: 200 OK if the instance or binding already existed, and 201 Created
+// otherwise.
+func createdStatus(existed bool) int {
+	if existed {
+		return http.StatusOK
+	}
+	return http.StatusCreated
+}
+
+// deletedStatus returns the status of a successful synchronous deprovision or
+// unbind: 200 OK if the instance or binding existed, and 410 Gone otherwise.
+// The StatefulBroker reports both as successful, as the client does.
+func deletedStatus(existed bool) int {
+	if existed {
+		return http.StatusOK
+	}
+	return http.StatusGone
+}
+
+func decodeBody(w http.ResponseWriter, body []byte, obj interface{}) bool {
+	if err := json.Unmarshal(body, obj); err != nil {
+		writeError(w, http.StatusBadRequest, "", fmt.Sprintf("malformed request body: %v", err))
+		return false
+	}
+
+	return true
+}
+
+// writeOperationResponse writes the response to a request that starts an
+// operation: a 202 Accepted response with the operation key if the operation
+// is asynchronous, and the given status and body otherwise.
+func writeOperationResponse(w http.ResponseWriter, async bool, key *v2.OperationKey, status int, body map[string]interface{}) {
+	if !async {
+		writeJSON(w, status, body)
+		return
+	}
+
+	accepted := map[string]interface{}{}
+	if key != nil {
+		accepted["operation"] = *key
+	}
+	writeJSON(w, http.StatusAccepted, accepted)
+}
+
+// writeBrokerError writes the response for an error returned by the
+// StatefulBroker.  HTTP errors are written with their status code, error
+// message and description; any other error is about the request itself, such
+// as a missing required field, and is written as a 400 Bad Request.
+func writeBrokerError(w http.ResponseWriter, err error) {
+	httpErr, ok := v2.IsHTTPError(err)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "", err.Error())
+		return
+	}
+
+	var errorMessage, description string
+	if httpErr.ErrorMessage != nil {
+		errorMessage = *httpErr.ErrorMessage
+	}
+	if httpErr.Description != nil {
+		description = *httpErr.Description
+	}
+	writeError(w, httpErr.StatusCode, errorMessage, description)
+}
+
+func writeError(w http.ResponseWriter, status int, errorMessage, description string) {
+	body := map[string]interface{}{}
+	if errorMessage != "" {
+		body["error"] = errorMessage
+	}
+	if description != "" {
+		body["description"] = description
+	}
+
+	writeJSON(w, status, body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, obj interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(obj)
+}
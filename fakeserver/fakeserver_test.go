@@ -0,0 +1,501 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeserver_test
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	v2 "sigs.k8s.io/go-open-service-broker-client/v2"
+	"sigs.k8s.io/go-open-service-broker-client/v2/fake"
+	"sigs.k8s.io/go-open-service-broker-client/v2/fakeserver"
+	"sigs.k8s.io/go-open-service-broker-client/v2/generator"
+)
+
+const (
+	testServiceID  = "test-service-id"
+	testPlanID     = "test-plan-id"
+	testInstanceID = "test-instance-id"
+	testBindingID  = "test-binding-id"
+)
+
+func testCatalog() *v2.CatalogResponse {
+	return &v2.CatalogResponse{
+		Services: []v2.Service{
+			{
+				ID:          testServiceID,
+				Name:        "test-service",
+				Description: "test service",
+				Bindable:    true,
+				Plans: []v2.Plan{
+					{
+						ID:          testPlanID,
+						Name:        "test-plan",
+						Description: "test plan",
+					},
+				},
+			},
+		},
+	}
+}
+
+func testAuthConfig() *v2.AuthConfig {
+	return &v2.AuthConfig{
+		BasicAuthConfig: &v2.BasicAuthConfig{
+			Username: "user",
+			Password: "pass",
+		},
+	}
+}
+
+func newServer(t *testing.T, config fakeserver.Config) (*httptest.Server, *fakeserver.Handler) {
+	handler, err := fakeserver.NewHandler(config)
+	if err != nil {
+		t.Fatalf("unexpected error creating handler: %v", err)
+	}
+
+	return httptest.NewServer(handler), handler
+}
+
+func newClient(t *testing.T, server *httptest.Server, authConfig *v2.AuthConfig) v2.Client {
+	config := v2.DefaultClientConfiguration()
+	config.URL = server.URL
+	config.AuthConfig = authConfig
+
+	client, err := v2.NewClient(config)
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+
+	return client
+}
+
+func provisionRequest(acceptsIncomplete bool) *v2.ProvisionRequest {
+	return &v2.ProvisionRequest{
+		InstanceID:        testInstanceID,
+		AcceptsIncomplete: acceptsIncomplete,
+		ServiceID:         testServiceID,
+		PlanID:            testPlanID,
+		OrganizationGUID:  "test-organization-guid",
+		SpaceGUID:         "test-space-guid",
+		Parameters:        map[string]interface{}{"size": "small"},
+	}
+}
+
+func deprovisionRequest(acceptsIncomplete bool) *v2.DeprovisionRequest {
+	return &v2.DeprovisionRequest{
+		InstanceID:        testInstanceID,
+		AcceptsIncomplete: acceptsIncomplete,
+		ServiceID:         testServiceID,
+		PlanID:            testPlanID,
+	}
+}
+
+func bindRequest() *v2.BindRequest {
+	return &v2.BindRequest{
+		InstanceID: testInstanceID,
+		BindingID:  testBindingID,
+		ServiceID:  testServiceID,
+		PlanID:     testPlanID,
+	}
+}
+
+func unbindRequest() *v2.UnbindRequest {
+	return &v2.UnbindRequest{
+		InstanceID: testInstanceID,
+		BindingID:  testBindingID,
+		ServiceID:  testServiceID,
+		PlanID:     testPlanID,
+	}
+}
+
+func expectStatusCode(t *testing.T, step string, err error, statusCode int) {
+	t.Helper()
+	httpErr, ok := v2.IsHTTPError(err)
+	if !ok {
+		t.Fatalf("%v: expected HTTP error with status %v, got %v", step, statusCode, err)
+	}
+	if httpErr.StatusCode != statusCode {
+		t.Fatalf("%v: expected status %v, got %v", step, statusCode, httpErr.StatusCode)
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	credentials := map[string]interface{}{"password": "secret"}
+	server, handler := newServer(t, fakeserver.Config{
+		Catalog:     testCatalog(),
+		AuthConfig:  testAuthConfig(),
+		Credentials: credentials,
+	})
+	defer server.Close()
+	client := newClient(t, server, testAuthConfig())
+
+	catalog, err := client.GetCatalog()
+	if err != nil {
+		t.Fatalf("get catalog: unexpected error: %v", err)
+	}
+	if e, a := catalog.RequestIdentity, handler.Requests()[0].Header.Get(v2.RequestIdentityHeader); e == "" || e != a {
+		t.Errorf("get catalog: expected request identity %q to be sent, got %q", e, a)
+	}
+	catalog.RequestIdentity = ""
+	if e, a := testCatalog(), catalog; !reflect.DeepEqual(e, a) {
+		t.Fatalf("get catalog: expected %+v, got %+v", e, a)
+	}
+
+	if _, err := client.ProvisionInstance(provisionRequest(false)); err != nil {
+		t.Fatalf("provision: unexpected error: %v", err)
+	}
+	if _, err := client.ProvisionInstance(provisionRequest(false)); err != nil {
+		t.Fatalf("identical provision: unexpected error: %v", err)
+	}
+	conflicting := provisionRequest(false)
+	conflicting.Parameters = map[string]interface{}{"size": "large"}
+	if _, err := client.ProvisionInstance(conflicting); !v2.IsConflictError(err) {
+		t.Fatalf("conflicting provision: expected conflict error, got %v", err)
+	}
+
+	instance, err := client.GetInstance(&v2.GetInstanceRequest{InstanceID: testInstanceID})
+	if err != nil {
+		t.Fatalf("get instance: unexpected error: %v", err)
+	}
+	if instance.PlanID != testPlanID || !reflect.DeepEqual(instance.Parameters, map[string]interface{}{"size": "small"}) {
+		t.Fatalf("get instance: unexpected response %+v", instance)
+	}
+
+	bindResponse, err := client.Bind(bindRequest())
+	if err != nil {
+		t.Fatalf("bind: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(credentials, bindResponse.Credentials) {
+		t.Fatalf("bind: expected credentials %v, got %v", credentials, bindResponse.Credentials)
+	}
+
+	_, err = client.DeprovisionInstance(deprovisionRequest(false))
+	expectStatusCode(t, "deprovision with bindings", err, http.StatusUnprocessableEntity)
+
+	if _, err := client.Unbind(unbindRequest()); err != nil {
+		t.Fatalf("unbind: unexpected error: %v", err)
+	}
+	if _, err := client.DeprovisionInstance(deprovisionRequest(false)); err != nil {
+		t.Fatalf("deprovision: unexpected error: %v", err)
+	}
+	// The broker responds 410 Gone, which the client treats as success.
+	if _, err := client.DeprovisionInstance(deprovisionRequest(false)); err != nil {
+		t.Fatalf("repeated deprovision: unexpected error: %v", err)
+	}
+
+	requests := handler.Requests()
+	if e, a := 10, len(requests); e != a {
+		t.Fatalf("expected %v requests, got %v", e, a)
+	}
+	if e, a := http.MethodPut, requests[1].Method; e != a {
+		t.Errorf("expected method %v, got %v", e, a)
+	}
+	if e, a := "/v2/service_instances/"+testInstanceID, requests[1].Path; e != a {
+		t.Errorf("expected path %v, got %v", e, a)
+	}
+	if e, a := v2.LatestAPIVersion().HeaderValue(), requests[1].Header.Get(v2.APIVersionHeader); e != a {
+		t.Errorf("expected API version header %v, got %v", e, a)
+	}
+	if e, a := testServiceID, requests[9].Query.Get("service_id"); e != a {
+		t.Errorf("expected service_id query parameter %v, got %v", e, a)
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	server, _ := newServer(t, fakeserver.Config{AuthConfig: testAuthConfig()})
+	defer server.Close()
+
+	cases := []struct {
+		name       string
+		authConfig *v2.AuthConfig
+		expectErr  bool
+	}{
+		{
+			name:       "valid credentials",
+			authConfig: testAuthConfig(),
+		},
+		{
+			name:      "no credentials",
+			expectErr: true,
+		},
+		{
+			name: "wrong credentials",
+			authConfig: &v2.AuthConfig{
+				BearerConfig: &v2.BearerConfig{Token: "token"},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		_, err := newClient(t, server, tc.authConfig).GetCatalog()
+		if !tc.expectErr {
+			if err != nil {
+				t.Errorf("%v: unexpected error: %v", tc.name, err)
+			}
+			continue
+		}
+		if httpErr, ok := v2.IsHTTPError(err); !ok || httpErr.StatusCode != http.StatusUnauthorized {
+			t.Errorf("%v: expected 401 error, got %v", tc.name, err)
+		}
+	}
+}
+
+// TestAuthenticationWithoutChecks covers the authentication modes whose
+// credentials the Handler cannot know: an OAuth2 token is required but not
+// checked, and requests from an AuthProvider are accepted as they are.
+func TestAuthenticationWithoutChecks(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"any-token","token_type":"bearer","expires_in":3600}`))
+	}))
+	defer tokenServer.Close()
+
+	oauth2Config := &v2.AuthConfig{
+		OAuth2ClientCredentialsConfig: &v2.OAuth2ClientCredentialsConfig{
+			TokenURL:     tokenServer.URL,
+			ClientID:     "client",
+			ClientSecret: "secret",
+		},
+	}
+	oauth2Server, _ := newServer(t, fakeserver.Config{AuthConfig: oauth2Config})
+	defer oauth2Server.Close()
+
+	if _, err := newClient(t, oauth2Server, oauth2Config).GetCatalog(); err != nil {
+		t.Errorf("oauth2 token: unexpected error: %v", err)
+	}
+	_, err := newClient(t, oauth2Server, testAuthConfig()).GetCatalog()
+	expectStatusCode(t, "oauth2 without token", err, http.StatusUnauthorized)
+
+	providerConfig := &v2.AuthConfig{
+		AuthProvider: v2.BearerTokenFunc(func() (string, error) {
+			return "provided-token", nil
+		}),
+	}
+	providerServer, _ := newServer(t, fakeserver.Config{AuthConfig: providerConfig})
+	defer providerServer.Close()
+
+	if _, err := newClient(t, providerServer, providerConfig).GetCatalog(); err != nil {
+		t.Errorf("auth provider: unexpected error: %v", err)
+	}
+	if _, err := newClient(t, providerServer, nil).GetCatalog(); err != nil {
+		t.Errorf("auth provider without credentials: unexpected error: %v", err)
+	}
+}
+
+func TestAPIVersion(t *testing.T) {
+	server, _ := newServer(t, fakeserver.Config{
+		APIVersions: []v2.APIVersion{v2.Version2_13()},
+	})
+	defer server.Close()
+
+	_, err := newClient(t, server, nil).GetCatalog()
+	expectStatusCode(t, "unsupported version", err, http.StatusPreconditionFailed)
+
+	config := v2.DefaultClientConfiguration()
+	config.URL = server.URL
+	version, err := v2.NegotiateAPIVersion(config)
+	if err != nil {
+		t.Fatalf("negotiate: unexpected error: %v", err)
+	}
+	if e, a := v2.Version2_13(), version; e != a {
+		t.Fatalf("negotiate: expected version %v, got %v", e, a)
+	}
+}
+
+func TestDeleteMissing(t *testing.T) {
+	server, _ := newServer(t, fakeserver.Config{})
+	defer server.Close()
+
+	// Unlike the StatefulBroker, the server responds to deleting what does
+	// not exist with 410 Gone, which the client turns into a successful
+	// response.
+	for _, path := range []string{
+		"/v2/service_instances/" + testInstanceID,
+		"/v2/service_instances/" + testInstanceID + "/service_bindings/" + testBindingID,
+	} {
+		request, err := http.NewRequest(http.MethodDelete, server.URL+path+"?service_id="+testServiceID+"&plan_id="+testPlanID, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating request: %v", err)
+		}
+		request.Header.Set(v2.APIVersionHeader, v2.LatestAPIVersion().HeaderValue())
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", path, err)
+		}
+		response.Body.Close()
+		if e, a := http.StatusGone, response.StatusCode; e != a {
+			t.Errorf("%v: expected status %v, got %v", path, e, a)
+		}
+	}
+}
+
+func TestTLS(t *testing.T) {
+	handler, err := fakeserver.NewHandler(fakeserver.Config{Catalog: testCatalog()})
+	if err != nil {
+		t.Fatalf("unexpected error creating handler: %v", err)
+	}
+	server := httptest.NewTLSServer(handler)
+	defer server.Close()
+
+	config := v2.DefaultClientConfiguration()
+	config.URL = server.URL
+	config.CAData = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	client, err := v2.NewClient(config)
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+
+	if _, err := client.GetCatalog(); err != nil {
+		t.Fatalf("get catalog: unexpected error: %v", err)
+	}
+}
+
+func TestAsynchronousOperations(t *testing.T) {
+	server, _ := newServer(t, fakeserver.Config{
+		Catalog:    testCatalog(),
+		AsyncSteps: 2,
+	})
+	defer server.Close()
+	client := newClient(t, server, nil)
+
+	if _, err := client.ProvisionInstance(provisionRequest(false)); !v2.IsAsyncRequiredError(err) {
+		t.Fatalf("synchronous provision: expected async required error, got %v", err)
+	}
+
+	response, err := client.ProvisionInstance(provisionRequest(true))
+	if err != nil {
+		t.Fatalf("provision: unexpected error: %v", err)
+	}
+	if !response.Async || response.OperationKey == nil {
+		t.Fatalf("provision: expected asynchronous response, got %+v", response)
+	}
+
+	_, err = client.GetInstance(&v2.GetInstanceRequest{InstanceID: testInstanceID})
+	expectStatusCode(t, "get provisioning instance", err, http.StatusNotFound)
+
+	options := &v2.WaitOptions{PollInterval: time.Millisecond, Delete: true}
+	result, err := v2.WaitForInstanceOperation(context.Background(), client, &v2.LastOperationRequest{
+		InstanceID:   testInstanceID,
+		OperationKey: response.OperationKey,
+	}, options)
+	if err != nil {
+		t.Fatalf("wait for provision: unexpected error: %v", err)
+	}
+	if result.State != v2.StateSucceeded || result.Polls != 3 {
+		t.Fatalf("wait for provision: unexpected result %+v", result)
+	}
+
+	deprovisionResponse, err := client.DeprovisionInstance(deprovisionRequest(true))
+	if err != nil {
+		t.Fatalf("deprovision: unexpected error: %v", err)
+	}
+	result, err = v2.WaitForInstanceOperation(context.Background(), client, &v2.LastOperationRequest{
+		InstanceID:   testInstanceID,
+		OperationKey: deprovisionResponse.OperationKey,
+	}, options)
+	if err != nil {
+		t.Fatalf("wait for deprovision: unexpected error: %v", err)
+	}
+	if result.State != v2.StateSucceeded {
+		t.Fatalf("wait for deprovision: unexpected result %+v", result)
+	}
+
+	_, err = client.PollLastOperation(&v2.LastOperationRequest{InstanceID: testInstanceID})
+	if !v2.IsGoneError(err) {
+		t.Fatalf("poll deleted instance: expected gone error, got %v", err)
+	}
+}
+
+func TestGeneratedCatalog(t *testing.T) {
+	g := generator.CreateGenerator(2, generator.Parameters{
+		Services: generator.ServiceRanges{Plans: 3},
+	})
+	generator.AssignPoolGoT(g)
+	expected, err := g.GetCatalog()
+	if err != nil {
+		t.Fatalf("unexpected error generating catalog: %v", err)
+	}
+
+	server, _ := newServer(t, fakeserver.Config{Generator: g})
+	defer server.Close()
+	client := newClient(t, server, nil)
+
+	catalog, err := client.GetCatalog()
+	if err != nil {
+		t.Fatalf("get catalog: unexpected error: %v", err)
+	}
+	catalog.RequestIdentity = ""
+	if !reflect.DeepEqual(expected, catalog) {
+		t.Fatalf("expected generated catalog %+v, got %+v", expected, catalog)
+	}
+
+	// Requests for plans that are not in the generated catalog are rejected.
+	request := provisionRequest(false)
+	request.ServiceID = catalog.Services[0].ID
+	_, err = client.ProvisionInstance(request)
+	expectStatusCode(t, "unknown plan", err, http.StatusBadRequest)
+
+	request.PlanID = catalog.Services[0].Plans[0].ID
+	if _, err := client.ProvisionInstance(request); err != nil {
+		t.Fatalf("provision: unexpected error: %v", err)
+	}
+}
+
+func TestFailOperation(t *testing.T) {
+	server, handler := newServer(t, fakeserver.Config{
+		Catalog:    testCatalog(),
+		AsyncSteps: 1,
+		FailOperation: func(action fake.ActionType, request interface{}) string {
+			if action == fake.ProvisionInstance {
+				return "out of capacity"
+			}
+			return ""
+		},
+	})
+	defer server.Close()
+	client := newClient(t, server, nil)
+
+	response, err := client.ProvisionInstance(provisionRequest(true))
+	if err != nil {
+		t.Fatalf("provision: unexpected error: %v", err)
+	}
+	result, err := v2.WaitForInstanceOperation(context.Background(), client, &v2.LastOperationRequest{
+		InstanceID:   testInstanceID,
+		OperationKey: response.OperationKey,
+	}, &v2.WaitOptions{PollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("wait for provision: unexpected error: %v", err)
+	}
+	if result.State != v2.StateFailed || result.Description == nil || *result.Description != "out of capacity" {
+		t.Fatalf("wait for provision: unexpected result %+v", result)
+	}
+
+	_, err = client.GetInstance(&v2.GetInstanceRequest{InstanceID: testInstanceID})
+	expectStatusCode(t, "get failed instance", err, http.StatusNotFound)
+
+	actions := handler.Broker().Actions()
+	if e, a := fake.ProvisionInstance, actions[0].Type; e != a {
+		t.Errorf("expected first action %v, got %v", e, a)
+	}
+}
@@ -17,11 +17,19 @@ limitations under the License.
 package v2
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 )
 
 func (c *client) GetBinding(r *GetBindingRequest) (*GetBindingResponse, error) {
+	return c.GetBindingWithContext(context.Background(), r)
+}
+
+func (c *client) GetBindingWithContext(ctx context.Context, r *GetBindingRequest) (_ *GetBindingResponse, err error) {
+	ctx, span := c.startSpan(ctx, OperationGetBinding, spanIDs{instanceID: r.InstanceID, bindingID: r.BindingID})
+	defer func() { endSpan(span, err) }()
+
 	if err := c.validateClientVersionIsAtLeast(Version2_14()); err != nil {
 		return nil, GetBindingNotAllowedError{
 			reason: err.Error(),
@@ -30,7 +38,7 @@ func (c *client) GetBinding(r *GetBindingRequest) (*GetBindingResponse, error) {
 
 	fullURL := fmt.Sprintf(bindingURLFmt, c.URL, r.InstanceID, r.BindingID)
 
-	response, err := c.prepareAndDo(http.MethodGet, fullURL, nil /* params */, nil /* request body */, nil /* originating identity */)
+	response, err := c.prepareAndDo(ctx, OperationGetBinding, http.MethodGet, fullURL, nil /* params */, nil /* request body */, nil /* originating identity */, r.RequestIdentity)
 	if err != nil {
 		return nil, err
 	}
@@ -44,13 +52,17 @@ func (c *client) GetBinding(r *GetBindingRequest) (*GetBindingResponse, error) {
 	case http.StatusOK:
 		userResponse := &GetBindingResponse{}
 		if err := c.unmarshalResponse(response, userResponse); err != nil {
-			return nil, HTTPStatusCodeError{StatusCode: response.StatusCode, ResponseError: err}
+			return nil, unmarshalError(response, err)
 		}
 
 		if !c.EnableAlphaFeatures {
 			userResponse.Endpoints = nil
 		}
+		if c.APIVersion.IsLessThan(Version2_15()) {
+			userResponse.Metadata = nil
+		}
 
+		userResponse.RequestIdentity = requestIdentity(response)
 		return userResponse, nil
 	default:
 		return nil, c.handleFailureResponse(response)
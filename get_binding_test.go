@@ -52,6 +52,24 @@ func okGetBindingResponse() *GetBindingResponse {
 	}
 	return response
 }
+
+const okBindingWithMetadataBytes = `{
+  "credentials": {
+    "test-key": "foo"
+  },
+  "metadata": {
+    "expires_at": "2019-12-31T23:59:59.0Z"
+  }
+}`
+
+func okGetBindingResponseWithMetadata() *GetBindingResponse {
+	response := okGetBindingResponse()
+	response.Metadata = &BindingMetadata{
+		ExpiresAt: "2019-12-31T23:59:59.0Z",
+	}
+	return response
+}
+
 func okGetBindingEndpointResponse() *GetBindingResponse {
 	response := okGetBindingResponse()
 	response.Endpoints = &[]Endpoint{
@@ -81,7 +99,11 @@ func TestGetBinding(t *testing.T) {
 				status: http.StatusOK,
 				body:   okBindingBytes,
 			},
-			expectedResponse: okGetBindingResponse(),
+			expectedResponse: func() *GetBindingResponse {
+				response := okGetBindingResponse()
+				response.RequestIdentity = testRequestIdentity
+				return response
+			}(),
 		},
 		{
 			name: "http error",
@@ -112,7 +134,29 @@ func TestGetBinding(t *testing.T) {
 				status: http.StatusInternalServerError,
 				body:   conventionalFailureResponseBody,
 			},
-			expectedErr: testHTTPStatusCodeError(),
+			expectedErr: testHTTPStatusCodeErrorWithRequestIdentity(),
+		},
+		{
+			name:       "metadata - 2.15",
+			APIVersion: Version2_15(),
+			httpReaction: httpReaction{
+				status: http.StatusOK,
+				body:   okBindingWithMetadataBytes,
+			},
+			expectedResponse: func() *GetBindingResponse {
+				response := okGetBindingResponseWithMetadata()
+				response.RequestIdentity = testRequestIdentity
+				return response
+			}(),
+		},
+		{
+			name:       "metadata not returned unless API version >= 2.15",
+			APIVersion: Version2_14(),
+			httpReaction: httpReaction{
+				status: http.StatusOK,
+				body:   okBindingWithMetadataBytes,
+			},
+			expectedResponse: okGetBindingResponse(),
 		},
 		{
 			name:               "unsupported API version",
@@ -127,7 +171,11 @@ func TestGetBinding(t *testing.T) {
 				status: http.StatusOK,
 				body:   okBindingEndpointBytes,
 			},
-			expectedResponse: okGetBindingEndpointResponse(),
+			expectedResponse: func() *GetBindingResponse {
+				response := okGetBindingEndpointResponse()
+				response.RequestIdentity = testRequestIdentity
+				return response
+			}(),
 		},
 		{
 			name:        "alpha features disabled: binding with endpoints",
@@ -137,7 +185,11 @@ func TestGetBinding(t *testing.T) {
 				status: http.StatusOK,
 				body:   okBindingEndpointBytes,
 			},
-			expectedResponse: okGetBindingResponse(),
+			expectedResponse: func() *GetBindingResponse {
+				response := okGetBindingResponse()
+				response.RequestIdentity = testRequestIdentity
+				return response
+			}(),
 		},
 	}
 
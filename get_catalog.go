@@ -17,14 +17,22 @@ limitations under the License.
 package v2
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 )
 
 func (c *client) GetCatalog() (*CatalogResponse, error) {
+	return c.GetCatalogWithContext(context.Background())
+}
+
+func (c *client) GetCatalogWithContext(ctx context.Context) (_ *CatalogResponse, err error) {
+	ctx, span := c.startSpan(ctx, OperationGetCatalog, spanIDs{})
+	defer func() { endSpan(span, err) }()
+
 	fullURL := fmt.Sprintf(catalogURL, c.URL)
 
-	response, err := c.prepareAndDo(http.MethodGet, fullURL, nil /* params */, nil /* request body */, nil /* originating identity */)
+	response, err := c.prepareAndDo(ctx, OperationGetCatalog, http.MethodGet, fullURL, nil /* params */, nil /* request body */, nil /* originating identity */, "" /* request identity */)
 	if err != nil {
 		return nil, err
 	}
@@ -38,13 +46,14 @@ func (c *client) GetCatalog() (*CatalogResponse, error) {
 	case http.StatusOK:
 		catalogResponse := &CatalogResponse{}
 		if err := c.unmarshalResponse(response, catalogResponse); err != nil {
-			return nil, HTTPStatusCodeError{StatusCode: response.StatusCode, ResponseError: err}
+			return nil, unmarshalError(response, err)
 		}
 
 		if c.APIVersion.IsLessThan(Version2_13()) || !c.EnableAlphaFeatures {
 			c.pruneCatalogResponse(catalogResponse)
 		}
 
+		catalogResponse.RequestIdentity = requestIdentity(response)
 		return catalogResponse, nil
 	default:
 		return nil, c.handleFailureResponse(response)
@@ -57,8 +66,10 @@ func (c *client) pruneCatalogResponse(catalogResponse *CatalogResponse) {
 			if c.APIVersion.IsLessThan(Version2_13()) {
 				catalogResponse.Services[ii].Plans[jj].Schemas = nil
 			}
-			if !c.EnableAlphaFeatures {
+			if !c.maintenanceInfoAllowed() {
 				catalogResponse.Services[ii].Plans[jj].MaintenanceInfo = nil
+			}
+			if !c.EnableAlphaFeatures {
 				catalogResponse.Services[ii].Plans[jj].MaximumPollingDuration = nil
 				catalogResponse.Services[ii].Plans[jj].PlanUpdateable = nil
 			}
@@ -331,11 +331,15 @@ func TestGetCatalog(t *testing.T) {
 				status: http.StatusOK,
 				body:   okCatalog215Bytes,
 			},
-			expectedResponse: okCatalog215Response(),
+			expectedResponse: func() *CatalogResponse {
+				response := okCatalog215Response()
+				response.RequestIdentity = testRequestIdentity
+				return response
+			}(),
 		},
 		{
 			name:        "alpha disabled: plan has its own updateable attribute, max polling duration and maintenance info",
-			version:     LatestAPIVersion(),
+			version:     Version2_14(),
 			enableAlpha: false,
 			httpReaction: httpReaction{
 				status: http.StatusOK,
@@ -343,6 +347,24 @@ func TestGetCatalog(t *testing.T) {
 			},
 			expectedResponse: okCatalog2Response(),
 		},
+		{
+			name:        "alpha disabled: maintenance info included if API version >= 2.15",
+			version:     Version2_15(),
+			enableAlpha: false,
+			httpReaction: httpReaction{
+				status: http.StatusOK,
+				body:   okCatalog215Bytes,
+			},
+			expectedResponse: func() *CatalogResponse {
+				response := okCatalog2Response()
+				response.Services[0].Plans[0].MaintenanceInfo = &MaintenanceInfo{
+					Version:     "1.2.3",
+					Description: "Avast! Pieces o' madness are forever clear.",
+				}
+				response.RequestIdentity = testRequestIdentity
+				return response
+			}(),
+		},
 	}
 
 	for _, tc := range cases {
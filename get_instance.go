@@ -17,11 +17,19 @@ limitations under the License.
 package v2
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 )
 
 func (c *client) GetInstance(r *GetInstanceRequest) (*GetInstanceResponse, error) {
+	return c.GetInstanceWithContext(context.Background(), r)
+}
+
+func (c *client) GetInstanceWithContext(ctx context.Context, r *GetInstanceRequest) (_ *GetInstanceResponse, err error) {
+	ctx, span := c.startSpan(ctx, OperationGetInstance, spanIDs{instanceID: r.InstanceID})
+	defer func() { endSpan(span, err) }()
+
 	if err := c.validateClientVersionIsAtLeast(Version2_14()); err != nil {
 		return nil, GetInstanceNotAllowedError{
 			reason: err.Error(),
@@ -30,7 +38,7 @@ func (c *client) GetInstance(r *GetInstanceRequest) (*GetInstanceResponse, error
 
 	fullURL := fmt.Sprintf(serviceInstanceURLFmt, c.URL, r.InstanceID)
 
-	response, err := c.prepareAndDo(http.MethodGet, fullURL, nil /* params */, nil /* request body */, nil /* originating identity */)
+	response, err := c.prepareAndDo(ctx, OperationGetInstance, http.MethodGet, fullURL, nil /* params */, nil /* request body */, nil /* originating identity */, r.RequestIdentity)
 	if err != nil {
 		return nil, err
 	}
@@ -44,9 +52,14 @@ func (c *client) GetInstance(r *GetInstanceRequest) (*GetInstanceResponse, error
 	case http.StatusOK:
 		userResponse := &GetInstanceResponse{}
 		if err := c.unmarshalResponse(response, userResponse); err != nil {
-			return nil, HTTPStatusCodeError{StatusCode: response.StatusCode, ResponseError: err}
+			return nil, unmarshalError(response, err)
+		}
+
+		if c.APIVersion.IsLessThan(Version2_15()) {
+			userResponse.Metadata = nil
 		}
 
+		userResponse.RequestIdentity = requestIdentity(response)
 		return userResponse, nil
 	default:
 		return nil, c.handleFailureResponse(response)
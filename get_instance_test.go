@@ -41,6 +41,21 @@ func okGetInstanceResponse() *GetInstanceResponse {
 	return response
 }
 
+const okInstanceWithMetadataBytes = `{
+  "service_id": "test-service",
+  "plan_id": "test-plan",
+  "metadata": {
+    "labels": {"team": "data"},
+    "attributes": {"region": "eu-west-1"}
+  }
+}`
+
+func okGetInstanceResponseWithMetadata() *GetInstanceResponse {
+	response := okGetInstanceResponse()
+	response.Metadata = testInstanceMetadata()
+	return response
+}
+
 func TestGetInstance(t *testing.T) {
 	cases := []struct {
 		name               string
@@ -58,7 +73,11 @@ func TestGetInstance(t *testing.T) {
 				status: http.StatusOK,
 				body:   okInstanceBytes,
 			},
-			expectedResponse: okGetInstanceResponse(),
+			expectedResponse: func() *GetInstanceResponse {
+				response := okGetInstanceResponse()
+				response.RequestIdentity = testRequestIdentity
+				return response
+			}(),
 		},
 		{
 			name: "http error",
@@ -89,7 +108,29 @@ func TestGetInstance(t *testing.T) {
 				status: http.StatusInternalServerError,
 				body:   conventionalFailureResponseBody,
 			},
-			expectedErr: testHTTPStatusCodeError(),
+			expectedErr: testHTTPStatusCodeErrorWithRequestIdentity(),
+		},
+		{
+			name:       "metadata - 2.15",
+			APIVersion: Version2_15(),
+			httpReaction: httpReaction{
+				status: http.StatusOK,
+				body:   okInstanceWithMetadataBytes,
+			},
+			expectedResponse: func() *GetInstanceResponse {
+				response := okGetInstanceResponseWithMetadata()
+				response.RequestIdentity = testRequestIdentity
+				return response
+			}(),
+		},
+		{
+			name:       "metadata not returned unless API version >= 2.15",
+			APIVersion: Version2_14(),
+			httpReaction: httpReaction{
+				status: http.StatusOK,
+				body:   okInstanceWithMetadataBytes,
+			},
+			expectedResponse: okGetInstanceResponse(),
 		},
 		{
 			name:               "unsupported API version",
@@ -2,4 +2,7 @@ module sigs.k8s.io/go-open-service-broker-client/v2
 
 go 1.14
 
-require k8s.io/klog/v2 v2.0.0
+require (
+	github.com/go-logr/logr v0.1.0
+	k8s.io/klog/v2 v2.0.0
+)
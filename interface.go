@@ -17,15 +17,25 @@ limitations under the License.
 package v2
 
 import (
+	"context"
 	"crypto/tls"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
 )
 
 // AuthConfig is a union-type representing the possible auth configurations a
-// client may use to authenticate to a broker.  Currently, only basic auth is
-// supported.
+// client may use to authenticate to a broker.  Exactly one of its fields must
+// be set.
 type AuthConfig struct {
-	BasicAuthConfig *BasicAuthConfig
-	BearerConfig    *BearerConfig
+	BasicAuthConfig               *BasicAuthConfig
+	BearerConfig                  *BearerConfig
+	OAuth2ClientCredentialsConfig *OAuth2ClientCredentialsConfig
+	// AuthProvider supplies credentials for each request, for credentials
+	// that are rotated while the client is in use.  See
+	// NewBasicAuthFileProvider and NewBearerTokenFileProvider.
+	AuthProvider AuthProvider
 }
 
 // BasicAuthConfig represents a set of basic auth credentials.
@@ -42,6 +52,33 @@ type BearerConfig struct {
 	Token string
 }
 
+// OAuth2ClientCredentialsConfig represents credentials for the OAuth2 client
+// credentials grant.  The client fetches an access token from the token
+// endpoint and sends it to the broker as a bearer token.  The token is cached
+// and refreshed shortly before it expires; if the broker responds with 401
+// Unauthorized, the client fetches a new token and retries the request once.
+//
+// Token requests are sent with an HTTP client of their own: the Transport,
+// Middleware and TLS configuration of the ClientConfiguration apply only to
+// requests to the broker.
+type OAuth2ClientCredentialsConfig struct {
+	// TokenURL is the URL of the authorization server's token endpoint.
+	TokenURL string
+	// ClientID is the OAuth2 client ID.
+	ClientID string
+	// ClientSecret is the OAuth2 client secret.
+	ClientSecret string
+	// Scopes are the optional scopes to request for the access token.
+	Scopes []string
+	// TLSConfig is the TLS configuration to use when communicating with the
+	// token endpoint.  If unset, the system root certificates are trusted.
+	TLSConfig *tls.Config
+	// Transport, if set, is the http.RoundTripper used to send token
+	// requests instead of one built from TLSConfig.  It cannot be combined
+	// with TLSConfig.
+	Transport http.RoundTripper
+}
+
 // ClientConfiguration represents the configuration of a Client.
 type ClientConfiguration struct {
 	// Name is the name to use for this client in log messages.  Using the
@@ -57,12 +94,24 @@ type ClientConfiguration struct {
 	// to the broker.
 	AuthConfig *AuthConfig
 	// TLSConfig is the TLS configuration to use when communicating with the
-	// broker.
+	// broker.  The client uses a copy of it, so it can be shared by the
+	// clients of several brokers.
 	TLSConfig *tls.Config
 	// Insecure represents whether the 'InsecureSkipVerify' TLS configuration
 	// field should be set.  If the TLSConfig field is set and this field is
 	// set to true, it overrides the value in the TLSConfig field.
 	Insecure bool
+	// Transport, if set, is the http.RoundTripper used to send requests to
+	// the broker instead of a transport built from the TLS configuration
+	// fields, which must then be left unset.  It can be used to record or
+	// replay traffic, or to customize how connections are made.
+	Transport http.RoundTripper
+	// Middleware wraps the transport used to send requests to the broker,
+	// whether it is Transport or the one built from the TLS configuration
+	// fields.  Every request made by the client, including each retry,
+	// passes through the middleware in order: the first middleware sees the
+	// request first.
+	Middleware []TransportMiddleware
 	// TimeoutSeconds is the length of the timeout of any request to the
 	// broker, in seconds.
 	TimeoutSeconds int
@@ -78,10 +127,129 @@ type ClientConfiguration struct {
 	// alpha features.
 	EnableAlphaFeatures bool
 	// CAData holds PEM-encoded bytes (typically read from a root certificates bundle).
-	// This CA certificate will be added to any specified in TLSConfig.RootCAs.
+	// It cannot be combined with TLSConfig.RootCAs; add the certificates to
+	// the pool in TLSConfig.RootCAs instead.
 	CAData []byte
-	// Verbose is whether the client will log to klog.
+	// CertFile and KeyFile are the paths of PEM files holding a client
+	// certificate and its private key, for brokers that require mutual TLS.
+	// The files are loaded again when they change, so a rotated certificate
+	// is used for new connections without recreating the client.
+	CertFile string
+	KeyFile  string
+	// CAFile is the path of a PEM file holding root certificates used to
+	// verify the broker, in addition to any in CAData.  The file is loaded
+	// again when it changes.  It cannot be combined with TLSConfig.RootCAs.
+	CAFile string
+	// Verbose is whether the client logs every request and response
+	// regardless of the verbosity of Logger, along with request headers and
+	// request and response bodies, with credentials redacted.  When unset,
+	// requests are logged at LogLevelRequests, responses at
+	// LogLevelResponses, and headers and bodies at LogLevelBodies.
 	Verbose bool
+	// Logger is the logger the client logs to.  Defaults to klog.
+	Logger logr.Logger
+	// LogRedactPaths are dot-separated paths of JSON values that are
+	// redacted from logged request and response bodies, such as
+	// "parameters.api_key" or "services.*.metadata.internal_url", where "*"
+	// matches any key or array element.  Values under the keys
+	// "credentials", "secret", "password", "access_token", "refresh_token"
+	// and "client_secret" are always redacted.
+	LogRedactPaths []string
+	// RetryPolicy controls whether and how the client retries requests that
+	// fail with transient errors.  If unset, each request is attempted
+	// exactly once.
+	RetryPolicy *RetryPolicy
+	// EnableOrphanMitigation controls whether the client performs orphan
+	// mitigation for ProvisionInstance and Bind requests.  When enabled and
+	// a request times out, receives a 201 Created response with a malformed
+	// body, any other 2xx response besides 200 OK, a 408 Request Timeout, or
+	// a 5xx response, the client immediately sends the corresponding
+	// DeprovisionInstance or Unbind request, as the Open Service Broker API
+	// specification requires platforms to do, and returns an
+	// OrphanMitigationError.
+	EnableOrphanMitigation bool
+	// NegotiateAPIVersion controls whether NewClient asks the broker which
+	// API version to use instead of using APIVersion as-is.  When set,
+	// NewClient requests the broker's catalog with each API version supported
+	// by this library in descending order, starting no higher than
+	// APIVersion if it is set, and uses the first version the broker does
+	// not reject with a 412 Precondition Failed response.  The client uses
+	// the negotiated version for its lifetime; create a new client to
+	// negotiate again, for example after the broker is upgraded.  See
+	// NegotiateAPIVersion.
+	NegotiateAPIVersion bool
+	// MetricsRecorder, if set, is given the outcome and latency of every
+	// request the client sends to the broker, labeled with Name and the
+	// operation that sent it.
+	MetricsRecorder MetricsRecorder
+	// Tracer, if set, starts a span for each Client method call, and the
+	// span is propagated to the broker in W3C Trace Context headers.  If
+	// unset, no spans are recorded.
+	Tracer Tracer
+	// RequestValidator, if set, validates ProvisionInstance, UpdateInstance
+	// and Bind requests before they are sent; see RequestValidator.
+	RequestValidator RequestValidator
+}
+
+// RetryPolicy configures retries of requests that fail with transient
+// errors.  Only requests that are safe to repeat are retried.  GET requests
+// (GetCatalog, GetInstance, GetBinding, PollLastOperation and
+// PollBindingLastOperation) are retried after connection errors and after
+// 429, 502, 503 and 504 responses.  PUT, PATCH and DELETE requests are
+// retried only after connection errors that occurred before the request was
+// written to the broker.
+//
+// The delay between attempts grows exponentially from InitialBackoff up to
+// MaxBackoff.  When a broker sends a Retry-After header with a 429 or 503
+// response, the delay it requests is used instead; if it exceeds MaxBackoff,
+// the response is returned without further attempts.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts made for a request,
+	// including the first.  Values less than 2 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff is the maximum delay between two attempts.  Zero means no
+	// maximum.
+	MaxBackoff time.Duration
+	// Multiplier is the factor by which the delay grows after each attempt.
+	// Values less than 1 are treated as 1.
+	Multiplier float64
+	// Jitter is the fraction, between 0 and 1, of each delay that is
+	// randomized in order to spread out retries from many clients.
+	Jitter float64
+	// OnRetry, if set, is called before each retry.  It can be used to count
+	// retries.
+	OnRetry func(RetryAttempt)
+}
+
+// RetryAttempt describes a failed attempt that is about to be retried.
+type RetryAttempt struct {
+	// Method is the HTTP method of the request.
+	Method string
+	// URL is the URL of the request.
+	URL string
+	// Attempt is the number of the failed attempt, starting at 1.
+	Attempt int
+	// StatusCode is the status code returned by the broker, or 0 if the
+	// attempt failed with a connection error.
+	StatusCode int
+	// Err is the connection error the attempt failed with, if any.
+	Err error
+	// Delay is how long the client waits before the next attempt.
+	Delay time.Duration
+}
+
+// DefaultRetryPolicy returns a RetryPolicy making up to 3 attempts, with
+// delays starting at 1 second and capped at 30 seconds.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 1 * time.Second,
+		MaxBackoff:     30 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
 }
 
 // DefaultClientConfiguration returns a default ClientConfiguration:
@@ -204,6 +372,51 @@ type Client interface {
 	GetBinding(r *GetBindingRequest) (*GetBindingResponse, error)
 }
 
+// ClientWithContext extends the Client interface with variants of each method
+// that accept a context.Context.  The context is attached to the HTTP request
+// sent to the broker, so canceling it or letting its deadline expire aborts
+// the in-flight request.  Requests aborted this way return a ContextError;
+// use IsCanceledError and IsDeadlineExceededError to test for these
+// conditions.
+//
+// The Client returned by NewClient and the fake.FakeClient both implement
+// ClientWithContext; callers holding a Client can use a type assertion to
+// access these methods.  The methods without a context behave as though
+// they were called with context.Background().
+type ClientWithContext interface {
+	Client
+
+	// GetCatalogWithContext is GetCatalog with a context.
+	GetCatalogWithContext(ctx context.Context) (*CatalogResponse, error)
+	// ProvisionInstanceWithContext is ProvisionInstance with a context.
+	ProvisionInstanceWithContext(ctx context.Context, r *ProvisionRequest) (*ProvisionResponse, error)
+	// UpdateInstanceWithContext is UpdateInstance with a context.
+	UpdateInstanceWithContext(ctx context.Context, r *UpdateInstanceRequest) (*UpdateInstanceResponse, error)
+	// DeprovisionInstanceWithContext is DeprovisionInstance with a context.
+	DeprovisionInstanceWithContext(ctx context.Context, r *DeprovisionRequest) (*DeprovisionResponse, error)
+	// GetInstanceWithContext is GetInstance with a context.
+	GetInstanceWithContext(ctx context.Context, r *GetInstanceRequest) (*GetInstanceResponse, error)
+	// PollLastOperationWithContext is PollLastOperation with a context.
+	PollLastOperationWithContext(ctx context.Context, r *LastOperationRequest) (*LastOperationResponse, error)
+	// PollBindingLastOperationWithContext is PollBindingLastOperation with a
+	// context.
+	PollBindingLastOperationWithContext(ctx context.Context, r *BindingLastOperationRequest) (*LastOperationResponse, error)
+	// BindWithContext is Bind with a context.
+	BindWithContext(ctx context.Context, r *BindRequest) (*BindResponse, error)
+	// UnbindWithContext is Unbind with a context.
+	UnbindWithContext(ctx context.Context, r *UnbindRequest) (*UnbindResponse, error)
+	// GetBindingWithContext is GetBinding with a context.
+	GetBindingWithContext(ctx context.Context, r *GetBindingRequest) (*GetBindingResponse, error)
+}
+
+// APIVersionGetter is implemented by clients that can report the API version
+// they use, which is useful when the version was negotiated with the broker.
+// The Client returned by NewClient implements APIVersionGetter.
+type APIVersionGetter interface {
+	// GetAPIVersion returns the API version used by the client.
+	GetAPIVersion() APIVersion
+}
+
 // CreateFunc allows control over which implementation of a Client is
 // returned.  Users of the Client interface may need to create clients for
 // multiple brokers in a way that makes normal dependency injection
@@ -0,0 +1,273 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package jsonschema validates values against JSON Schemas.  It is used by
+// the schema package to validate parameters and by the client to check the
+// schemas in catalogs.
+//
+// It implements the validation keywords of JSON Schema drafts 04, 06, 07 and
+// 2019-09, including if/then/else, dependencies and their 2019-09 forms
+// dependentRequired and dependentSchemas, and a set of common formats.  A
+// schema without $schema is validated as draft-04, the draft the Open Service
+// Broker API specifies; brokers publish schemas of the later drafts too.
+// References are resolved within the schema they appear in; references to
+// other documents, unevaluatedItems and unevaluatedProperties are not
+// supported.
+//
+// The validator is written here rather than imported so that the client module
+// keeps depending only on klog and logr.  It validates only: it does not
+// collect annotations or produce the output formats of the specification.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type draft int
+
+const (
+	draft04   draft = 4
+	draft06   draft = 6
+	draft07   draft = 7
+	draft2019 draft = 2019
+)
+
+// maxDepth bounds the nesting of schemas during validation, so that a
+// schema that refers to itself without consuming the value terminates.
+const maxDepth = 256
+
+// Schema is a compiled JSON Schema.
+type Schema struct {
+	root     interface{}
+	draft    draft
+	patterns map[string]*regexp.Regexp
+}
+
+// Compile compiles a JSON Schema, such as the Parameters of a
+// v2.InputParametersSchema.  It returns an error if the schema is not a JSON
+// object or boolean, if one of its patterns is not a valid regular
+// expression, or if one of its references cannot be resolved.
+func Compile(schema interface{}) (*Schema, error) {
+	root, err := normalize(schema)
+	if err != nil {
+		return nil, fmt.Errorf("schema is not JSON: %v", err)
+	}
+
+	s := &Schema{
+		root:     root,
+		draft:    detectDraft(root),
+		patterns: map[string]*regexp.Regexp{},
+	}
+	if err := s.compile(root, "#"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Validate validates a value against the schema and returns the values that
+// do not conform to it, or nil if the value is valid.
+func (s *Schema) Validate(value interface{}) []FieldError {
+	instance, err := normalize(value)
+	if err != nil {
+		return []FieldError{{Keyword: "type", Message: fmt.Sprintf("value is not JSON: %v", err)}}
+	}
+	return s.validate(s.root, instance, nil, 0)
+}
+
+// FieldError is a value that does not conform to a schema.
+type FieldError struct {
+	// Field is the dot-separated path of the value, with the elements of
+	// arrays identified by their index, such as "disks.0.size".  It is empty
+	// for the validated value itself.
+	Field string
+	// Keyword is the schema keyword that the value does not conform to, such
+	// as "maximum" or "required".
+	Keyword string
+	// Message describes how the value does not conform.
+	Message string
+}
+
+func (e FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%v: %v", e.Field, e.Message)
+}
+
+// normalize converts a Go value to the generic form that encoding/json
+// decodes JSON into.
+func normalize(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// detectDraft returns the draft a schema declares in $schema.  A schema that
+// does not declare one is a draft-04 schema, the draft the Open Service Broker
+// API requires of the schemas in a catalog; one that declares a later draft
+// than 2019-09 is validated as 2019-09.
+func detectDraft(root interface{}) draft {
+	object, _ := root.(map[string]interface{})
+	uri, _ := object["$schema"].(string)
+	switch {
+	case uri == "", strings.Contains(uri, "draft-04"), strings.Contains(uri, "draft-03"):
+		return draft04
+	case strings.Contains(uri, "draft-06"):
+		return draft06
+	case strings.Contains(uri, "draft-07"):
+		return draft07
+	default:
+		return draft2019
+	}
+}
+
+// compile checks the schema at the given location and compiles its
+// patterns.
+func (s *Schema) compile(schema interface{}, location string) error {
+	object, ok := schema.(map[string]interface{})
+	if !ok {
+		if _, ok := schema.(bool); ok {
+			return nil
+		}
+		return fmt.Errorf("schema at %v must be an object or a boolean", location)
+	}
+
+	if ref, ok := object["$ref"].(string); ok {
+		if _, err := s.resolve(ref); err != nil {
+			return fmt.Errorf("schema at %v: %v", location, err)
+		}
+	}
+	if pattern, ok := object["pattern"].(string); ok {
+		if err := s.compilePattern(pattern, location+"/pattern"); err != nil {
+			return err
+		}
+	}
+
+	for _, keyword := range []string{"additionalItems", "additionalProperties", "contains", "propertyNames", "not", "if", "then", "else"} {
+		if subschema, ok := object[keyword]; ok {
+			if err := s.compile(subschema, location+"/"+keyword); err != nil {
+				return err
+			}
+		}
+	}
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf", "items"} {
+		if subschemas, ok := object[keyword].([]interface{}); ok {
+			for i, subschema := range subschemas {
+				if err := s.compile(subschema, fmt.Sprintf("%v/%v/%d", location, keyword, i)); err != nil {
+					return err
+				}
+			}
+		} else if subschema, ok := object[keyword]; ok && keyword == "items" {
+			if err := s.compile(subschema, location+"/items"); err != nil {
+				return err
+			}
+		}
+	}
+	for _, keyword := range []string{"properties", "patternProperties", "definitions", "$defs", "dependentSchemas", "dependencies"} {
+		subschemas, _ := object[keyword].(map[string]interface{})
+		for _, name := range sortedKeys(subschemas) {
+			if keyword == "patternProperties" {
+				if err := s.compilePattern(name, location+"/patternProperties"); err != nil {
+					return err
+				}
+			}
+			if _, ok := subschemas[name].([]interface{}); ok && keyword == "dependencies" {
+				continue
+			}
+			if err := s.compile(subschemas[name], location+"/"+keyword+"/"+escapePointer(name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) compilePattern(pattern, location string) error {
+	if _, ok := s.patterns[pattern]; ok {
+		return nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern %q at %v: %v", pattern, location, err)
+	}
+	s.patterns[pattern] = re
+	return nil
+}
+
+// resolve returns the subschema that a reference within the schema refers
+// to.
+func (s *Schema) resolve(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported reference %q; only references within the schema are supported", ref)
+	}
+	pointer, err := url.PathUnescape(strings.TrimPrefix(ref, "#"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid reference %q: %v", ref, err)
+	}
+	if pointer == "" {
+		return s.root, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("unsupported reference %q; only JSON pointers are supported", ref)
+	}
+
+	current := s.root
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch value := current.(type) {
+		case map[string]interface{}:
+			child, ok := value[token]
+			if !ok {
+				return nil, fmt.Errorf("unresolvable reference %q", ref)
+			}
+			current = child
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(value) {
+				return nil, fmt.Errorf("unresolvable reference %q", ref)
+			}
+			current = value[index]
+		default:
+			return nil, fmt.Errorf("unresolvable reference %q", ref)
+		}
+	}
+	return current, nil
+}
+
+func escapePointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
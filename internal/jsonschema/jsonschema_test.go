@@ -0,0 +1,240 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func mustUnmarshal(t *testing.T, data string) interface{} {
+	var value interface{}
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		t.Fatalf("invalid JSON %s: %v", data, err)
+	}
+	return value
+}
+
+// errorLocations returns the field and keyword of each error.
+func errorLocations(errs []FieldError) []string {
+	var locations []string
+	for _, err := range errs {
+		locations = append(locations, err.Field+" "+err.Keyword)
+	}
+	return locations
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name     string
+		schema   string
+		value    string
+		expected []string
+	}{
+		{
+			name:   "valid object",
+			schema: `{"type":"object","properties":{"size":{"type":"integer","minimum":1}},"required":["size"]}`,
+			value:  `{"size":3}`,
+		},
+		{
+			name:     "type",
+			schema:   `{"type":"object","properties":{"size":{"type":"integer"},"name":{"type":["string","null"]}}}`,
+			value:    `{"size":1.5,"name":true}`,
+			expected: []string{"name type", "size type"},
+		},
+		{
+			name:     "required and additional properties",
+			schema:   `{"properties":{"a":{}},"required":["a","b"],"additionalProperties":false}`,
+			value:    `{"c":1}`,
+			expected: []string{"a required", "b required", "c additionalProperties"},
+		},
+		{
+			name:     "additional properties schema and pattern properties",
+			schema:   `{"patternProperties":{"^x-":{"type":"string"}},"additionalProperties":{"type":"integer"}}`,
+			value:    `{"x-a":1,"b":"two","c":3}`,
+			expected: []string{"b type", "x-a type"},
+		},
+		{
+			name:     "enum and const",
+			schema:   `{"properties":{"tier":{"enum":["small","large"]},"version":{"const":2}}}`,
+			value:    `{"tier":"medium","version":3}`,
+			expected: []string{"tier enum", "version const"},
+		},
+		{
+			name:     "numbers",
+			schema:   `{"items":[{"maximum":10},{"exclusiveMinimum":0},{"multipleOf":0.1},{"multipleOf":0.1}]}`,
+			value:    `[11,0,0.3,0.35]`,
+			expected: []string{"0 maximum", "1 exclusiveMinimum", "3 multipleOf"},
+		},
+		{
+			name:     "draft-04 exclusive maximum",
+			schema:   `{"$schema":"http://json-schema.org/draft-04/schema#","maximum":10,"exclusiveMaximum":true}`,
+			value:    `10`,
+			expected: []string{" exclusiveMaximum"},
+		},
+		{
+			name:     "strings",
+			schema:   `{"items":[{"minLength":2},{"maxLength":2},{"pattern":"^[a-z]+$"},{"format":"ipv4"},{"format":"unknown"}]}`,
+			value:    `["é","abc","a1","1.2.3","anything"]`,
+			expected: []string{"0 minLength", "1 maxLength", "2 pattern", "3 format"},
+		},
+		{
+			name:     "arrays",
+			schema:   `{"properties":{"tags":{"type":"array","items":{"type":"string"},"minItems":4,"uniqueItems":true}}}`,
+			value:    `{"tags":["a",1,"a"]}`,
+			expected: []string{"tags minItems", "tags uniqueItems", "tags.1 type"},
+		},
+		{
+			name:     "tuples and additional items",
+			schema:   `{"items":[{"type":"string"}],"additionalItems":false}`,
+			value:    `["a","b"]`,
+			expected: []string{"1 false"},
+		},
+		{
+			name:     "contains",
+			schema:   `{"contains":{"const":"admin"}}`,
+			value:    `["user"]`,
+			expected: []string{" contains"},
+		},
+		{
+			name:     "dependencies",
+			schema:   `{"dependencies":{"tls":["certificate"]},"dependentSchemas":{"replicas":{"required":["zone"]}}}`,
+			value:    `{"tls":true,"replicas":3}`,
+			expected: []string{"certificate dependencies", "zone required"},
+		},
+		{
+			name:     "combinators",
+			schema:   `{"properties":{"a":{"anyOf":[{"type":"string"},{"type":"integer"}]},"b":{"oneOf":[{"minimum":1},{"minimum":2}]},"c":{"not":{"type":"null"}},"d":{"allOf":[{"minimum":1},{"maximum":2}]}}}`,
+			value:    `{"a":true,"b":3,"c":null,"d":3}`,
+			expected: []string{"a anyOf", "b oneOf", "c not", "d maximum"},
+		},
+		{
+			name:     "if then else",
+			schema:   `{"if":{"properties":{"tier":{"const":"large"}}},"then":{"required":["replicas"]},"else":{"properties":{"replicas":{"maximum":1}}}}`,
+			value:    `{"tier":"small","replicas":3}`,
+			expected: []string{"replicas maximum"},
+		},
+		{
+			name:     "references",
+			schema:   `{"definitions":{"size":{"type":"integer"}},"$defs":{"name":{"type":"string"}},"properties":{"size":{"$ref":"#/definitions/size"},"name":{"$ref":"#/$defs/name"}}}`,
+			value:    `{"size":"large","name":1}`,
+			expected: []string{"name type", "size type"},
+		},
+		{
+			name:     "draft-04 by default ignores keywords next to references",
+			schema:   `{"definitions":{"size":{"type":"integer"}},"properties":{"size":{"$ref":"#/definitions/size","maximum":10},"name":{"type":"string","maxLength":3}}}`,
+			value:    `{"size":11,"name":"long"}`,
+			expected: []string{"name maxLength"},
+		},
+		{
+			name:     "draft-04 by default has boolean exclusive maximum",
+			schema:   `{"maximum":10,"exclusiveMaximum":true}`,
+			value:    `10`,
+			expected: []string{" exclusiveMaximum"},
+		},
+		{
+			name:   "draft-07 ignores keywords next to references",
+			schema: `{"$schema":"http://json-schema.org/draft-07/schema#","definitions":{"any":{}},"properties":{"a":{"$ref":"#/definitions/any","type":"string"}}}`,
+			value:  `{"a":1}`,
+		},
+		{
+			name:     "2019-09 applies keywords next to references",
+			schema:   `{"$schema":"https://json-schema.org/draft/2019-09/schema","$defs":{"any":{}},"properties":{"a":{"$ref":"#/$defs/any","type":"string"}}}`,
+			value:    `{"a":1}`,
+			expected: []string{"a type"},
+		},
+		{
+			name:     "recursive reference",
+			schema:   `{"properties":{"name":{"type":"string"},"children":{"type":"array","items":{"$ref":"#"}}}}`,
+			value:    `{"children":[{"name":"a"},{"children":[{"name":2}]}]}`,
+			expected: []string{"children.1.children.0.name type"},
+		},
+		{
+			name:     "boolean schemas",
+			schema:   `{"properties":{"a":true,"b":false}}`,
+			value:    `{"a":1,"b":2}`,
+			expected: []string{"b false"},
+		},
+	}
+
+	for _, tc := range cases {
+		schema, err := Compile(mustUnmarshal(t, tc.schema))
+		if err != nil {
+			t.Errorf("%v: unexpected error compiling schema: %v", tc.name, err)
+			continue
+		}
+
+		errs := schema.Validate(mustUnmarshal(t, tc.value))
+		if e, a := tc.expected, errorLocations(errs); !reflect.DeepEqual(e, a) {
+			t.Errorf("%v: unexpected errors; expected %q, got %q (%v)", tc.name, e, a, errs)
+		}
+	}
+}
+
+func TestValidateGoValues(t *testing.T) {
+	schema, err := Compile(map[string]interface{}{
+		"properties": map[string]interface{}{
+			"size":  map[string]interface{}{"type": "integer", "maximum": 10},
+			"zones": map[string]interface{}{"items": map[string]string{"type": "string"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	errs := schema.Validate(map[string]interface{}{
+		"size":  int64(11),
+		"zones": []int{1},
+	})
+	expected := []FieldError{
+		{Field: "size", Keyword: "maximum", Message: "must be less than or equal to 10"},
+		{Field: "zones.0", Keyword: "type", Message: "must be string, not number"},
+	}
+	if e, a := expected, errs; !reflect.DeepEqual(e, a) {
+		t.Errorf("unexpected errors;\nexpected %+v\ngot      %+v", e, a)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		schema string
+	}{
+		{name: "not a schema", schema: `"string"`},
+		{name: "invalid pattern", schema: `{"properties":{"a":{"pattern":"("}}}`},
+		{name: "invalid pattern property", schema: `{"patternProperties":{"(":{}}}`},
+		{name: "unresolvable reference", schema: `{"properties":{"a":{"$ref":"#/definitions/missing"}}}`},
+		{name: "remote reference", schema: `{"$ref":"https://example.com/schema.json"}`},
+		{name: "invalid subschema", schema: `{"items":[1]}`},
+	}
+
+	for _, tc := range cases {
+		if _, err := Compile(mustUnmarshal(t, tc.schema)); err == nil {
+			t.Errorf("%v: expected an error", tc.name)
+		}
+	}
+}
+
+func TestFieldErrorString(t *testing.T) {
+	if e, a := "disks.0.size: is required", (FieldError{Field: "disks.0.size", Message: "is required"}).Error(); e != a {
+		t.Errorf("expected %q, got %q", e, a)
+	}
+	if e, a := "must be object, not string", (FieldError{Message: "must be object, not string"}).Error(); e != a {
+		t.Errorf("expected %q, got %q", e, a)
+	}
+}
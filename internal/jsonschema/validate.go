@@ -0,0 +1,464 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// validate validates an instance at the given path against a schema.
+func (s *Schema) validate(schema, instance interface{}, path []string, depth int) []FieldError {
+	if depth > maxDepth {
+		return []FieldError{fieldError(path, "$ref", "schema nesting is too deep")}
+	}
+
+	object, ok := schema.(map[string]interface{})
+	if !ok {
+		if allowed, ok := schema.(bool); ok && !allowed {
+			return []FieldError{fieldError(path, "false", "no value is allowed")}
+		}
+		return nil
+	}
+
+	var errs []FieldError
+	if ref, ok := object["$ref"].(string); ok {
+		// Compile has checked that the reference resolves.
+		target, _ := s.resolve(ref)
+		errs = append(errs, s.validate(target, instance, path, depth+1)...)
+		// Before 2019-09, the keywords next to a reference are ignored.
+		if s.draft < draft2019 {
+			return errs
+		}
+	}
+
+	errs = append(errs, s.validateGeneric(object, instance, path)...)
+	switch value := instance.(type) {
+	case float64:
+		errs = append(errs, validateNumber(object, value, path)...)
+	case string:
+		errs = append(errs, s.validateString(object, value, path)...)
+	case []interface{}:
+		errs = append(errs, s.validateArray(object, value, path, depth)...)
+	case map[string]interface{}:
+		errs = append(errs, s.validateObject(object, value, path, depth)...)
+	}
+	errs = append(errs, s.validateCombinators(object, instance, path, depth)...)
+	return errs
+}
+
+func (s *Schema) valid(schema, instance interface{}, path []string, depth int) bool {
+	return len(s.validate(schema, instance, path, depth+1)) == 0
+}
+
+// validateGeneric validates the keywords that apply to every type.
+func (s *Schema) validateGeneric(schema map[string]interface{}, instance interface{}, path []string) []FieldError {
+	var errs []FieldError
+
+	if schemaType, ok := schema["type"]; ok {
+		var types []string
+		switch t := schemaType.(type) {
+		case string:
+			types = []string{t}
+		case []interface{}:
+			for _, element := range t {
+				if name, ok := element.(string); ok {
+					types = append(types, name)
+				}
+			}
+		}
+
+		matches := false
+		for _, t := range types {
+			if hasType(instance, t) {
+				matches = true
+			}
+		}
+		if !matches && len(types) > 0 {
+			errs = append(errs, fieldError(path, "type", fmt.Sprintf("must be %v, not %v", strings.Join(types, " or "), typeOf(instance))))
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		matches := false
+		for _, value := range enum {
+			if reflect.DeepEqual(value, instance) {
+				matches = true
+			}
+		}
+		if !matches {
+			errs = append(errs, fieldError(path, "enum", fmt.Sprintf("must be one of %v", formatValue(enum))))
+		}
+	}
+
+	if value, ok := schema["const"]; ok && !reflect.DeepEqual(value, instance) {
+		errs = append(errs, fieldError(path, "const", fmt.Sprintf("must be %v", formatValue(value))))
+	}
+
+	return errs
+}
+
+func validateNumber(schema map[string]interface{}, value float64, path []string) []FieldError {
+	var errs []FieldError
+
+	if multipleOf, ok := schema["multipleOf"].(float64); ok && multipleOf > 0 {
+		quotient := value / multipleOf
+		if math.Abs(quotient-math.Round(quotient)) > 1e-9*math.Max(1, math.Abs(quotient)) {
+			errs = append(errs, fieldError(path, "multipleOf", fmt.Sprintf("must be a multiple of %v", multipleOf)))
+		}
+	}
+
+	// In draft-04, exclusiveMaximum and exclusiveMinimum are booleans that
+	// modify maximum and minimum; since draft-06, they are numbers.
+	if maximum, ok := schema["maximum"].(float64); ok {
+		if exclusive, _ := schema["exclusiveMaximum"].(bool); exclusive && value >= maximum {
+			errs = append(errs, fieldError(path, "exclusiveMaximum", fmt.Sprintf("must be less than %v", maximum)))
+		} else if value > maximum {
+			errs = append(errs, fieldError(path, "maximum", fmt.Sprintf("must be less than or equal to %v", maximum)))
+		}
+	}
+	if maximum, ok := schema["exclusiveMaximum"].(float64); ok && value >= maximum {
+		errs = append(errs, fieldError(path, "exclusiveMaximum", fmt.Sprintf("must be less than %v", maximum)))
+	}
+	if minimum, ok := schema["minimum"].(float64); ok {
+		if exclusive, _ := schema["exclusiveMinimum"].(bool); exclusive && value <= minimum {
+			errs = append(errs, fieldError(path, "exclusiveMinimum", fmt.Sprintf("must be greater than %v", minimum)))
+		} else if value < minimum {
+			errs = append(errs, fieldError(path, "minimum", fmt.Sprintf("must be greater than or equal to %v", minimum)))
+		}
+	}
+	if minimum, ok := schema["exclusiveMinimum"].(float64); ok && value <= minimum {
+		errs = append(errs, fieldError(path, "exclusiveMinimum", fmt.Sprintf("must be greater than %v", minimum)))
+	}
+
+	return errs
+}
+
+func (s *Schema) validateString(schema map[string]interface{}, value string, path []string) []FieldError {
+	var errs []FieldError
+
+	length := utf8.RuneCountInString(value)
+	if maxLength, ok := schema["maxLength"].(float64); ok && length > int(maxLength) {
+		errs = append(errs, fieldError(path, "maxLength", fmt.Sprintf("must be at most %v characters long", maxLength)))
+	}
+	if minLength, ok := schema["minLength"].(float64); ok && length < int(minLength) {
+		errs = append(errs, fieldError(path, "minLength", fmt.Sprintf("must be at least %v characters long", minLength)))
+	}
+	if pattern, ok := schema["pattern"].(string); ok && !s.match(pattern, value) {
+		errs = append(errs, fieldError(path, "pattern", fmt.Sprintf("must match the pattern %q", pattern)))
+	}
+	if format, ok := schema["format"].(string); ok {
+		if check, ok := formats[format]; ok && !check(value) {
+			errs = append(errs, fieldError(path, "format", fmt.Sprintf("must be a valid %v", format)))
+		}
+	}
+
+	return errs
+}
+
+func (s *Schema) validateArray(schema map[string]interface{}, value []interface{}, path []string, depth int) []FieldError {
+	var errs []FieldError
+
+	if maxItems, ok := schema["maxItems"].(float64); ok && len(value) > int(maxItems) {
+		errs = append(errs, fieldError(path, "maxItems", fmt.Sprintf("must have at most %v items", maxItems)))
+	}
+	if minItems, ok := schema["minItems"].(float64); ok && len(value) < int(minItems) {
+		errs = append(errs, fieldError(path, "minItems", fmt.Sprintf("must have at least %v items", minItems)))
+	}
+	if unique, _ := schema["uniqueItems"].(bool); unique {
+	duplicates:
+		for i := range value {
+			for j := 0; j < i; j++ {
+				if reflect.DeepEqual(value[i], value[j]) {
+					errs = append(errs, fieldError(path, "uniqueItems", fmt.Sprintf("must not contain duplicate items; items %d and %d are equal", j, i)))
+					break duplicates
+				}
+			}
+		}
+	}
+
+	switch items := schema["items"].(type) {
+	case []interface{}:
+		for i, element := range value {
+			elementPath := appendPath(path, strconv.Itoa(i))
+			if i < len(items) {
+				errs = append(errs, s.validate(items[i], element, elementPath, depth+1)...)
+			} else if additionalItems, ok := schema["additionalItems"]; ok {
+				errs = append(errs, s.validate(additionalItems, element, elementPath, depth+1)...)
+			}
+		}
+	case map[string]interface{}, bool:
+		for i, element := range value {
+			errs = append(errs, s.validate(items, element, appendPath(path, strconv.Itoa(i)), depth+1)...)
+		}
+	}
+
+	if contains, ok := schema["contains"]; ok {
+		count := 0
+		for i, element := range value {
+			if s.valid(contains, element, appendPath(path, strconv.Itoa(i)), depth) {
+				count++
+			}
+		}
+		minContains := 1
+		if s.draft >= draft2019 {
+			if value, ok := schema["minContains"].(float64); ok {
+				minContains = int(value)
+			}
+			if maxContains, ok := schema["maxContains"].(float64); ok && count > int(maxContains) {
+				errs = append(errs, fieldError(path, "maxContains", fmt.Sprintf("must contain at most %v items matching the contains schema", maxContains)))
+			}
+		}
+		if count < minContains {
+			errs = append(errs, fieldError(path, "contains", fmt.Sprintf("must contain at least %d items matching the contains schema", minContains)))
+		}
+	}
+
+	return errs
+}
+
+func (s *Schema) validateObject(schema map[string]interface{}, value map[string]interface{}, path []string, depth int) []FieldError {
+	var errs []FieldError
+
+	if maxProperties, ok := schema["maxProperties"].(float64); ok && len(value) > int(maxProperties) {
+		errs = append(errs, fieldError(path, "maxProperties", fmt.Sprintf("must have at most %v properties", maxProperties)))
+	}
+	if minProperties, ok := schema["minProperties"].(float64); ok && len(value) < int(minProperties) {
+		errs = append(errs, fieldError(path, "minProperties", fmt.Sprintf("must have at least %v properties", minProperties)))
+	}
+
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, element := range required {
+			if name, ok := element.(string); ok {
+				if _, ok := value[name]; !ok {
+					errs = append(errs, fieldError(appendPath(path, name), "required", "is required"))
+				}
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	patternProperties, _ := schema["patternProperties"].(map[string]interface{})
+	additionalProperties, hasAdditionalProperties := schema["additionalProperties"]
+	propertyNames, hasPropertyNames := schema["propertyNames"]
+	for _, name := range sortedKeys(value) {
+		propertyPath := appendPath(path, name)
+
+		if hasPropertyNames {
+			errs = append(errs, s.validate(propertyNames, name, propertyPath, depth+1)...)
+		}
+
+		additional := true
+		if property, ok := properties[name]; ok {
+			additional = false
+			errs = append(errs, s.validate(property, value[name], propertyPath, depth+1)...)
+		}
+		for _, pattern := range sortedKeys(patternProperties) {
+			if s.match(pattern, name) {
+				additional = false
+				errs = append(errs, s.validate(patternProperties[pattern], value[name], propertyPath, depth+1)...)
+			}
+		}
+		if additional && hasAdditionalProperties {
+			if allowed, ok := additionalProperties.(bool); ok && !allowed {
+				errs = append(errs, fieldError(propertyPath, "additionalProperties", "is not allowed"))
+			} else {
+				errs = append(errs, s.validate(additionalProperties, value[name], propertyPath, depth+1)...)
+			}
+		}
+	}
+
+	// dependencies is split into dependentRequired and dependentSchemas in
+	// 2019-09; all three are supported regardless of the draft.
+	for _, keyword := range []string{"dependencies", "dependentRequired", "dependentSchemas"} {
+		dependencies, _ := schema[keyword].(map[string]interface{})
+		for _, name := range sortedKeys(dependencies) {
+			if _, ok := value[name]; !ok {
+				continue
+			}
+			if required, ok := dependencies[name].([]interface{}); ok {
+				for _, element := range required {
+					if dependency, ok := element.(string); ok {
+						if _, ok := value[dependency]; !ok {
+							errs = append(errs, fieldError(appendPath(path, dependency), keyword, fmt.Sprintf("is required when %q is set", name)))
+						}
+					}
+				}
+			} else {
+				errs = append(errs, s.validate(dependencies[name], value, path, depth+1)...)
+			}
+		}
+	}
+
+	return errs
+}
+
+func (s *Schema) validateCombinators(schema map[string]interface{}, instance interface{}, path []string, depth int) []FieldError {
+	var errs []FieldError
+
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		for _, subschema := range allOf {
+			errs = append(errs, s.validate(subschema, instance, path, depth+1)...)
+		}
+	}
+
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		matches := false
+		for _, subschema := range anyOf {
+			if s.valid(subschema, instance, path, depth) {
+				matches = true
+				break
+			}
+		}
+		if !matches {
+			errs = append(errs, fieldError(path, "anyOf", "must match at least one of the anyOf schemas"))
+		}
+	}
+
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		count := 0
+		for _, subschema := range oneOf {
+			if s.valid(subschema, instance, path, depth) {
+				count++
+			}
+		}
+		if count != 1 {
+			errs = append(errs, fieldError(path, "oneOf", fmt.Sprintf("must match exactly one of the oneOf schemas, but matches %d", count)))
+		}
+	}
+
+	if not, ok := schema["not"]; ok && s.valid(not, instance, path, depth) {
+		errs = append(errs, fieldError(path, "not", "must not match the not schema"))
+	}
+
+	if condition, ok := schema["if"]; ok {
+		if s.valid(condition, instance, path, depth) {
+			if then, ok := schema["then"]; ok {
+				errs = append(errs, s.validate(then, instance, path, depth+1)...)
+			}
+		} else if otherwise, ok := schema["else"]; ok {
+			errs = append(errs, s.validate(otherwise, instance, path, depth+1)...)
+		}
+	}
+
+	return errs
+}
+
+// match returns whether a value matches a pattern of the schema.  Patterns
+// in subschemas that are only reachable by reference are compiled here.
+func (s *Schema) match(pattern, value string) bool {
+	re, ok := s.patterns[pattern]
+	if !ok {
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			return false
+		}
+	}
+	return re.MatchString(value)
+}
+
+func typeOf(instance interface{}) string {
+	switch instance.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func hasType(instance interface{}, t string) bool {
+	if t == "integer" {
+		number, ok := instance.(float64)
+		return ok && number == math.Trunc(number)
+	}
+	return typeOf(instance) == t
+}
+
+func formatValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
+
+func fieldError(path []string, keyword, message string) FieldError {
+	return FieldError{
+		Field:   strings.Join(path, "."),
+		Keyword: keyword,
+		Message: message,
+	}
+}
+
+func appendPath(path []string, segment string) []string {
+	return append(path[:len(path):len(path)], segment)
+}
+
+var (
+	hostnamePattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+	uuidPattern     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// formats are the checks of the formats that are validated.  Other formats
+// are treated as annotations and not validated.
+var formats = map[string]func(string) bool{
+	"date-time": func(value string) bool {
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	},
+	"date": func(value string) bool {
+		_, err := time.Parse("2006-01-02", value)
+		return err == nil
+	},
+	"email": func(value string) bool {
+		address, err := mail.ParseAddress(value)
+		return err == nil && address.Address == value
+	},
+	"hostname": func(value string) bool {
+		return len(value) <= 253 && hostnamePattern.MatchString(value)
+	},
+	"ipv4": func(value string) bool {
+		ip := net.ParseIP(value)
+		return ip != nil && ip.To4() != nil && !strings.Contains(value, ":")
+	},
+	"ipv6": func(value string) bool {
+		return net.ParseIP(value) != nil && strings.Contains(value, ":")
+	},
+	"uri": func(value string) bool {
+		u, err := url.Parse(value)
+		return err == nil && u.IsAbs()
+	},
+	"uuid": uuidPattern.MatchString,
+}
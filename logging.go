@@ -0,0 +1,204 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"k8s.io/klog/v2"
+	"k8s.io/klog/v2/klogr"
+)
+
+const (
+	// LogLevelRequests is the verbosity at which the client logs each request
+	// to the broker.
+	LogLevelRequests = 2
+	// LogLevelResponses is the verbosity at which the client logs the status
+	// code of each response from the broker.
+	LogLevelResponses = 3
+	// LogLevelBodies is the verbosity at which the client logs request
+	// headers and request and response bodies, with credentials redacted.
+	LogLevelBodies = 5
+
+	// redactedLogValue replaces redacted values in logs.
+	redactedLogValue = "REDACTED"
+)
+
+// defaultLogger is the logger of clients without a configured Logger.
+var defaultLogger = klogr.New()
+
+// redactedLogKeys are the JSON object keys whose values are redacted wherever
+// they appear in a logged body, such as the credentials of a binding, the
+// secret of a dashboard client and OAuth2 tokens and client secrets.
+var redactedLogKeys = map[string]bool{
+	"credentials":   true,
+	"secret":        true,
+	"password":      true,
+	"access_token":  true,
+	"refresh_token": true,
+	"client_secret": true,
+}
+
+// redactedLogHeaders are the request headers whose values are redacted in
+// logs.  The originating identity header carries the identity of the platform
+// user, such as a user name or email address.
+var redactedLogHeaders = []string{"Authorization", "Proxy-Authorization", OriginatingIdentityHeader}
+
+// logger returns the client's logger, labeled with the client's name.  If no
+// logger is configured, the client logs to klog.
+func (c *client) logger() logr.Logger {
+	logger := c.Logger
+	if logger == nil {
+		logger = defaultLogger
+	}
+	return logger.WithValues("broker", c.Name)
+}
+
+// logAt returns the client's logger at the given verbosity.  When Verbose is
+// set, the message is logged regardless of verbosity, as it was before the
+// client supported verbosity levels.
+func (c *client) logAt(level int) logr.InfoLogger {
+	if c.Verbose {
+		return c.logger()
+	}
+	return c.logger().V(level)
+}
+
+// bodyLogger returns the logger for request headers and request and response
+// bodies, and whether they are logged at all, so that they are only redacted
+// when they are logged.
+func (c *client) bodyLogger() (logr.InfoLogger, bool) {
+	logger := c.logAt(LogLevelBodies)
+	return logger, logger.Enabled()
+}
+
+// logWarning logs a message that needs attention without being an error, such
+// as a failed request attempt that is retried.  logr has no warning level, so
+// when the client logs to klog the message is a klog warning, and a
+// configured Logger logs it with Info at verbosity 0, which is never
+// filtered.
+func (c *client) logWarning(msg string, keysAndValues ...interface{}) {
+	if c.Logger != nil {
+		c.logger().Info(msg, keysAndValues...)
+		return
+	}
+
+	var pairs strings.Builder
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		fmt.Fprintf(&pairs, " %v=%v", keysAndValues[i], keysAndValues[i+1])
+	}
+	klog.Warningf("broker %q: %s%s", c.Name, msg, pairs.String())
+}
+
+// parseLogRedactPaths parses the dot-separated JSON paths in
+// ClientConfiguration.LogRedactPaths.
+func parseLogRedactPaths(paths []string) ([][]string, error) {
+	parsed := make([][]string, 0, len(paths))
+	for _, path := range paths {
+		segments := strings.Split(path, ".")
+		for _, segment := range segments {
+			if segment == "" {
+				return nil, fmt.Errorf("invalid log redaction path %q", path)
+			}
+		}
+		parsed = append(parsed, segments)
+	}
+	return parsed, nil
+}
+
+// redactBodyForLog returns a JSON body as a string to be logged, with the
+// values of redactedLogKeys and of the client's LogRedactPaths redacted.  A
+// body that is not JSON is not logged, since it cannot be redacted.
+func (c *client) redactBodyForLog(body []byte) string {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return fmt.Sprintf("<%d bytes of non-JSON content>", len(body))
+	}
+
+	value = redactLogKeys(value)
+	for _, path := range c.logRedactPaths {
+		value = redactLogPath(value, path)
+	}
+
+	redacted, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("<%d bytes of unprintable content>", len(body))
+	}
+	return string(redacted)
+}
+
+// redactLogKeys redacts the values of redactedLogKeys anywhere in value.
+func redactLogKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if redactedLogKeys[key] {
+				v[key] = redactedLogValue
+			} else {
+				v[key] = redactLogKeys(child)
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redactLogKeys(child)
+		}
+	}
+	return value
+}
+
+// redactLogPath redacts the value at the given path in value.  A "*" segment
+// matches every key of an object or element of an array, and a number matches
+// the element of an array at that index.
+func redactLogPath(value interface{}, path []string) interface{} {
+	if len(path) == 0 {
+		return redactedLogValue
+	}
+
+	segment, rest := path[0], path[1:]
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if segment == "*" || segment == key {
+				v[key] = redactLogPath(child, rest)
+			}
+		}
+	case []interface{}:
+		index, err := strconv.Atoi(segment)
+		for i, child := range v {
+			if segment == "*" || (err == nil && index == i) {
+				v[i] = redactLogPath(child, rest)
+			}
+		}
+	}
+	return value
+}
+
+// redactHeaderForLog returns a copy of header with credentials redacted.
+func redactHeaderForLog(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, key := range redactedLogHeaders {
+		if redacted.Get(key) != "" {
+			redacted.Set(key, redactedLogValue)
+		}
+	}
+	return redacted
+}
@@ -0,0 +1,277 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// testLogEntry is a message logged to a testLogger.
+type testLogEntry struct {
+	level         int
+	msg           string
+	keysAndValues []interface{}
+}
+
+func (e testLogEntry) String() string {
+	return fmt.Sprintf("%d %s %v", e.level, e.msg, e.keysAndValues)
+}
+
+// testLogger is a logr.Logger that records the messages logged at or below
+// its verbosity.
+type testLogger struct {
+	verbosity int
+	level     int
+	values    []interface{}
+	entries   *[]testLogEntry
+}
+
+var _ logr.Logger = testLogger{}
+
+func newTestLogger(verbosity int) testLogger {
+	return testLogger{verbosity: verbosity, entries: &[]testLogEntry{}}
+}
+
+func (l testLogger) Info(msg string, keysAndValues ...interface{}) {
+	if l.Enabled() {
+		*l.entries = append(*l.entries, testLogEntry{level: l.level, msg: msg, keysAndValues: append(l.values, keysAndValues...)})
+	}
+}
+
+func (l testLogger) Enabled() bool {
+	return l.level <= l.verbosity
+}
+
+func (l testLogger) Error(err error, msg string, keysAndValues ...interface{}) {
+	l.level = 0
+	l.Info(msg, append(keysAndValues, "error", err)...)
+}
+
+func (l testLogger) V(level int) logr.InfoLogger {
+	l.level = level
+	return l
+}
+
+func (l testLogger) WithValues(keysAndValues ...interface{}) logr.Logger {
+	l.values = append(append([]interface{}{}, l.values...), keysAndValues...)
+	return l
+}
+
+func (l testLogger) WithName(name string) logr.Logger {
+	return l
+}
+
+func (l testLogger) output() string {
+	var lines []string
+	for _, entry := range *l.entries {
+		lines = append(lines, entry.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestLoggingRedactsCredentials(t *testing.T) {
+	cases := []struct {
+		name        string
+		verbosity   int
+		verbose     bool
+		expected    []string
+		notExpected []string
+	}{
+		{
+			name:        "not logged by default",
+			verbosity:   0,
+			notExpected: []string{"doing request", "response body"},
+		},
+		{
+			name:        "requests",
+			verbosity:   LogLevelRequests,
+			expected:    []string{"2 doing request [broker test client method PUT"},
+			notExpected: []string{"received response", "response body", "Authorization"},
+		},
+		{
+			name:        "responses",
+			verbosity:   LogLevelResponses,
+			expected:    []string{"2 doing request", "3 received response [broker test client method PUT"},
+			notExpected: []string{"response body", "Authorization", `"parameters"`},
+		},
+		{
+			name:      "bodies",
+			verbosity: LogLevelBodies,
+			expected: []string{
+				"2 doing request",
+				"3 received response",
+				"5 request",
+				`"parameters":{"api_key":"REDACTED","password":"REDACTED","size":"small"}`,
+				"Authorization:[REDACTED]",
+				"X-Broker-Api-Originating-Identity:[REDACTED]",
+				"5 response body",
+				`"credentials":"REDACTED"`,
+			},
+		},
+		{
+			name:      "verbose",
+			verbosity: 0,
+			verbose:   true,
+			expected: []string{
+				"0 doing request",
+				"0 request",
+				`"parameters":{"api_key":"REDACTED","password":"REDACTED","size":"small"}`,
+				"Authorization:[REDACTED]",
+				"X-Broker-Api-Originating-Identity:[REDACTED]",
+				"0 response body",
+				`"credentials":"REDACTED"`,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		logger := newTestLogger(tc.verbosity)
+		klient := newTestClient(t, tc.name, Version2_14(), false, httpChecks{}, httpReaction{})
+		klient.Verbose = tc.verbose
+		klient.Logger = logger
+		klient.AuthConfig = &AuthConfig{BearerConfig: &BearerConfig{Token: "bearer-token"}}
+		klient.logRedactPaths, _ = parseLogRedactPaths([]string{"parameters.api_key"})
+		klient.doRequestFunc = func(request *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusCreated,
+				Body:       closer(`{"credentials":{"username":"user","password":"credential-password"}}`),
+			}, nil
+		}
+
+		request := defaultBindRequest()
+		request.OriginatingIdentity = &OriginatingIdentity{Platform: "kubernetes", Value: `{"username":"alice"}`}
+		request.Parameters = map[string]interface{}{
+			"api_key":  "parameter-key",
+			"password": "parameter-password",
+			"size":     "small",
+		}
+		if _, err := klient.Bind(request); err != nil {
+			t.Fatalf("%v: unexpected error: %v", tc.name, err)
+		}
+
+		output := logger.output()
+		for _, secret := range []string{"bearer-token", "credential-password", "parameter-key", "parameter-password"} {
+			if strings.Contains(output, secret) {
+				t.Errorf("%v: expected %q to be redacted from the logs:\n%s", tc.name, secret, output)
+			}
+		}
+		for _, expected := range tc.expected {
+			if !strings.Contains(output, expected) {
+				t.Errorf("%v: expected the logs to contain %q:\n%s", tc.name, expected, output)
+			}
+		}
+		for _, notExpected := range tc.notExpected {
+			if strings.Contains(output, notExpected) {
+				t.Errorf("%v: expected the logs not to contain %q:\n%s", tc.name, notExpected, output)
+			}
+		}
+	}
+}
+
+func TestRetryIsLoggedAsWarning(t *testing.T) {
+	logger := newTestLogger(0)
+	klient := newTestClient(t, "retry warning", Version2_14(), false, httpChecks{}, httpReaction{})
+	klient.Logger = logger
+	klient.RetryPolicy = &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	attempts := 0
+	klient.doRequestFunc = func(request *http.Request) (*http.Response, error) {
+		attempts++
+		if attempts == 1 {
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: closer(`{}`)}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: closer(`{"services":[]}`)}, nil
+	}
+
+	if _, err := klient.GetCatalog(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A configured Logger has no warning level, so the retry is logged at
+	// verbosity 0, which is never filtered.
+	if e, a := "0 request attempt failed; retrying", logger.output(); !strings.Contains(a, e) {
+		t.Errorf("expected the logs to contain %q:\n%s", e, a)
+	}
+}
+
+func TestRedactBodyForLog(t *testing.T) {
+	cases := []struct {
+		name     string
+		paths    []string
+		body     string
+		expected string
+	}{
+		{
+			name:     "dashboard client secret in catalog",
+			body:     `{"services":[{"id":"s","dashboard_client":{"id":"d","secret":"shh"}}]}`,
+			expected: `{"services":[{"dashboard_client":{"id":"d","secret":"REDACTED"},"id":"s"}]}`,
+		},
+		{
+			name:     "OAuth2 tokens and client secret",
+			body:     `{"access_token":"a","refresh_token":"r","client_secret":"c","token_type":"bearer"}`,
+			expected: `{"access_token":"REDACTED","client_secret":"REDACTED","refresh_token":"REDACTED","token_type":"bearer"}`,
+		},
+		{
+			name:     "wildcard path",
+			paths:    []string{"services.*.metadata.internal"},
+			body:     `{"services":[{"metadata":{"internal":"a","public":"b"}},{"metadata":{"internal":"c"}}]}`,
+			expected: `{"services":[{"metadata":{"internal":"REDACTED","public":"b"}},{"metadata":{"internal":"REDACTED"}}]}`,
+		},
+		{
+			name:     "index path",
+			paths:    []string{"volume_mounts.1"},
+			body:     `{"volume_mounts":["a","b"]}`,
+			expected: `{"volume_mounts":["a","REDACTED"]}`,
+		},
+		{
+			name:     "missing path",
+			paths:    []string{"parameters.api_key"},
+			body:     `{"parameters":{"size":"small"}}`,
+			expected: `{"parameters":{"size":"small"}}`,
+		},
+		{
+			name:     "not JSON",
+			body:     `password=hunter2`,
+			expected: `<16 bytes of non-JSON content>`,
+		},
+	}
+
+	for _, tc := range cases {
+		paths, err := parseLogRedactPaths(tc.paths)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", tc.name, err)
+		}
+		klient := &client{logRedactPaths: paths}
+		if e, a := tc.expected, klient.redactBodyForLog([]byte(tc.body)); e != a {
+			t.Errorf("%v: unexpected redacted body:\nexpected %s\ngot      %s", tc.name, e, a)
+		}
+	}
+}
+
+func TestNewClientLogRedactPaths(t *testing.T) {
+	config := DefaultClientConfiguration()
+	config.URL = "https://example.com"
+	config.LogRedactPaths = []string{"parameters..api_key"}
+	if _, err := NewClient(config); err == nil {
+		t.Error("expected error for an invalid log redaction path")
+	}
+}
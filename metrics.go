@@ -0,0 +1,186 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Operation is the name of a Client method, used to label the requests it
+// sends to a broker.
+type Operation string
+
+const (
+	// OperationGetCatalog labels requests sent by GetCatalog.
+	OperationGetCatalog Operation = "GetCatalog"
+	// OperationProvisionInstance labels requests sent by ProvisionInstance.
+	OperationProvisionInstance Operation = "ProvisionInstance"
+	// OperationUpdateInstance labels requests sent by UpdateInstance.
+	OperationUpdateInstance Operation = "UpdateInstance"
+	// OperationDeprovisionInstance labels requests sent by
+	// DeprovisionInstance.
+	OperationDeprovisionInstance Operation = "DeprovisionInstance"
+	// OperationGetInstance labels requests sent by GetInstance.
+	OperationGetInstance Operation = "GetInstance"
+	// OperationPollLastOperation labels requests sent by PollLastOperation.
+	OperationPollLastOperation Operation = "PollLastOperation"
+	// OperationPollBindingLastOperation labels requests sent by
+	// PollBindingLastOperation.
+	OperationPollBindingLastOperation Operation = "PollBindingLastOperation"
+	// OperationBind labels requests sent by Bind.
+	OperationBind Operation = "Bind"
+	// OperationUnbind labels requests sent by Unbind.
+	OperationUnbind Operation = "Unbind"
+	// OperationGetBinding labels requests sent by GetBinding.
+	OperationGetBinding Operation = "GetBinding"
+)
+
+// ErrorClass is a coarse classification of the outcome of a request, suitable
+// for use as a metric label.
+type ErrorClass string
+
+const (
+	// ErrorClassNone is the class of requests that received a response with
+	// a status code below 400.
+	ErrorClassNone ErrorClass = ""
+	// ErrorClassClientError is the class of requests that received a 4xx
+	// response.
+	ErrorClassClientError ErrorClass = "client_error"
+	// ErrorClassServerError is the class of requests that received a 5xx
+	// response.
+	ErrorClassServerError ErrorClass = "server_error"
+	// ErrorClassTimeout is the class of requests that timed out, either
+	// because the client's timeout elapsed or because the context's deadline
+	// expired.
+	ErrorClassTimeout ErrorClass = "timeout"
+	// ErrorClassCanceled is the class of requests whose context was
+	// canceled.
+	ErrorClassCanceled ErrorClass = "canceled"
+	// ErrorClassNetwork is the class of requests that failed without a
+	// response for any other reason, such as a refused connection or a TLS
+	// error.
+	ErrorClassNetwork ErrorClass = "network"
+)
+
+// RequestMetrics describes a single HTTP request sent to a broker.  When a
+// request is retried, each attempt is described separately.
+type RequestMetrics struct {
+	// Name is the name of the client, as set in ClientConfiguration.
+	Name string
+	// Operation is the Client method that sent the request.
+	Operation Operation
+	// Method is the HTTP method of the request.
+	Method string
+	// Attempt is the number of the attempt, starting at 1.
+	Attempt int
+	// StatusCode is the status code of the response, or 0 if no response was
+	// received.
+	StatusCode int
+	// ErrorClass classifies the outcome of the request.
+	ErrorClass ErrorClass
+	// Duration is the time from sending the request to receiving the
+	// response headers, or to the request failing.
+	Duration time.Duration
+}
+
+// MetricsRecorder receives a RequestMetrics for every request a client sends
+// to a broker, to be exported to a metrics system such as Prometheus; for
+// example, RecordRequest might increment a counter labeled with Name,
+// Operation, Method and ErrorClass and observe Duration in a histogram.
+// RecordRequest is called synchronously, and possibly concurrently, so it
+// should not block.
+type MetricsRecorder interface {
+	RecordRequest(metrics RequestMetrics)
+}
+
+// MetricsRecorderFunc is an adapter allowing an ordinary function to be used
+// as a MetricsRecorder.
+type MetricsRecorderFunc func(metrics RequestMetrics)
+
+// RecordRequest calls f(metrics).
+func (f MetricsRecorderFunc) RecordRequest(metrics RequestMetrics) {
+	f(metrics)
+}
+
+type operationKey struct{}
+
+// withOperation returns a context labeling the requests made with it as
+// being sent by the given operation.
+func withOperation(ctx context.Context, operation Operation) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// operationFromContext returns the operation set with withOperation.
+func operationFromContext(ctx context.Context) Operation {
+	operation, _ := ctx.Value(operationKey{}).(Operation)
+	return operation
+}
+
+// doAttempt sends a single attempt of a request with doRequestFunc, recording
+// it with the client's MetricsRecorder.
+func (c *client) doAttempt(request *http.Request, attempt int) (*http.Response, error) {
+	if c.MetricsRecorder == nil {
+		return c.doRequestFunc(request)
+	}
+
+	start := time.Now()
+	response, err := c.doRequestFunc(request)
+
+	metrics := RequestMetrics{
+		Name:      c.Name,
+		Operation: operationFromContext(request.Context()),
+		Method:    request.Method,
+		Attempt:   attempt,
+		Duration:  time.Since(start),
+	}
+	if response != nil {
+		metrics.StatusCode = response.StatusCode
+	}
+	metrics.ErrorClass = classifyRequestError(request.Context(), metrics.StatusCode, err)
+	c.MetricsRecorder.RecordRequest(metrics)
+
+	return response, err
+}
+
+// classifyRequestError returns the ErrorClass of a request that received the
+// given status code or error.
+func classifyRequestError(ctx context.Context, statusCode int, err error) ErrorClass {
+	if err != nil {
+		var netErr net.Error
+		switch {
+		case errors.Is(ctx.Err(), context.Canceled):
+			return ErrorClassCanceled
+		case ctx.Err() != nil, errors.As(err, &netErr) && netErr.Timeout():
+			return ErrorClassTimeout
+		default:
+			return ErrorClassNetwork
+		}
+	}
+
+	switch {
+	case statusCode >= 500:
+		return ErrorClassServerError
+	case statusCode >= 400:
+		return ErrorClassClientError
+	default:
+		return ErrorClassNone
+	}
+}
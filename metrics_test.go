@@ -0,0 +1,199 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestMetricsRecorderOperations(t *testing.T) {
+	cases := []struct {
+		operation Operation
+		method    string
+		call      func(Client) error
+	}{
+		{
+			operation: OperationGetCatalog,
+			method:    http.MethodGet,
+			call:      func(c Client) error { _, err := c.GetCatalog(); return err },
+		},
+		{
+			operation: OperationProvisionInstance,
+			method:    http.MethodPut,
+			call:      func(c Client) error { _, err := c.ProvisionInstance(defaultProvisionRequest()); return err },
+		},
+		{
+			operation: OperationUpdateInstance,
+			method:    http.MethodPatch,
+			call:      func(c Client) error { _, err := c.UpdateInstance(defaultUpdateInstanceRequest()); return err },
+		},
+		{
+			operation: OperationDeprovisionInstance,
+			method:    http.MethodDelete,
+			call:      func(c Client) error { _, err := c.DeprovisionInstance(defaultDeprovisionRequest()); return err },
+		},
+		{
+			operation: OperationGetInstance,
+			method:    http.MethodGet,
+			call:      func(c Client) error { _, err := c.GetInstance(defaultGetInstanceRequest()); return err },
+		},
+		{
+			operation: OperationPollLastOperation,
+			method:    http.MethodGet,
+			call:      func(c Client) error { _, err := c.PollLastOperation(defaultLastOperationRequest()); return err },
+		},
+		{
+			operation: OperationPollBindingLastOperation,
+			method:    http.MethodGet,
+			call: func(c Client) error {
+				_, err := c.PollBindingLastOperation(defaultBindingLastOperationRequest())
+				return err
+			},
+		},
+		{
+			operation: OperationBind,
+			method:    http.MethodPut,
+			call:      func(c Client) error { _, err := c.Bind(defaultBindRequest()); return err },
+		},
+		{
+			operation: OperationUnbind,
+			method:    http.MethodDelete,
+			call:      func(c Client) error { _, err := c.Unbind(defaultUnbindRequest()); return err },
+		},
+		{
+			operation: OperationGetBinding,
+			method:    http.MethodGet,
+			call:      func(c Client) error { _, err := c.GetBinding(defaultGetBindingRequest()); return err },
+		},
+	}
+
+	for _, tc := range cases {
+		var recorded []RequestMetrics
+		klient := newTestClient(t, string(tc.operation), Version2_14(), true, httpChecks{}, httpReaction{})
+		klient.MetricsRecorder = MetricsRecorderFunc(func(m RequestMetrics) {
+			recorded = append(recorded, m)
+		})
+		klient.doRequestFunc = func(request *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusNotFound, Body: closer(`{}`)}, nil
+		}
+
+		_ = tc.call(klient)
+
+		if len(recorded) != 1 {
+			t.Errorf("%v: expected 1 recorded request, got %v", tc.operation, len(recorded))
+			continue
+		}
+		m := recorded[0]
+		if e, a := klient.Name, m.Name; e != a {
+			t.Errorf("%v: unexpected name; expected %v, got %v", tc.operation, e, a)
+		}
+		if e, a := tc.operation, m.Operation; e != a {
+			t.Errorf("%v: unexpected operation; expected %v, got %v", tc.operation, e, a)
+		}
+		if e, a := tc.method, m.Method; e != a {
+			t.Errorf("%v: unexpected method; expected %v, got %v", tc.operation, e, a)
+		}
+		if e, a := http.StatusNotFound, m.StatusCode; e != a {
+			t.Errorf("%v: unexpected status code; expected %v, got %v", tc.operation, e, a)
+		}
+		if e, a := ErrorClassClientError, m.ErrorClass; e != a {
+			t.Errorf("%v: unexpected error class; expected %q, got %q", tc.operation, e, a)
+		}
+	}
+}
+
+func TestMetricsRecorderRetries(t *testing.T) {
+	var recorded []RequestMetrics
+	var bodies []string
+	klient := newTestClient(t, "retries", Version2_14(), false, httpChecks{}, httpReaction{})
+	klient.RetryPolicy = testRetryPolicy()
+	klient.MetricsRecorder = MetricsRecorderFunc(func(m RequestMetrics) {
+		recorded = append(recorded, m)
+	})
+	klient.doRequestFunc = doAttempts(t, "retries", []attemptReaction{
+		{status: http.StatusServiceUnavailable},
+		{status: http.StatusOK},
+	}, &bodies)
+
+	if _, err := klient.GetCatalog(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(recorded) != 2 {
+		t.Fatalf("expected 2 recorded attempts, got %v", len(recorded))
+	}
+	for i, expected := range []struct {
+		status     int
+		errorClass ErrorClass
+	}{
+		{http.StatusServiceUnavailable, ErrorClassServerError},
+		{http.StatusOK, ErrorClassNone},
+	} {
+		m := recorded[i]
+		if e, a := i+1, m.Attempt; e != a {
+			t.Errorf("attempt %v: unexpected attempt number %v", e, a)
+		}
+		if e, a := expected.status, m.StatusCode; e != a {
+			t.Errorf("attempt %v: unexpected status code; expected %v, got %v", i+1, e, a)
+		}
+		if e, a := expected.errorClass, m.ErrorClass; e != a {
+			t.Errorf("attempt %v: unexpected error class; expected %q, got %q", i+1, e, a)
+		}
+		if e, a := OperationGetCatalog, m.Operation; e != a {
+			t.Errorf("attempt %v: unexpected operation; expected %v, got %v", i+1, e, a)
+		}
+	}
+}
+
+func TestClassifyRequestError(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+
+	cases := []struct {
+		name       string
+		ctx        context.Context
+		statusCode int
+		err        error
+		expected   ErrorClass
+	}{
+		{name: "200", ctx: context.Background(), statusCode: http.StatusOK, expected: ErrorClassNone},
+		{name: "409", ctx: context.Background(), statusCode: http.StatusConflict, expected: ErrorClassClientError},
+		{name: "502", ctx: context.Background(), statusCode: http.StatusBadGateway, expected: ErrorClassServerError},
+		{name: "canceled", ctx: canceled, err: canceled.Err(), expected: ErrorClassCanceled},
+		{name: "deadline exceeded", ctx: expired, err: expired.Err(), expected: ErrorClassTimeout},
+		{
+			name:     "client timeout",
+			ctx:      context.Background(),
+			err:      &url.Error{Op: "Get", URL: "https://example.com/v2/catalog", Err: timeoutError{}},
+			expected: ErrorClassTimeout,
+		},
+		{name: "connection refused", ctx: context.Background(), err: errors.New("connection refused"), expected: ErrorClassNetwork},
+	}
+
+	for _, tc := range cases {
+		if e, a := tc.expected, classifyRequestError(tc.ctx, tc.statusCode, tc.err); e != a {
+			t.Errorf("%v: expected %q, got %q", tc.name, e, a)
+		}
+	}
+}
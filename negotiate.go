@@ -0,0 +1,91 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// NegotiateAPIVersion returns the highest API version supported by this
+// library that the broker described by config accepts, never higher than
+// config.APIVersion if it is set.  Versions are tried in descending order by
+// requesting the broker's catalog; a 412 Precondition Failed response means
+// the broker does not support the version and the next one is tried.  Any
+// other error aborts negotiation and is returned.
+//
+// The result is not cached: every call probes the broker again.  A client
+// created with ClientConfiguration.NegotiateAPIVersion keeps the version it
+// negotiated for its lifetime instead.
+func NegotiateAPIVersion(config *ClientConfiguration) (APIVersion, error) {
+	probeConfig := *config
+	probeConfig.NegotiateAPIVersion = false
+	probe, err := NewClient(&probeConfig)
+	if err != nil {
+		return APIVersion{}, err
+	}
+
+	return probe.(*client).negotiateAPIVersion(context.Background())
+}
+
+// GetAPIVersion returns the API version used by the client.
+func (c *client) GetAPIVersion() APIVersion {
+	return c.APIVersion
+}
+
+// negotiateAPIVersion determines the highest API version, no higher than
+// c.APIVersion if set, that the broker accepts.  The broker is probed with a
+// copy of c, so c itself is not modified.
+func (c *client) negotiateAPIVersion(ctx context.Context) (APIVersion, error) {
+	ceiling := c.APIVersion
+	if ceiling.label == "" {
+		ceiling = LatestAPIVersion()
+	}
+
+	versions := APIVersions()
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].order > versions[j].order
+	})
+
+	var rejected []string
+	for _, version := range versions {
+		if version.order > ceiling.order {
+			continue
+		}
+
+		probe := *c
+		probe.APIVersion = version
+		_, err := probe.GetCatalogWithContext(ctx)
+		if err == nil {
+			c.logAt(LogLevelRequests).Info("negotiated API version", "version", version.label)
+			return version, nil
+		}
+
+		httpErr, isHTTPErr := IsHTTPError(err)
+		if !isHTTPErr || httpErr.StatusCode != http.StatusPreconditionFailed {
+			return APIVersion{}, err
+		}
+
+		c.logAt(LogLevelRequests).Info("API version rejected", "version", version.label)
+		rejected = append(rejected, version.label)
+	}
+
+	return APIVersion{}, fmt.Errorf("broker %q rejected every supported API version: %s", c.Name, strings.Join(rejected, ", "))
+}
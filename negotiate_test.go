@@ -0,0 +1,192 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// doNegotiationHTTP returns a doRequestFunc that rejects the given API
+// versions with a 412 and records the versions it was asked for.
+func doNegotiationHTTP(rejected map[string]bool, status int, requested *[]string) doRequestFunc {
+	return func(request *http.Request) (*http.Response, error) {
+		version := request.Header.Get(APIVersionHeader)
+		*requested = append(*requested, version)
+
+		code, body := http.StatusOK, okCatalogBytes
+		if status != 0 {
+			code, body = status, conventionalFailureResponseBody
+		} else if rejected[version] {
+			code, body = http.StatusPreconditionFailed, conventionalFailureResponseBody
+		}
+
+		return &http.Response{
+			StatusCode: code,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(body)),
+		}, nil
+	}
+}
+
+func TestNegotiateAPIVersion(t *testing.T) {
+	cases := []struct {
+		name              string
+		ceiling           APIVersion
+		rejected          map[string]bool
+		status            int
+		expectedVersion   APIVersion
+		expectedRequested []string
+		expectedErr       bool
+	}{
+		{
+			name:              "latest accepted",
+			expectedVersion:   Version2_15(),
+			expectedRequested: []string{"2.15"},
+		},
+		{
+			name:              "newer versions rejected",
+			rejected:          map[string]bool{"2.15": true, "2.14": true},
+			expectedVersion:   Version2_13(),
+			expectedRequested: []string{"2.15", "2.14", "2.13"},
+		},
+		{
+			name:              "configured version is the ceiling",
+			ceiling:           Version2_12(),
+			expectedVersion:   Version2_12(),
+			expectedRequested: []string{"2.12"},
+		},
+		{
+			name:              "every version rejected",
+			ceiling:           Version2_12(),
+			rejected:          map[string]bool{"2.12": true, "2.11": true},
+			expectedRequested: []string{"2.12", "2.11"},
+			expectedErr:       true,
+		},
+		{
+			name:              "other errors abort negotiation",
+			status:            http.StatusInternalServerError,
+			expectedRequested: []string{"2.15"},
+			expectedErr:       true,
+		},
+	}
+
+	for _, tc := range cases {
+		var requested []string
+		klient := &client{
+			Name:       "test client",
+			URL:        "https://example.com",
+			APIVersion: tc.ceiling,
+		}
+		doRequest := doNegotiationHTTP(tc.rejected, tc.status, &requested)
+		klient.doRequestFunc = func(request *http.Request) (*http.Response, error) {
+			if e, a := tc.ceiling, klient.APIVersion; e != a {
+				t.Errorf("%v: expected client API version to stay %v while probing, got %v", tc.name, e, a)
+			}
+			return doRequest(request)
+		}
+
+		version, err := klient.negotiateAPIVersion(context.Background())
+		if tc.expectedErr && err == nil {
+			t.Errorf("%v: expected an error", tc.name)
+		}
+		if !tc.expectedErr && err != nil {
+			t.Errorf("%v: unexpected error: %v", tc.name, err)
+		}
+		if e, a := tc.expectedVersion, version; e != a {
+			t.Errorf("%v: expected version %v, got %v", tc.name, e, a)
+		}
+		if e, a := tc.expectedRequested, requested; !reflect.DeepEqual(e, a) {
+			t.Errorf("%v: expected requested versions %v, got %v", tc.name, e, a)
+		}
+		if e, a := tc.ceiling, klient.APIVersion; e != a {
+			t.Errorf("%v: expected client API version to stay %v, got %v", tc.name, e, a)
+		}
+	}
+}
+
+func TestNegotiateAPIVersionNotShared(t *testing.T) {
+	var requested []string
+	rejected := map[string]bool{"2.15": true}
+	newClient := func() *client {
+		return &client{
+			Name:          "test client",
+			URL:           "https://example.com",
+			doRequestFunc: doNegotiationHTTP(rejected, 0, &requested),
+		}
+	}
+
+	version, err := newClient().negotiateAPIVersion(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := Version2_14(), version; e != a {
+		t.Errorf("expected version %v, got %v", e, a)
+	}
+
+	// The broker was upgraded: a new client for the same URL sees it.
+	rejected = map[string]bool{}
+	requested = nil
+	version, err = newClient().negotiateAPIVersion(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := Version2_15(), version; e != a {
+		t.Errorf("expected version %v, got %v", e, a)
+	}
+	if e, a := []string{"2.15"}, requested; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected requested versions %v, got %v", e, a)
+	}
+}
+
+func TestNewClientNegotiateAPIVersion(t *testing.T) {
+	var requested []string
+	doRequest := doNegotiationHTTP(map[string]bool{"2.15": true, "2.14": true}, 0, &requested)
+
+	config := DefaultClientConfiguration()
+	config.URL = "https://example.com/"
+	config.NegotiateAPIVersion = true
+	config.Transport = RoundTripperFunc(doRequest)
+
+	klient, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := Version2_13(), klient.(APIVersionGetter).GetAPIVersion(); e != a {
+		t.Errorf("expected negotiated version %v, got %v", e, a)
+	}
+
+	// The client keeps the negotiated version.
+	requested = nil
+	if _, err := klient.GetCatalog(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := []string{"2.13"}, requested; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected requested versions %v, got %v", e, a)
+	}
+
+	version, err := NegotiateAPIVersion(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := Version2_13(), version; e != a {
+		t.Errorf("expected negotiated version %v, got %v", e, a)
+	}
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// oauth2ExpiryDelta is how long before its expiry a token is refreshed, so
+// that it does not expire while a request is in flight.
+const oauth2ExpiryDelta = 10 * time.Second
+
+// oauth2TokenSource fetches access tokens with the OAuth2 client credentials
+// grant and caches them until shortly before they expire.
+type oauth2TokenSource struct {
+	config    *OAuth2ClientCredentialsConfig
+	doRequest func(*http.Request) (*http.Response, error)
+	now       func() time.Time
+
+	// mu is held while a token is fetched, so that concurrent requests
+	// needing a new token share a single token request.
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+type oauth2TokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// newOAuth2HTTPClient returns the http.Client used to send token requests.  It
+// is separate from the client used for the broker, so that token requests do
+// not pass through the broker's Transport, Middleware or TLS configuration.
+func newOAuth2HTTPClient(config *OAuth2ClientCredentialsConfig, timeout time.Duration) (*http.Client, error) {
+	httpClient := &http.Client{Timeout: timeout}
+
+	if config.Transport != nil {
+		if config.TLSConfig != nil {
+			return nil, errors.New("Cannot specify a Transport together with a TLSConfig in OAuth2ClientCredentialsConfig")
+		}
+		httpClient.Transport = config.Transport
+	} else if config.TLSConfig != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = config.TLSConfig.Clone()
+		httpClient.Transport = transport
+	}
+
+	return httpClient, nil
+}
+
+func newOAuth2TokenSource(config *OAuth2ClientCredentialsConfig, doRequest func(*http.Request) (*http.Response, error)) *oauth2TokenSource {
+	return &oauth2TokenSource{
+		config:    config,
+		doRequest: doRequest,
+		now:       time.Now,
+	}
+}
+
+// Token returns a valid access token, fetching a new one if there is no
+// cached token, the cached token is about to expire, or forceRefresh is set.
+func (s *oauth2TokenSource) Token(ctx context.Context, forceRefresh bool) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !forceRefresh && s.token != "" && (s.expiry.IsZero() || s.now().Add(oauth2ExpiryDelta).Before(s.expiry)) {
+		return s.token, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(s.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.config.Scopes, " "))
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set(contentType, "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", jsonType)
+	// RFC 6749 section 2.3.1 requires the client credentials to be
+	// form-encoded before they are used for basic auth.
+	request.SetBasicAuth(url.QueryEscape(s.config.ClientID), url.QueryEscape(s.config.ClientSecret))
+
+	requested := s.now()
+	response, err := s.doRequest(request)
+	if err != nil {
+		return "", fmt.Errorf("fetching OAuth2 token: %w", err)
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", fmt.Errorf("fetching OAuth2 token: %w", err)
+	}
+
+	tokenResponse := &oauth2TokenResponse{}
+	if err := json.Unmarshal(body, tokenResponse); err != nil && response.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("fetching OAuth2 token: malformed response: %w", err)
+	}
+	if response.StatusCode != http.StatusOK || tokenResponse.AccessToken == "" {
+		return "", fmt.Errorf("fetching OAuth2 token: status %d; error: %q; description: %q", response.StatusCode, tokenResponse.Error, tokenResponse.ErrorDescription)
+	}
+	// Token types are case insensitive (RFC 6749 section 5.1), and only
+	// bearer tokens can be sent to the broker.
+	if !strings.EqualFold(tokenResponse.TokenType, "bearer") {
+		return "", fmt.Errorf("fetching OAuth2 token: unsupported token type %q", tokenResponse.TokenType)
+	}
+
+	s.token = tokenResponse.AccessToken
+	s.expiry = time.Time{}
+	if tokenResponse.ExpiresIn > 0 {
+		s.expiry = requested.Add(time.Duration(tokenResponse.ExpiresIn) * time.Second)
+	}
+
+	return s.token, nil
+}
+
+// retryWithRefreshedToken discards a 401 Unauthorized response to the given
+// request and repeats the request once with a newly fetched OAuth2 token, in
+// case the broker rejected a token that was revoked or expired early.
+func (c *client) retryWithRefreshedToken(request *http.Request, response *http.Response) (*http.Response, error) {
+	_ = drainReader(response.Body)
+	response.Body.Close()
+
+	token, err := c.tokenSource.Token(request.Context(), true)
+	if err != nil {
+		return nil, err
+	}
+
+	retryRequest := request.Clone(request.Context())
+	if request.GetBody != nil {
+		body, err := request.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		retryRequest.Body = body
+	}
+	retryRequest.Header.Set("Authorization", "Bearer "+token)
+
+	return c.doWithRetry(retryRequest)
+}
@@ -0,0 +1,325 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const testTokenURL = "https://auth.example.com/oauth/token"
+
+// fakeOAuth2Broker serves token requests to testTokenURL, issuing tokens
+// "token-1", "token-2" and so on, and answers other requests as a broker
+// that only accepts the tokens in accepted.
+type fakeOAuth2Broker struct {
+	t             *testing.T
+	expiresIn     int
+	tokenRequests int
+	accepted      map[string]bool
+	brokerBodies  []string
+	authorization []string
+}
+
+func (f *fakeOAuth2Broker) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.URL.String() == testTokenURL {
+		f.tokenRequests++
+		if err := request.ParseForm(); err != nil {
+			f.t.Fatalf("unexpected error parsing token request: %v", err)
+		}
+		if e, a := "client_credentials", request.PostForm.Get("grant_type"); e != a {
+			f.t.Errorf("unexpected grant_type; expected %v, got %v", e, a)
+		}
+		if e, a := "broker.read broker.write", request.PostForm.Get("scope"); e != a {
+			f.t.Errorf("unexpected scope; expected %v, got %v", e, a)
+		}
+		username, password, ok := request.BasicAuth()
+		if !ok || username != "client%2Fid" || password != "secret" {
+			f.t.Errorf("unexpected client credentials %q:%q", username, password)
+		}
+
+		body := fmt.Sprintf(`{"access_token":"token-%d","token_type":"bearer","expires_in":%d}`, f.tokenRequests, f.expiresIn)
+		return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewBufferString(body))}, nil
+	}
+
+	authorization := request.Header.Get("Authorization")
+	f.authorization = append(f.authorization, authorization)
+	if request.Body != nil {
+		body, _ := ioutil.ReadAll(request.Body)
+		f.brokerBodies = append(f.brokerBodies, string(body))
+	}
+
+	if !f.accepted[authorization] {
+		return &http.Response{StatusCode: http.StatusUnauthorized, Body: ioutil.NopCloser(bytes.NewBufferString(`{}`))}, nil
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewBufferString(`{"services":[]}`))}, nil
+}
+
+// newOAuth2TestClient returns a client that sends both broker and token
+// requests to the given fakeOAuth2Broker.  Token requests must not pass
+// through the broker Middleware.
+func newOAuth2TestClient(t *testing.T, broker *fakeOAuth2Broker) *client {
+	config := DefaultClientConfiguration()
+	config.URL = "https://example.com"
+	config.APIVersion = Version2_14()
+	config.Transport = broker
+	config.Middleware = []TransportMiddleware{
+		func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+				if request.URL.String() == testTokenURL {
+					t.Errorf("expected token requests not to pass through the broker middleware")
+				}
+				return next.RoundTrip(request)
+			})
+		},
+	}
+	config.AuthConfig = &AuthConfig{
+		OAuth2ClientCredentialsConfig: &OAuth2ClientCredentialsConfig{
+			TokenURL:     testTokenURL,
+			ClientID:     "client/id",
+			ClientSecret: "secret",
+			Scopes:       []string{"broker.read", "broker.write"},
+			Transport:    broker,
+		},
+	}
+
+	klient, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return klient.(*client)
+}
+
+func TestOAuth2TokenIsCached(t *testing.T) {
+	broker := &fakeOAuth2Broker{t: t, expiresIn: 3600, accepted: map[string]bool{"Bearer token-1": true}}
+	klient := newOAuth2TestClient(t, broker)
+
+	for i := 0; i < 3; i++ {
+		if _, err := klient.GetCatalog(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if e, a := 1, broker.tokenRequests; e != a {
+		t.Errorf("expected %v token requests, got %v", e, a)
+	}
+	for _, authorization := range broker.authorization {
+		if e, a := "Bearer token-1", authorization; e != a {
+			t.Errorf("unexpected Authorization header; expected %v, got %v", e, a)
+		}
+	}
+}
+
+func TestOAuth2TokenIsRefreshedBeforeExpiry(t *testing.T) {
+	broker := &fakeOAuth2Broker{t: t, expiresIn: 60, accepted: map[string]bool{"Bearer token-1": true, "Bearer token-2": true}}
+	klient := newOAuth2TestClient(t, broker)
+
+	now := time.Now()
+	klient.tokenSource.now = func() time.Time { return now }
+
+	if _, err := klient.GetCatalog(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now = now.Add(45 * time.Second)
+	if _, err := klient.GetCatalog(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := 1, broker.tokenRequests; e != a {
+		t.Errorf("expected the token to be reused; expected %v token requests, got %v", e, a)
+	}
+
+	// Within oauth2ExpiryDelta of the token expiring.
+	now = now.Add(10 * time.Second)
+	if _, err := klient.GetCatalog(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := 2, broker.tokenRequests; e != a {
+		t.Errorf("expected the token to be refreshed; expected %v token requests, got %v", e, a)
+	}
+	if e, a := "Bearer token-2", broker.authorization[2]; e != a {
+		t.Errorf("unexpected Authorization header; expected %v, got %v", e, a)
+	}
+}
+
+func TestOAuth2RetriesOnceOnUnauthorized(t *testing.T) {
+	// The broker rejects the first token, as if it had been revoked.
+	broker := &fakeOAuth2Broker{t: t, expiresIn: 3600, accepted: map[string]bool{"Bearer token-2": true}}
+	klient := newOAuth2TestClient(t, broker)
+
+	request := defaultProvisionRequest()
+	request.AcceptsIncomplete = true
+	if _, err := klient.ProvisionInstance(request); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if e, a := 2, broker.tokenRequests; e != a {
+		t.Errorf("expected %v token requests, got %v", e, a)
+	}
+	if e, a := []string{"Bearer token-1", "Bearer token-2"}, broker.authorization; fmt.Sprint(e) != fmt.Sprint(a) {
+		t.Errorf("unexpected Authorization headers; expected %v, got %v", e, a)
+	}
+	if len(broker.brokerBodies) != 2 || broker.brokerBodies[0] == "" || broker.brokerBodies[0] != broker.brokerBodies[1] {
+		t.Errorf("expected the request body to be sent again on retry, got %q", broker.brokerBodies)
+	}
+}
+
+func TestOAuth2UnauthorizedAfterRefresh(t *testing.T) {
+	broker := &fakeOAuth2Broker{t: t, expiresIn: 3600, accepted: map[string]bool{}}
+	klient := newOAuth2TestClient(t, broker)
+
+	_, err := klient.GetCatalog()
+	httpErr, ok := IsHTTPError(err)
+	if !ok {
+		t.Fatalf("expected an HTTP error, got %v", err)
+	}
+	if e, a := http.StatusUnauthorized, httpErr.StatusCode; e != a {
+		t.Errorf("unexpected status code; expected %v, got %v", e, a)
+	}
+	if e, a := 2, len(broker.authorization); e != a {
+		t.Errorf("expected the request to be retried once; expected %v requests, got %v", e, a)
+	}
+}
+
+func TestOAuth2TokenError(t *testing.T) {
+	klient := newTestClient(t, "oauth2 token error", Version2_14(), false, httpChecks{}, httpReaction{})
+	klient.tokenSource = newOAuth2TokenSource(&OAuth2ClientCredentialsConfig{
+		TokenURL: testTokenURL,
+		ClientID: "client-id",
+	}, func(request *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusBadRequest,
+			Body:       closer(`{"error":"invalid_client","error_description":"unknown client"}`),
+		}, nil
+	})
+	klient.AuthConfig = &AuthConfig{OAuth2ClientCredentialsConfig: klient.tokenSource.config}
+	klient.doRequestFunc = func(request *http.Request) (*http.Response, error) {
+		t.Error("expected no request to the broker without a token")
+		return nil, fmt.Errorf("unexpected request")
+	}
+
+	if _, err := klient.GetCatalog(); err == nil {
+		t.Fatal("expected an error when the token endpoint rejects the client")
+	}
+}
+
+func TestOAuth2TokenRequestErrorIsWrapped(t *testing.T) {
+	sentinel := errors.New("connection refused")
+	source := newOAuth2TokenSource(&OAuth2ClientCredentialsConfig{
+		TokenURL: testTokenURL,
+		ClientID: "client-id",
+	}, func(request *http.Request) (*http.Response, error) {
+		return nil, sentinel
+	})
+
+	if _, err := source.Token(context.Background(), false); !errors.Is(err, sentinel) {
+		t.Fatalf("expected the token request error to be wrapped, got %v", err)
+	}
+}
+
+func TestOAuth2TokenType(t *testing.T) {
+	cases := []struct {
+		tokenType string
+		expectErr bool
+	}{
+		{tokenType: "bearer"},
+		{tokenType: "Bearer"},
+		{tokenType: "mac", expectErr: true},
+		{tokenType: "", expectErr: true},
+	}
+
+	for _, tc := range cases {
+		source := newOAuth2TokenSource(&OAuth2ClientCredentialsConfig{
+			TokenURL: testTokenURL,
+			ClientID: "client-id",
+		}, func(request *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       closer(fmt.Sprintf(`{"access_token":"token","token_type":%q}`, tc.tokenType)),
+			}, nil
+		})
+
+		_, err := source.Token(context.Background(), false)
+		if tc.expectErr && err == nil {
+			t.Errorf("%q: expected error, got none", tc.tokenType)
+		}
+		if !tc.expectErr && err != nil {
+			t.Errorf("%q: unexpected error: %v", tc.tokenType, err)
+		}
+	}
+}
+
+func TestNewClientOAuth2Validation(t *testing.T) {
+	cases := []struct {
+		name       string
+		authConfig *AuthConfig
+	}{
+		{
+			name:       "empty",
+			authConfig: &AuthConfig{},
+		},
+		{
+			name: "combined with bearer",
+			authConfig: &AuthConfig{
+				BearerConfig: &BearerConfig{Token: "token"},
+				OAuth2ClientCredentialsConfig: &OAuth2ClientCredentialsConfig{
+					TokenURL: testTokenURL,
+					ClientID: "client-id",
+				},
+			},
+		},
+		{
+			name: "missing token URL",
+			authConfig: &AuthConfig{
+				OAuth2ClientCredentialsConfig: &OAuth2ClientCredentialsConfig{ClientID: "client-id"},
+			},
+		},
+		{
+			name: "missing client ID",
+			authConfig: &AuthConfig{
+				OAuth2ClientCredentialsConfig: &OAuth2ClientCredentialsConfig{TokenURL: testTokenURL},
+			},
+		},
+		{
+			name: "transport combined with TLS config",
+			authConfig: &AuthConfig{
+				OAuth2ClientCredentialsConfig: &OAuth2ClientCredentialsConfig{
+					TokenURL:  testTokenURL,
+					ClientID:  "client-id",
+					TLSConfig: &tls.Config{},
+					Transport: http.DefaultTransport,
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		config := DefaultClientConfiguration()
+		config.URL = "https://example.com"
+		config.AuthConfig = tc.authConfig
+		if _, err := NewClient(config); err == nil {
+			t.Errorf("%v: expected error, got none", tc.name)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KubernetesOriginatingIdentity is the value of an OriginatingIdentity for
+// the PlatformKubernetes platform.  Its fields are those of the user info of
+// a Kubernetes user.
+type KubernetesOriginatingIdentity struct {
+	// Username is the name of the user.
+	Username string `json:"username"`
+	// UID is the unique ID of the user.
+	UID string `json:"uid"`
+	// Groups are the groups the user belongs to.
+	Groups []string `json:"groups"`
+	// Extra is any additional information about the user provided by the
+	// authenticator.
+	Extra map[string][]string `json:"extra,omitempty"`
+}
+
+// CloudFoundryOriginatingIdentity is the value of an OriginatingIdentity for
+// the PlatformCloudFoundry platform.
+type CloudFoundryOriginatingIdentity struct {
+	// UserID is the GUID of the user.
+	UserID string `json:"user_id"`
+}
+
+// NewKubernetesOriginatingIdentity returns an OriginatingIdentity for the
+// Kubernetes user with the given user info.
+func NewKubernetesOriginatingIdentity(username, uid string, groups []string, extra map[string][]string) *OriginatingIdentity {
+	if groups == nil {
+		groups = []string{}
+	}
+	return newOriginatingIdentity(PlatformKubernetes, &KubernetesOriginatingIdentity{
+		Username: username,
+		UID:      uid,
+		Groups:   groups,
+		Extra:    extra,
+	})
+}
+
+// NewCloudFoundryOriginatingIdentity returns an OriginatingIdentity for the
+// Cloud Foundry user with the given GUID.
+func NewCloudFoundryOriginatingIdentity(userID string) *OriginatingIdentity {
+	return newOriginatingIdentity(PlatformCloudFoundry, &CloudFoundryOriginatingIdentity{
+		UserID: userID,
+	})
+}
+
+func newOriginatingIdentity(platform string, value interface{}) *OriginatingIdentity {
+	// Marshalling structs of strings cannot fail.
+	valueBytes, _ := json.Marshal(value)
+	return &OriginatingIdentity{
+		Platform: platform,
+		Value:    string(valueBytes),
+	}
+}
+
+// ParseOriginatingIdentity parses the value of an OriginatingIdentityHeader
+// sent by a platform.  It is the inverse of the encoding the client does when
+// sending an OriginatingIdentity.
+func ParseOriginatingIdentity(headerValue string) (*OriginatingIdentity, error) {
+	parts := strings.SplitN(strings.TrimSpace(headerValue), " ", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return nil, errors.New("originating identity must be a platform and a value separated by a space")
+	}
+
+	value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return nil, fmt.Errorf("originating identity value must be base64 encoded: %v", err)
+	}
+	if err := isValidJSON(string(value)); err != nil {
+		return nil, fmt.Errorf("originating identity value must be valid JSON: %v", err)
+	}
+
+	return &OriginatingIdentity{
+		Platform: parts[0],
+		Value:    string(value),
+	}, nil
+}
+
+// Kubernetes returns the value of an OriginatingIdentity for the
+// PlatformKubernetes platform.
+func (i *OriginatingIdentity) Kubernetes() (*KubernetesOriginatingIdentity, error) {
+	identity := &KubernetesOriginatingIdentity{}
+	if err := i.unmarshalValue(PlatformKubernetes, identity); err != nil {
+		return nil, err
+	}
+	if identity.Username == "" {
+		return nil, required("originating identity username")
+	}
+	return identity, nil
+}
+
+// CloudFoundry returns the value of an OriginatingIdentity for the
+// PlatformCloudFoundry platform.
+func (i *OriginatingIdentity) CloudFoundry() (*CloudFoundryOriginatingIdentity, error) {
+	identity := &CloudFoundryOriginatingIdentity{}
+	if err := i.unmarshalValue(PlatformCloudFoundry, identity); err != nil {
+		return nil, err
+	}
+	if identity.UserID == "" {
+		return nil, required("originating identity user_id")
+	}
+	return identity, nil
+}
+
+func (i *OriginatingIdentity) unmarshalValue(platform string, value interface{}) error {
+	if i.Platform != platform {
+		return fmt.Errorf("originating identity platform is %q, not %q", i.Platform, platform)
+	}
+	if err := json.Unmarshal([]byte(i.Value), value); err != nil {
+		return fmt.Errorf("invalid %v originating identity value: %v", platform, err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewKubernetesOriginatingIdentity(t *testing.T) {
+	cases := []struct {
+		name          string
+		groups        []string
+		extra         map[string][]string
+		expectedValue string
+	}{
+		{
+			name:          "groups and extra",
+			groups:        []string{"admins", "system:authenticated"},
+			extra:         map[string][]string{"scopes": {"openid"}},
+			expectedValue: `{"username":"alice","uid":"c2dde242-5ce4-11e7-988c-000c2946f14f","groups":["admins","system:authenticated"],"extra":{"scopes":["openid"]}}`,
+		},
+		{
+			name:          "no groups or extra",
+			expectedValue: `{"username":"alice","uid":"c2dde242-5ce4-11e7-988c-000c2946f14f","groups":[]}`,
+		},
+	}
+
+	for _, tc := range cases {
+		identity := NewKubernetesOriginatingIdentity("alice", "c2dde242-5ce4-11e7-988c-000c2946f14f", tc.groups, tc.extra)
+		if e, a := PlatformKubernetes, identity.Platform; e != a {
+			t.Errorf("%v: unexpected platform; expected %v, got %v", tc.name, e, a)
+		}
+		if e, a := tc.expectedValue, identity.Value; e != a {
+			t.Errorf("%v: unexpected value;\nexpected %v\ngot      %v", tc.name, e, a)
+		}
+	}
+}
+
+func TestNewCloudFoundryOriginatingIdentity(t *testing.T) {
+	identity := NewCloudFoundryOriginatingIdentity("683ea748-3092-4ff4-b656-39cacc4d5360")
+	if e, a := PlatformCloudFoundry, identity.Platform; e != a {
+		t.Errorf("unexpected platform; expected %v, got %v", e, a)
+	}
+	if e, a := `{"user_id":"683ea748-3092-4ff4-b656-39cacc4d5360"}`, identity.Value; e != a {
+		t.Errorf("unexpected value; expected %v, got %v", e, a)
+	}
+}
+
+func TestParseOriginatingIdentity(t *testing.T) {
+	kubernetes := NewKubernetesOriginatingIdentity("alice", "uid", []string{"admins"}, map[string][]string{"scopes": {"openid"}})
+	headerValue, err := buildOriginatingIdentityHeaderValue(kubernetes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	parsed, err := ParseOriginatingIdentity(headerValue)
+	if err != nil {
+		t.Fatalf("unexpected error parsing %q: %v", headerValue, err)
+	}
+	if e, a := kubernetes, parsed; !reflect.DeepEqual(e, a) {
+		t.Errorf("unexpected originating identity; expected %+v, got %+v", e, a)
+	}
+
+	user, err := parsed.Kubernetes()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedUser := &KubernetesOriginatingIdentity{
+		Username: "alice",
+		UID:      "uid",
+		Groups:   []string{"admins"},
+		Extra:    map[string][]string{"scopes": {"openid"}},
+	}
+	if e, a := expectedUser, user; !reflect.DeepEqual(e, a) {
+		t.Errorf("unexpected Kubernetes user; expected %+v, got %+v", e, a)
+	}
+	if _, err := parsed.CloudFoundry(); err == nil {
+		t.Error("expected an error for a Kubernetes identity parsed as Cloud Foundry")
+	}
+
+	// The example from the Open Service Broker API specification.
+	parsed, err = ParseOriginatingIdentity("cloudfoundry eyANCiAgInVzZXJfaWQiOiAiNjgzZWE3NDgtMzA5Mi00ZmY0LWI2NTYtMzljYWNjNGQ1MzYwIg0KfQ==")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfUser, err := parsed.CloudFoundry()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := "683ea748-3092-4ff4-b656-39cacc4d5360", cfUser.UserID; e != a {
+		t.Errorf("unexpected Cloud Foundry user ID; expected %v, got %v", e, a)
+	}
+}
+
+func TestParseOriginatingIdentityErrors(t *testing.T) {
+	cases := []struct {
+		name        string
+		headerValue string
+	}{
+		{name: "empty", headerValue: ""},
+		{name: "no value", headerValue: "kubernetes"},
+		{name: "not base64", headerValue: "kubernetes not-base64!"},
+		{name: "not JSON", headerValue: "kubernetes bm90IGpzb24="},
+	}
+
+	for _, tc := range cases {
+		if _, err := ParseOriginatingIdentity(tc.headerValue); err == nil {
+			t.Errorf("%v: expected an error parsing %q", tc.name, tc.headerValue)
+		}
+	}
+}
+
+func TestOriginatingIdentityMissingUser(t *testing.T) {
+	if _, err := (&OriginatingIdentity{Platform: PlatformKubernetes, Value: `{"uid":"uid"}`}).Kubernetes(); err == nil {
+		t.Error("expected an error for a Kubernetes identity without a username")
+	}
+	if _, err := (&OriginatingIdentity{Platform: PlatformCloudFoundry, Value: `{}`}).CloudFoundry(); err == nil {
+		t.Error("expected an error for a Cloud Foundry identity without a user ID")
+	}
+}
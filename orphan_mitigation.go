@@ -0,0 +1,117 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// isOrphanMitigationRequired returns whether the error returned for a
+// provision or bind request is one of the conditions for which the Open
+// Service Broker API specification requires platforms to perform orphan
+// mitigation: a timeout, a 201 Created response with a malformed body, any
+// other 2xx response besides 200 OK, a 408 Request Timeout, or a 5xx
+// response.
+func isOrphanMitigationRequired(err error) bool {
+	if IsDeadlineExceededError(err) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	statusCodeError, ok := IsHTTPError(err)
+	if !ok {
+		return false
+	}
+
+	switch code := statusCodeError.StatusCode; {
+	case code == http.StatusCreated:
+		return statusCodeError.ResponseError != nil
+	case code == http.StatusOK:
+		return false
+	case code >= 200 && code < 300:
+		return true
+	case code == http.StatusRequestTimeout:
+		return true
+	case code >= 500:
+		return true
+	default:
+		return false
+	}
+}
+
+// mitigateOrphanedInstance sends a deprovision request for the instance
+// described by the given provision request and returns an
+// OrphanMitigationError reporting the outcome.  The deprovision request is
+// not bound to the context of the provision request, which may already have
+// expired.
+func (c *client) mitigateOrphanedInstance(r *ProvisionRequest, err error) error {
+	c.logger().Error(err, "performing orphan mitigation for instance", "instanceID", r.InstanceID)
+
+	mitigationErr := OrphanMitigationError{Err: err}
+
+	response, deprovisionErr := c.DeprovisionInstanceWithContext(context.Background(), &DeprovisionRequest{
+		InstanceID:          r.InstanceID,
+		AcceptsIncomplete:   r.AcceptsIncomplete,
+		ServiceID:           r.ServiceID,
+		PlanID:              r.PlanID,
+		OriginatingIdentity: r.OriginatingIdentity,
+	})
+	if deprovisionErr != nil {
+		mitigationErr.MitigationErr = deprovisionErr
+		return mitigationErr
+	}
+
+	mitigationErr.MitigationSucceeded = true
+	mitigationErr.MitigationAsync = response.Async
+	mitigationErr.MitigationOperationKey = response.OperationKey
+	return mitigationErr
+}
+
+// mitigateOrphanedBinding sends an unbind request for the binding described
+// by the given bind request and returns an OrphanMitigationError reporting
+// the outcome.  The unbind request is not bound to the context of the bind
+// request, which may already have expired.
+func (c *client) mitigateOrphanedBinding(r *BindRequest, err error) error {
+	c.logger().Error(err, "performing orphan mitigation for binding", "instanceID", r.InstanceID, "bindingID", r.BindingID)
+
+	mitigationErr := OrphanMitigationError{Err: err}
+
+	response, unbindErr := c.UnbindWithContext(context.Background(), &UnbindRequest{
+		InstanceID:          r.InstanceID,
+		BindingID:           r.BindingID,
+		AcceptsIncomplete:   r.AcceptsIncomplete,
+		ServiceID:           r.ServiceID,
+		PlanID:              r.PlanID,
+		OriginatingIdentity: r.OriginatingIdentity,
+	})
+	if unbindErr != nil {
+		mitigationErr.MitigationErr = unbindErr
+		return mitigationErr
+	}
+
+	mitigationErr.MitigationSucceeded = true
+	mitigationErr.MitigationAsync = response.Async
+	mitigationErr.MitigationOperationKey = response.OperationKey
+	return mitigationErr
+}
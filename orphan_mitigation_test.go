@@ -0,0 +1,333 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// timeoutError is a net.Error that reports a timeout.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// doOrphanMitigationHTTP returns a doRequestFunc that replies to PUT requests
+// with putReaction and to DELETE requests with deleteReaction, recording the
+// methods of the requests it receives.
+func doOrphanMitigationHTTP(putReaction, deleteReaction httpReaction, methods *[]string) doRequestFunc {
+	return func(request *http.Request) (*http.Response, error) {
+		*methods = append(*methods, request.Method)
+
+		reaction := putReaction
+		if request.Method == http.MethodDelete {
+			reaction = deleteReaction
+		}
+		if reaction.err != nil {
+			return nil, reaction.err
+		}
+
+		return &http.Response{
+			StatusCode: reaction.status,
+			Header:     reaction.header,
+			Body:       closer(reaction.body),
+		}, nil
+	}
+}
+
+func TestIsOrphanMitigationRequired(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "other error",
+			err:      errors.New("other error"),
+			expected: false,
+		},
+		{
+			name:     "200 with malformed response",
+			err:      HTTPStatusCodeError{StatusCode: http.StatusOK, ResponseError: errors.New("malformed")},
+			expected: false,
+		},
+		{
+			name:     "201 with malformed response",
+			err:      HTTPStatusCodeError{StatusCode: http.StatusCreated, ResponseError: errors.New("malformed")},
+			expected: true,
+		},
+		{
+			name:     "other 2xx",
+			err:      HTTPStatusCodeError{StatusCode: http.StatusNoContent},
+			expected: true,
+		},
+		{
+			name:     "408",
+			err:      HTTPStatusCodeError{StatusCode: http.StatusRequestTimeout},
+			expected: true,
+		},
+		{
+			name:     "other 4xx",
+			err:      HTTPStatusCodeError{StatusCode: http.StatusConflict},
+			expected: false,
+		},
+		{
+			name:     "5xx",
+			err:      HTTPStatusCodeError{StatusCode: http.StatusBadGateway},
+			expected: true,
+		},
+		{
+			name:     "client timeout",
+			err:      &url.Error{Op: http.MethodPut, URL: "https://example.com", Err: timeoutError{}},
+			expected: true,
+		},
+		{
+			name:     "context deadline exceeded",
+			err:      ContextError{Err: context.DeadlineExceeded},
+			expected: true,
+		},
+		{
+			name:     "context canceled",
+			err:      ContextError{Err: context.Canceled},
+			expected: false,
+		},
+	}
+
+	for _, tc := range cases {
+		if e, a := tc.expected, isOrphanMitigationRequired(tc.err); e != a {
+			t.Errorf("%v: expected %v, got %v", tc.name, e, a)
+		}
+	}
+}
+
+func TestProvisionInstanceOrphanMitigation(t *testing.T) {
+	cases := []struct {
+		name                    string
+		enabled                 bool
+		request                 *ProvisionRequest
+		putReaction             httpReaction
+		deleteReaction          httpReaction
+		expectedMethods         []string
+		expectedResponse        *ProvisionResponse
+		expectedMitigation      bool
+		expectedMitigationOK    bool
+		expectedMitigationAsync bool
+	}{
+		{
+			name:    "disabled",
+			enabled: false,
+			putReaction: httpReaction{
+				status: http.StatusInternalServerError,
+				body:   conventionalFailureResponseBody,
+			},
+			expectedMethods: []string{http.MethodPut},
+		},
+		{
+			name:    "success",
+			enabled: true,
+			putReaction: httpReaction{
+				status: http.StatusCreated,
+				body:   successProvisionResponseBody,
+			},
+			expectedMethods:  []string{http.MethodPut},
+			expectedResponse: successProvisionResponse(),
+		},
+		{
+			name:    "4xx does not require mitigation",
+			enabled: true,
+			putReaction: httpReaction{
+				status: http.StatusBadRequest,
+				body:   conventionalFailureResponseBody,
+			},
+			expectedMethods: []string{http.MethodPut},
+		},
+		{
+			name:    "5xx mitigated",
+			enabled: true,
+			putReaction: httpReaction{
+				status: http.StatusInternalServerError,
+				body:   conventionalFailureResponseBody,
+			},
+			deleteReaction: httpReaction{
+				status: http.StatusOK,
+				body:   `{}`,
+			},
+			expectedMethods:      []string{http.MethodPut, http.MethodDelete},
+			expectedMitigation:   true,
+			expectedMitigationOK: true,
+		},
+		{
+			name:    "201 with malformed response mitigated",
+			enabled: true,
+			putReaction: httpReaction{
+				status: http.StatusCreated,
+				body:   malformedResponse,
+			},
+			deleteReaction: httpReaction{
+				status: http.StatusGone,
+				body:   `{}`,
+			},
+			expectedMethods:      []string{http.MethodPut, http.MethodDelete},
+			expectedMitigation:   true,
+			expectedMitigationOK: true,
+		},
+		{
+			name:    "timeout mitigated asynchronously",
+			enabled: true,
+			request: defaultAsyncProvisionRequest(),
+			putReaction: httpReaction{
+				err: &url.Error{Op: http.MethodPut, URL: "https://example.com", Err: timeoutError{}},
+			},
+			deleteReaction: httpReaction{
+				status: http.StatusAccepted,
+				body:   `{"operation": "test-operation-key"}`,
+			},
+			expectedMethods:         []string{http.MethodPut, http.MethodDelete},
+			expectedMitigation:      true,
+			expectedMitigationOK:    true,
+			expectedMitigationAsync: true,
+		},
+		{
+			name:    "failed mitigation",
+			enabled: true,
+			putReaction: httpReaction{
+				status: http.StatusBadGateway,
+				body:   conventionalFailureResponseBody,
+			},
+			deleteReaction: httpReaction{
+				status: http.StatusInternalServerError,
+				body:   conventionalFailureResponseBody,
+			},
+			expectedMethods:    []string{http.MethodPut, http.MethodDelete},
+			expectedMitigation: true,
+		},
+	}
+
+	for _, tc := range cases {
+		if tc.request == nil {
+			tc.request = defaultProvisionRequest()
+		}
+
+		var methods []string
+		klient := newTestClient(t, tc.name, Version2_14(), false, httpChecks{}, httpReaction{})
+		klient.EnableOrphanMitigation = tc.enabled
+		klient.doRequestFunc = doOrphanMitigationHTTP(tc.putReaction, tc.deleteReaction, &methods)
+
+		response, err := klient.ProvisionInstance(tc.request)
+
+		checkOrphanMitigation(t, tc.name, methods, tc.expectedMethods, err, tc.expectedMitigation, tc.expectedMitigationOK, tc.expectedMitigationAsync)
+		if tc.expectedResponse != nil {
+			doResponseChecks(t, tc.name, response, err, tc.expectedResponse, "", nil)
+		}
+	}
+}
+
+func TestBindOrphanMitigation(t *testing.T) {
+	cases := []struct {
+		name                 string
+		putReaction          httpReaction
+		deleteReaction       httpReaction
+		expectedMethods      []string
+		expectedMitigation   bool
+		expectedMitigationOK bool
+	}{
+		{
+			name: "success",
+			putReaction: httpReaction{
+				status: http.StatusCreated,
+				body:   successBindResponseBody,
+			},
+			expectedMethods: []string{http.MethodPut},
+		},
+		{
+			name: "other 2xx mitigated",
+			putReaction: httpReaction{
+				status: http.StatusNoContent,
+			},
+			deleteReaction: httpReaction{
+				status: http.StatusOK,
+				body:   `{}`,
+			},
+			expectedMethods:      []string{http.MethodPut, http.MethodDelete},
+			expectedMitigation:   true,
+			expectedMitigationOK: true,
+		},
+		{
+			name: "408 mitigated",
+			putReaction: httpReaction{
+				status: http.StatusRequestTimeout,
+			},
+			deleteReaction: httpReaction{
+				status: http.StatusOK,
+				body:   `{}`,
+			},
+			expectedMethods:      []string{http.MethodPut, http.MethodDelete},
+			expectedMitigation:   true,
+			expectedMitigationOK: true,
+		},
+	}
+
+	for _, tc := range cases {
+		var methods []string
+		klient := newTestClient(t, tc.name, Version2_14(), false, httpChecks{}, httpReaction{})
+		klient.EnableOrphanMitigation = true
+		klient.doRequestFunc = doOrphanMitigationHTTP(tc.putReaction, tc.deleteReaction, &methods)
+
+		_, err := klient.Bind(defaultBindRequest())
+
+		checkOrphanMitigation(t, tc.name, methods, tc.expectedMethods, err, tc.expectedMitigation, tc.expectedMitigationOK, false)
+	}
+}
+
+func checkOrphanMitigation(t *testing.T, name string, methods, expectedMethods []string, err error, expectedMitigation, expectedMitigationOK, expectedMitigationAsync bool) {
+	if e, a := len(expectedMethods), len(methods); e != a {
+		t.Errorf("%v: unexpected requests; expected %v, got %v", name, expectedMethods, methods)
+		return
+	}
+	for i := range methods {
+		if e, a := expectedMethods[i], methods[i]; e != a {
+			t.Errorf("%v: unexpected request %d; expected %v, got %v", name, i, e, a)
+		}
+	}
+
+	mitigationErr, ok := IsOrphanMitigationError(err)
+	if e, a := expectedMitigation, ok; e != a {
+		t.Errorf("%v: unexpected orphan mitigation; expected %v, got %v (error: %v)", name, e, a, err)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	if e, a := expectedMitigationOK, mitigationErr.MitigationSucceeded; e != a {
+		t.Errorf("%v: unexpected MitigationSucceeded; expected %v, got %v", name, e, a)
+	}
+	if e, a := expectedMitigationAsync, mitigationErr.MitigationAsync; e != a {
+		t.Errorf("%v: unexpected MitigationAsync; expected %v, got %v", name, e, a)
+	}
+	if expectedMitigationOK != (mitigationErr.MitigationErr == nil) {
+		t.Errorf("%v: unexpected MitigationErr: %v", name, mitigationErr.MitigationErr)
+	}
+	if mitigationErr.Unwrap() == nil {
+		t.Errorf("%v: expected original error to be preserved", name)
+	}
+}
@@ -0,0 +1,12 @@
+module sigs.k8s.io/go-open-service-broker-client/v2/otel
+
+go 1.15
+
+require (
+	go.opentelemetry.io/otel v1.0.0
+	go.opentelemetry.io/otel/sdk v1.0.0
+	go.opentelemetry.io/otel/trace v1.0.0
+	sigs.k8s.io/go-open-service-broker-client/v2 v2.0.0-00010101000000-000000000000
+)
+
+replace sigs.k8s.io/go-open-service-broker-client/v2 => ../
@@ -0,0 +1,131 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package otel adapts an OpenTelemetry trace.TracerProvider to the v2.Tracer
+// interface, so that the spans of a client are exported with OpenTelemetry:
+//
+//	config.Tracer = otel.NewTracer(tracerProvider)
+//	client, err := v2.NewClient(config)
+//
+// It is a module of its own, so that the client does not depend on
+// OpenTelemetry.
+package otel
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+
+	v2 "sigs.k8s.io/go-open-service-broker-client/v2"
+)
+
+// InstrumentationName is the name of the OpenTelemetry tracer the spans of a
+// client are started with.
+const InstrumentationName = "sigs.k8s.io/go-open-service-broker-client/v2"
+
+// NewTracer returns a v2.Tracer that starts the spans of a client with a
+// tracer of the given TracerProvider.  The spans are client spans, with the
+// attributes the client sets, and the OpenTelemetry semantic convention
+// attributes for them: the broker name is also set as peer.service, and
+// http.status_code is shared with the client.  A span whose operation fails
+// has the error recorded on it, an error.type attribute and an error status;
+// a response status code alone does not set the status, since a 410 Gone is a
+// successful response to a delete.
+func NewTracer(provider trace.TracerProvider) v2.Tracer {
+	return &tracer{
+		tracer: provider.Tracer(InstrumentationName, trace.WithSchemaURL(semconv.SchemaURL)),
+	}
+}
+
+type tracer struct {
+	tracer trace.Tracer
+}
+
+// Start implements v2.Tracer.
+func (t *tracer) Start(ctx context.Context, name string) (context.Context, v2.Span) {
+	ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+	return ctx, &otelSpan{span: span}
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+// SetAttribute implements v2.Span.
+func (s *otelSpan) SetAttribute(key string, value interface{}) {
+	s.span.SetAttributes(keyValue(key, value))
+	if key == v2.AttributeBrokerName {
+		if name, ok := value.(string); ok && name != "" {
+			s.span.SetAttributes(semconv.PeerServiceKey.String(name))
+		}
+	}
+}
+
+// RecordError implements v2.Span.
+func (s *otelSpan) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetAttributes(errorTypeKey.String(errorType(err)))
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// End implements v2.Span.
+func (s *otelSpan) End() {
+	s.span.End()
+}
+
+// SpanContext implements v2.Span.
+func (s *otelSpan) SpanContext() v2.SpanContext {
+	spanContext := s.span.SpanContext()
+	return v2.SpanContext{
+		TraceID:    spanContext.TraceID(),
+		SpanID:     spanContext.SpanID(),
+		Sampled:    spanContext.IsSampled(),
+		TraceState: spanContext.TraceState().String(),
+	}
+}
+
+// errorTypeKey is the semantic convention attribute describing the class of
+// error an operation failed with.  It postdates the conventions of this
+// version of OpenTelemetry.
+const errorTypeKey = attribute.Key("error.type")
+
+// errorType returns the value of the error.type attribute for an error: the
+// status code of an HTTP error, and "_OTHER" otherwise, as the semantic
+// conventions specify for HTTP clients.
+func errorType(err error) string {
+	if httpErr, ok := v2.IsHTTPError(err); ok {
+		return strconv.Itoa(httpErr.StatusCode)
+	}
+	return "_OTHER"
+}
+
+// keyValue returns an attribute for a value set by the client, which is a
+// string or an int.
+func keyValue(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case int:
+		return attribute.Int(key, v)
+	case string:
+		return attribute.String(key, v)
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
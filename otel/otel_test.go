@@ -0,0 +1,121 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otel_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	v2 "sigs.k8s.io/go-open-service-broker-client/v2"
+	"sigs.k8s.io/go-open-service-broker-client/v2/fakeserver"
+	"sigs.k8s.io/go-open-service-broker-client/v2/otel"
+)
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	values := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		values[kv.Key] = kv.Value
+	}
+	return values
+}
+
+func TestTracer(t *testing.T) {
+	handler, err := fakeserver.NewHandler(fakeserver.Config{})
+	if err != nil {
+		t.Fatalf("unexpected error creating handler: %v", err)
+	}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	config := v2.DefaultClientConfiguration()
+	config.Name = "test-broker"
+	config.URL = server.URL
+	config.Tracer = otel.NewTracer(provider)
+	client, err := v2.NewClient(config)
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+
+	if _, err := client.ProvisionInstance(&v2.ProvisionRequest{
+		InstanceID:       "test-instance-id",
+		ServiceID:        "test-service-id",
+		PlanID:           "test-plan-id",
+		OrganizationGUID: "test-organization-guid",
+		SpaceGUID:        "test-space-guid",
+	}); err != nil {
+		t.Fatalf("provision: unexpected error: %v", err)
+	}
+	if _, err := client.GetInstance(&v2.GetInstanceRequest{InstanceID: "missing-instance-id"}); err == nil {
+		t.Fatal("get instance: expected an error")
+	}
+
+	spans := recorder.Ended()
+	if e, a := 2, len(spans); e != a {
+		t.Fatalf("expected %v spans, got %v", e, a)
+	}
+
+	provision := spans[0]
+	if e, a := string(v2.OperationProvisionInstance), provision.Name(); e != a {
+		t.Errorf("expected span name %v, got %v", e, a)
+	}
+	if e, a := trace.SpanKindClient, provision.SpanKind(); e != a {
+		t.Errorf("expected span kind %v, got %v", e, a)
+	}
+	if e, a := codes.Unset, provision.Status().Code; e != a {
+		t.Errorf("expected status %v, got %v", e, a)
+	}
+	values := attributes(provision)
+	for key, expected := range map[attribute.Key]attribute.Value{
+		v2.AttributeBrokerName:     attribute.StringValue("test-broker"),
+		"peer.service":             attribute.StringValue("test-broker"),
+		v2.AttributeInstanceID:     attribute.StringValue("test-instance-id"),
+		v2.AttributeServiceID:      attribute.StringValue("test-service-id"),
+		v2.AttributeHTTPStatusCode: attribute.IntValue(http.StatusCreated),
+	} {
+		if actual := values[key]; actual != expected {
+			t.Errorf("expected attribute %v to be %v, got %v", key, expected.Emit(), actual.Emit())
+		}
+	}
+
+	// The broker received the span in the traceparent header.
+	traceparent := handler.Requests()[0].Header.Get(v2.TraceparentHeader)
+	if !strings.Contains(traceparent, provision.SpanContext().TraceID().String()) {
+		t.Errorf("expected traceparent %q to carry trace ID %v", traceparent, provision.SpanContext().TraceID())
+	}
+
+	getInstance := spans[1]
+	if e, a := codes.Error, getInstance.Status().Code; e != a {
+		t.Errorf("expected status %v, got %v", e, a)
+	}
+	if e, a := attribute.StringValue("404"), attributes(getInstance)["error.type"]; e != a {
+		t.Errorf("expected error.type %v, got %v", e.Emit(), a.Emit())
+	}
+	if e, a := 1, len(getInstance.Events()); e != a {
+		t.Errorf("expected %v error event, got %v", e, a)
+	}
+}
@@ -17,13 +17,31 @@ limitations under the License.
 package v2
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 )
 
+// internal message body types
+
+// bindingLastOperationResponseBody is the body of a response to a poll of the
+// last operation of a binding, whose metadata is that of a binding rather
+// than of the instance metadata decoded into LastOperationResponse.Metadata.
+type bindingLastOperationResponseBody struct {
+	LastOperationResponse
+	Metadata *BindingMetadata `json:"metadata,omitempty"`
+}
+
 func (c *client) PollBindingLastOperation(r *BindingLastOperationRequest) (*LastOperationResponse, error) {
+	return c.PollBindingLastOperationWithContext(context.Background(), r)
+}
+
+func (c *client) PollBindingLastOperationWithContext(ctx context.Context, r *BindingLastOperationRequest) (_ *LastOperationResponse, err error) {
+	ctx, span := c.startSpan(ctx, OperationPollBindingLastOperation, spanIDs{instanceID: r.InstanceID, bindingID: r.BindingID, serviceID: stringValue(r.ServiceID), planID: stringValue(r.PlanID)})
+	defer func() { endSpan(span, err) }()
+
 	if err := c.validateClientVersionIsAtLeast(Version2_14()); err != nil {
 		return nil, AsyncBindingOperationsNotAllowedError{
 			reason: err.Error(),
@@ -49,7 +67,7 @@ func (c *client) PollBindingLastOperation(r *BindingLastOperationRequest) (*Last
 		params[VarKeyOperation] = opStr
 	}
 
-	response, err := c.prepareAndDo(http.MethodGet, fullURL, params, nil /* request body */, r.OriginatingIdentity)
+	response, err := c.prepareAndDo(ctx, OperationPollBindingLastOperation, http.MethodGet, fullURL, params, nil /* request body */, r.OriginatingIdentity, r.RequestIdentity)
 	if err != nil {
 		return nil, err
 	}
@@ -61,9 +79,14 @@ func (c *client) PollBindingLastOperation(r *BindingLastOperationRequest) (*Last
 
 	switch response.StatusCode {
 	case http.StatusOK:
-		userResponse := &LastOperationResponse{}
-		if err := c.unmarshalResponse(response, userResponse); err != nil {
-			return nil, HTTPStatusCodeError{StatusCode: response.StatusCode, ResponseError: err}
+		responseBodyObj := &bindingLastOperationResponseBody{}
+		if err := c.unmarshalResponse(response, responseBodyObj); err != nil {
+			return nil, unmarshalError(response, err)
+		}
+
+		userResponse := &responseBodyObj.LastOperationResponse
+		if c.APIVersion.AtLeast(Version2_15()) {
+			userResponse.BindingMetadata = responseBodyObj.Metadata
 		}
 
 		if c.EnableAlphaFeatures {
@@ -74,6 +97,7 @@ func (c *client) PollBindingLastOperation(r *BindingLastOperationRequest) (*Last
 			}
 		}
 
+		userResponse.RequestIdentity = requestIdentity(response)
 		return userResponse, nil
 	default:
 		return nil, c.handleFailureResponse(response)
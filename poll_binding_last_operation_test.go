@@ -32,6 +32,22 @@ func defaultBindingLastOperationRequest() *BindingLastOperationRequest {
 	}
 }
 
+const metadataBindingLastOperationResponseBody = `{
+  "state": "succeeded",
+  "description": "test description",
+  "metadata": {
+    "expires_at": "2019-12-31T23:59:59.0Z"
+  }
+}`
+
+func successBindingLastOperationResponseWithMetadata() *LastOperationResponse {
+	r := successLastOperationResponse()
+	r.BindingMetadata = &BindingMetadata{
+		ExpiresAt: "2019-12-31T23:59:59.0Z",
+	}
+	return r
+}
+
 func TestPollBindingLastOperation(t *testing.T) {
 	cases := []struct {
 		name                string
@@ -51,7 +67,11 @@ func TestPollBindingLastOperation(t *testing.T) {
 				status: http.StatusOK,
 				body:   successLastOperationResponseBody,
 			},
-			expectedResponse: successLastOperationResponse(),
+			expectedResponse: func() *LastOperationResponse {
+				response := successLastOperationResponse()
+				response.RequestIdentity = testRequestIdentity
+				return response
+			}(),
 		},
 		{
 			name: "op in progress",
@@ -59,7 +79,11 @@ func TestPollBindingLastOperation(t *testing.T) {
 				status: http.StatusOK,
 				body:   inProgressLastOperationResponseBody,
 			},
-			expectedResponse: inProgressLastOperationResponse(),
+			expectedResponse: func() *LastOperationResponse {
+				response := inProgressLastOperationResponse()
+				response.RequestIdentity = testRequestIdentity
+				return response
+			}(),
 		},
 		{
 			name: "op failed",
@@ -67,7 +91,11 @@ func TestPollBindingLastOperation(t *testing.T) {
 				status: http.StatusOK,
 				body:   failedLastOperationResponseBody,
 			},
-			expectedResponse: failedLastOperationResponse(),
+			expectedResponse: func() *LastOperationResponse {
+				response := failedLastOperationResponse()
+				response.RequestIdentity = testRequestIdentity
+				return response
+			}(),
 		},
 		{
 			name: "http error",
@@ -98,7 +126,7 @@ func TestPollBindingLastOperation(t *testing.T) {
 				status: http.StatusInternalServerError,
 				body:   conventionalFailureResponseBody,
 			},
-			expectedErr: testHTTPStatusCodeError(),
+			expectedErr: testHTTPStatusCodeErrorWithRequestIdentity(),
 		},
 		{
 			name: "op succeeded",
@@ -106,7 +134,11 @@ func TestPollBindingLastOperation(t *testing.T) {
 				status: http.StatusOK,
 				body:   successLastOperationResponseBody,
 			},
-			expectedResponse: successLastOperationResponse(),
+			expectedResponse: func() *LastOperationResponse {
+				response := successLastOperationResponse()
+				response.RequestIdentity = testRequestIdentity
+				return response
+			}(),
 		},
 		{
 			name:                "originating identity included",
@@ -116,7 +148,11 @@ func TestPollBindingLastOperation(t *testing.T) {
 				status: http.StatusOK,
 				body:   successLastOperationResponseBody,
 			},
-			expectedResponse: successLastOperationResponse(),
+			expectedResponse: func() *LastOperationResponse {
+				response := successLastOperationResponse()
+				response.RequestIdentity = testRequestIdentity
+				return response
+			}(),
 		},
 		{
 			name:                "originating identity excluded",
@@ -126,7 +162,11 @@ func TestPollBindingLastOperation(t *testing.T) {
 				status: http.StatusOK,
 				body:   successLastOperationResponseBody,
 			},
-			expectedResponse: successLastOperationResponse(),
+			expectedResponse: func() *LastOperationResponse {
+				response := successLastOperationResponse()
+				response.RequestIdentity = testRequestIdentity
+				return response
+			}(),
 		},
 		{
 			name:               "unsupported API version",
@@ -142,9 +182,10 @@ func TestPollBindingLastOperation(t *testing.T) {
 			},
 			enableAlpha: true,
 			expectedResponse: &LastOperationResponse{
-				State:       StateInProgress,
-				Description: strPtr("test description"),
-				PollDelay:   durationPtr(300 * time.Second),
+				State:           StateInProgress,
+				Description:     strPtr("test description"),
+				PollDelay:       durationPtr(300 * time.Second),
+				RequestIdentity: testRequestIdentity,
 			},
 		},
 		{
@@ -156,9 +197,10 @@ func TestPollBindingLastOperation(t *testing.T) {
 				header: map[string][]string{PollingDelayHeader: {"300"}},
 			},
 			expectedResponse: &LastOperationResponse{
-				State:       StateInProgress,
-				Description: strPtr("test description"),
-				PollDelay:   nil,
+				State:           StateInProgress,
+				Description:     strPtr("test description"),
+				PollDelay:       nil,
+				RequestIdentity: testRequestIdentity,
 			},
 		},
 		{
@@ -171,11 +213,34 @@ func TestPollBindingLastOperation(t *testing.T) {
 				header: map[string][]string{PollingDelayHeader: {"2021-01-23T23:12:00Z"}},
 			},
 			expectedResponse: &LastOperationResponse{
-				State:       StateInProgress,
-				Description: strPtr("test description"),
-				PollDelay:   nil,
+				State:           StateInProgress,
+				Description:     strPtr("test description"),
+				PollDelay:       nil,
+				RequestIdentity: testRequestIdentity,
 			},
 		},
+		{
+			name:       "metadata - 2.15",
+			APIVersion: Version2_15(),
+			httpReaction: httpReaction{
+				status: http.StatusOK,
+				body:   metadataBindingLastOperationResponseBody,
+			},
+			expectedResponse: func() *LastOperationResponse {
+				response := successBindingLastOperationResponseWithMetadata()
+				response.RequestIdentity = testRequestIdentity
+				return response
+			}(),
+		},
+		{
+			name:       "metadata not returned unless API version >= 2.15",
+			APIVersion: Version2_14(),
+			httpReaction: httpReaction{
+				status: http.StatusOK,
+				body:   metadataBindingLastOperationResponseBody,
+			},
+			expectedResponse: successLastOperationResponse(),
+		},
 	}
 
 	for _, tc := range cases {
@@ -17,6 +17,7 @@ limitations under the License.
 package v2
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -24,6 +25,13 @@ import (
 )
 
 func (c *client) PollLastOperation(r *LastOperationRequest) (*LastOperationResponse, error) {
+	return c.PollLastOperationWithContext(context.Background(), r)
+}
+
+func (c *client) PollLastOperationWithContext(ctx context.Context, r *LastOperationRequest) (_ *LastOperationResponse, err error) {
+	ctx, span := c.startSpan(ctx, OperationPollLastOperation, spanIDs{instanceID: r.InstanceID, serviceID: stringValue(r.ServiceID), planID: stringValue(r.PlanID)})
+	defer func() { endSpan(span, err) }()
+
 	if err := validateLastOperationRequest(r); err != nil {
 		return nil, err
 	}
@@ -43,7 +51,7 @@ func (c *client) PollLastOperation(r *LastOperationRequest) (*LastOperationRespo
 		params[VarKeyOperation] = opStr
 	}
 
-	response, err := c.prepareAndDo(http.MethodGet, fullURL, params, nil /* request body */, r.OriginatingIdentity)
+	response, err := c.prepareAndDo(ctx, OperationPollLastOperation, http.MethodGet, fullURL, params, nil /* request body */, r.OriginatingIdentity, r.RequestIdentity)
 	if err != nil {
 		return nil, err
 	}
@@ -57,7 +65,7 @@ func (c *client) PollLastOperation(r *LastOperationRequest) (*LastOperationRespo
 	case http.StatusOK:
 		userResponse := &LastOperationResponse{}
 		if err := c.unmarshalResponse(response, userResponse); err != nil {
-			return nil, HTTPStatusCodeError{StatusCode: response.StatusCode, ResponseError: err}
+			return nil, unmarshalError(response, err)
 		}
 
 		if c.EnableAlphaFeatures {
@@ -67,6 +75,11 @@ func (c *client) PollLastOperation(r *LastOperationRequest) (*LastOperationRespo
 			}
 		}
 
+		if c.APIVersion.IsLessThan(Version2_15()) {
+			userResponse.Metadata = nil
+		}
+
+		userResponse.RequestIdentity = requestIdentity(response)
 		return userResponse, nil
 	default:
 		return nil, c.handleFailureResponse(response)
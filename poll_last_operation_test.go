@@ -59,6 +59,21 @@ func failedLastOperationResponse() *LastOperationResponse {
 
 const failedLastOperationResponseBody = `{"state":"failed","description":"test description"}`
 
+const metadataLastOperationResponseBody = `{
+  "state": "succeeded",
+  "description": "test description",
+  "metadata": {
+    "labels": {"team": "data"},
+    "attributes": {"region": "eu-west-1"}
+  }
+}`
+
+func successLastOperationResponseWithMetadata() *LastOperationResponse {
+	r := successLastOperationResponse()
+	r.Metadata = testInstanceMetadata()
+	return r
+}
+
 func TestPollLastOperation(t *testing.T) {
 	cases := []struct {
 		name                string
@@ -178,9 +193,10 @@ func TestPollLastOperation(t *testing.T) {
 				header: map[string][]string{PollingDelayHeader: {"600"}},
 			},
 			expectedResponse: &LastOperationResponse{
-				State:       StateInProgress,
-				Description: strPtr("test description"),
-				PollDelay:   durationPtr(600 * time.Second),
+				State:           StateInProgress,
+				Description:     strPtr("test description"),
+				PollDelay:       durationPtr(600 * time.Second),
+				RequestIdentity: testRequestIdentity,
 			},
 		},
 		{
@@ -193,8 +209,9 @@ func TestPollLastOperation(t *testing.T) {
 				header: map[string][]string{PollingDelayHeader: {"600"}},
 			},
 			expectedResponse: &LastOperationResponse{
-				State:       StateInProgress,
-				Description: strPtr("test description"),
+				State:           StateInProgress,
+				Description:     strPtr("test description"),
+				RequestIdentity: testRequestIdentity,
 			},
 		},
 		{
@@ -207,9 +224,32 @@ func TestPollLastOperation(t *testing.T) {
 				header: map[string][]string{PollingDelayHeader: {"2020-12-31T23:59:60Z"}},
 			},
 			expectedResponse: &LastOperationResponse{
-				State:       StateInProgress,
-				Description: strPtr("test description"),
+				State:           StateInProgress,
+				Description:     strPtr("test description"),
+				RequestIdentity: testRequestIdentity,
+			},
+		},
+		{
+			name:    "metadata - 2.15",
+			version: Version2_15(),
+			httpReaction: httpReaction{
+				status: http.StatusOK,
+				body:   metadataLastOperationResponseBody,
 			},
+			expectedResponse: func() *LastOperationResponse {
+				response := successLastOperationResponseWithMetadata()
+				response.RequestIdentity = testRequestIdentity
+				return response
+			}(),
+		},
+		{
+			name:    "metadata not returned unless API version >= 2.15",
+			version: Version2_14(),
+			httpReaction: httpReaction{
+				status: http.StatusOK,
+				body:   metadataLastOperationResponseBody,
+			},
+			expectedResponse: successLastOperationResponse(),
 		},
 	}
 
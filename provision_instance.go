@@ -17,10 +17,9 @@ limitations under the License.
 package v2
 
 import (
+	"context"
 	"fmt"
 	"net/http"
-
-	"k8s.io/klog/v2"
 )
 
 // internal message body types
@@ -32,17 +31,40 @@ type provisionRequestBody struct {
 	SpaceGUID        string                 `json:"space_guid"`
 	Parameters       map[string]interface{} `json:"parameters,omitempty"`
 	Context          map[string]interface{} `json:"context,omitempty"`
+	MaintenanceInfo  *MaintenanceInfo       `json:"maintenance_info,omitempty"`
 }
 
 type provisionSuccessResponseBody struct {
-	DashboardURL *string `json:"dashboard_url"`
-	Operation    *string `json:"operation"`
+	DashboardURL *string           `json:"dashboard_url"`
+	Operation    *string           `json:"operation"`
+	Metadata     *InstanceMetadata `json:"metadata"`
 }
 
 func (c *client) ProvisionInstance(r *ProvisionRequest) (*ProvisionResponse, error) {
+	return c.ProvisionInstanceWithContext(context.Background(), r)
+}
+
+func (c *client) ProvisionInstanceWithContext(ctx context.Context, r *ProvisionRequest) (_ *ProvisionResponse, err error) {
+	ctx, span := c.startSpan(ctx, OperationProvisionInstance, spanIDs{instanceID: r.InstanceID, serviceID: r.ServiceID, planID: r.PlanID})
+	defer func() { endSpan(span, err) }()
+
+	response, err := c.provisionInstance(ctx, r)
+	if err != nil && c.EnableOrphanMitigation && isOrphanMitigationRequired(err) {
+		return nil, c.mitigateOrphanedInstance(r, err)
+	}
+
+	return response, err
+}
+
+func (c *client) provisionInstance(ctx context.Context, r *ProvisionRequest) (*ProvisionResponse, error) {
 	if err := validateProvisionRequest(r); err != nil {
 		return nil, err
 	}
+	if c.RequestValidator != nil {
+		if err := c.RequestValidator.ValidateProvisionRequest(r); err != nil {
+			return nil, err
+		}
+	}
 
 	fullURL := fmt.Sprintf(serviceInstanceURLFmt, c.URL, r.InstanceID)
 
@@ -63,7 +85,11 @@ func (c *client) ProvisionInstance(r *ProvisionRequest) (*ProvisionResponse, err
 		requestBody.Context = r.Context
 	}
 
-	response, err := c.prepareAndDo(http.MethodPut, fullURL, params, requestBody, r.OriginatingIdentity)
+	if c.maintenanceInfoAllowed() {
+		requestBody.MaintenanceInfo = r.MaintenanceInfo
+	}
+
+	response, err := c.prepareAndDo(ctx, OperationProvisionInstance, http.MethodPut, fullURL, params, requestBody, r.OriginatingIdentity, r.RequestIdentity)
 	if err != nil {
 		return nil, err
 	}
@@ -77,9 +103,14 @@ func (c *client) ProvisionInstance(r *ProvisionRequest) (*ProvisionResponse, err
 	case http.StatusCreated, http.StatusOK:
 		userResponse := &ProvisionResponse{}
 		if err := c.unmarshalResponse(response, userResponse); err != nil {
-			return nil, HTTPStatusCodeError{StatusCode: response.StatusCode, ResponseError: err}
+			return nil, unmarshalError(response, err)
 		}
 
+		if c.APIVersion.IsLessThan(Version2_15()) {
+			userResponse.Metadata = nil
+		}
+
+		userResponse.RequestIdentity = requestIdentity(response)
 		return userResponse, nil
 	case http.StatusAccepted:
 		if !r.AcceptsIncomplete {
@@ -90,7 +121,7 @@ func (c *client) ProvisionInstance(r *ProvisionRequest) (*ProvisionResponse, err
 
 		responseBodyObj := &provisionSuccessResponseBody{}
 		if err := c.unmarshalResponse(response, responseBodyObj); err != nil {
-			return nil, HTTPStatusCodeError{StatusCode: response.StatusCode, ResponseError: err}
+			return nil, unmarshalError(response, err)
 		}
 
 		var opPtr *OperationKey
@@ -105,11 +136,13 @@ func (c *client) ProvisionInstance(r *ProvisionRequest) (*ProvisionResponse, err
 			DashboardURL: responseBodyObj.DashboardURL,
 			OperationKey: opPtr,
 		}
-
-		if c.Verbose {
-			klog.Infof("broker %q: received asynchronous response", c.Name)
+		if c.APIVersion.AtLeast(Version2_15()) {
+			userResponse.Metadata = responseBodyObj.Metadata
 		}
 
+		c.logAt(LogLevelRequests).Info("received asynchronous response")
+
+		userResponse.RequestIdentity = requestIdentity(response)
 		return userResponse, nil
 	default:
 		return nil, c.handleFailureResponse(response)
@@ -73,8 +73,54 @@ func successProvisionResponseAsync() *ProvisionResponse {
 	return r
 }
 
+const metadataProvisionResponseBody = `{
+  "dashboard_url": "https://example.com/dashboard",
+  "metadata": {
+    "labels": {"team": "data"},
+    "attributes": {"region": "eu-west-1"}
+  }
+}`
+
+const metadataAsyncProvisionResponseBody = `{
+  "dashboard_url": "https://example.com/dashboard",
+  "operation": "test-operation-key",
+  "metadata": {
+    "labels": {"team": "data"},
+    "attributes": {"region": "eu-west-1"}
+  }
+}`
+
+func testInstanceMetadata() *InstanceMetadata {
+	return &InstanceMetadata{
+		Labels:     map[string]interface{}{"team": "data"},
+		Attributes: map[string]interface{}{"region": "eu-west-1"},
+	}
+}
+
+func successProvisionResponseWithMetadata() *ProvisionResponse {
+	r := successProvisionResponse()
+	r.Metadata = testInstanceMetadata()
+	return r
+}
+
+func successProvisionResponseAsyncWithMetadata() *ProvisionResponse {
+	r := successProvisionResponseAsync()
+	r.Metadata = testInstanceMetadata()
+	return r
+}
+
 const contextProvisionRequestBody = `{"service_id":"test-service-id","plan_id":"test-plan-id","organization_guid":"test-organization-guid","space_guid":"test-space-guid","context":{"foo":"bar"}}`
 
+const maintenanceInfoProvisionRequestBody = `{"service_id":"test-service-id","plan_id":"test-plan-id","organization_guid":"test-organization-guid","space_guid":"test-space-guid","maintenance_info":{"version":"1.2.3"}}`
+
+func maintenanceInfoProvisionRequest() *ProvisionRequest {
+	r := defaultProvisionRequest()
+	r.MaintenanceInfo = &MaintenanceInfo{
+		Version: "1.2.3",
+	}
+	return r
+}
+
 func TestProvisionInstance(t *testing.T) {
 	cases := []struct {
 		name                string
@@ -236,6 +282,114 @@ func TestProvisionInstance(t *testing.T) {
 			},
 			expectedResponse: successProvisionResponse(),
 		},
+		{
+			name:    "maintenance info - 2.15",
+			version: Version2_15(),
+			request: maintenanceInfoProvisionRequest(),
+			httpChecks: httpChecks{
+				body: maintenanceInfoProvisionRequestBody,
+			},
+			httpReaction: httpReaction{
+				status: http.StatusCreated,
+				body:   successProvisionResponseBody,
+			},
+			expectedResponse: func() *ProvisionResponse {
+				response := successProvisionResponse()
+				response.RequestIdentity = testRequestIdentity
+				return response
+			}(),
+		},
+		{
+			name:        "maintenance info - alpha",
+			version:     Version2_14(),
+			enableAlpha: true,
+			request:     maintenanceInfoProvisionRequest(),
+			httpChecks: httpChecks{
+				body: maintenanceInfoProvisionRequestBody,
+			},
+			httpReaction: httpReaction{
+				status: http.StatusCreated,
+				body:   successProvisionResponseBody,
+			},
+			expectedResponse: successProvisionResponse(),
+		},
+		{
+			name:    "maintenance info not sent unless API version >= 2.15 or alpha",
+			version: Version2_14(),
+			request: maintenanceInfoProvisionRequest(),
+			httpChecks: httpChecks{
+				body: successProvisionRequestBody,
+			},
+			httpReaction: httpReaction{
+				status: http.StatusCreated,
+				body:   successProvisionResponseBody,
+			},
+			expectedResponse: successProvisionResponse(),
+		},
+		{
+			name:    "maintenance info conflict",
+			version: Version2_15(),
+			request: maintenanceInfoProvisionRequest(),
+			httpChecks: httpChecks{
+				body: maintenanceInfoProvisionRequestBody,
+			},
+			httpReaction: httpReaction{
+				status: http.StatusUnprocessableEntity,
+				body:   `{"error":"MaintenanceInfoConflict","description":"maintenance info mismatch"}`,
+			},
+			expectedErr: HTTPStatusCodeError{
+				StatusCode:      http.StatusUnprocessableEntity,
+				ErrorMessage:    strPtr(MaintenanceInfoConflictErrorMessage),
+				Description:     strPtr("maintenance info mismatch"),
+				RequestIdentity: testRequestIdentity,
+			},
+		},
+		{
+			name:    "metadata - 2.15",
+			version: Version2_15(),
+			httpReaction: httpReaction{
+				status: http.StatusCreated,
+				body:   metadataProvisionResponseBody,
+			},
+			expectedResponse: func() *ProvisionResponse {
+				response := successProvisionResponseWithMetadata()
+				response.RequestIdentity = testRequestIdentity
+				return response
+			}(),
+		},
+		{
+			name:    "metadata - 2.15 asynchronous",
+			version: Version2_15(),
+			request: defaultAsyncProvisionRequest(),
+			httpReaction: httpReaction{
+				status: http.StatusAccepted,
+				body:   metadataAsyncProvisionResponseBody,
+			},
+			expectedResponse: func() *ProvisionResponse {
+				response := successProvisionResponseAsyncWithMetadata()
+				response.RequestIdentity = testRequestIdentity
+				return response
+			}(),
+		},
+		{
+			name:    "metadata not returned unless API version >= 2.15",
+			version: Version2_14(),
+			httpReaction: httpReaction{
+				status: http.StatusCreated,
+				body:   metadataProvisionResponseBody,
+			},
+			expectedResponse: successProvisionResponse(),
+		},
+		{
+			name:    "metadata not returned unless API version >= 2.15 - asynchronous",
+			version: Version2_14(),
+			request: defaultAsyncProvisionRequest(),
+			httpReaction: httpReaction{
+				status: http.StatusAccepted,
+				body:   metadataAsyncProvisionResponseBody,
+			},
+			expectedResponse: successProvisionResponseAsync(),
+		},
 	}
 
 	for _, tc := range cases {
@@ -0,0 +1,405 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package recorder contains an http.RoundTripper that records the traffic
+// between a client and a broker to a cassette file, and replays it later
+// without the broker.  Set it as the Transport of a v2.ClientConfiguration:
+//
+//	rec, err := recorder.New(recorder.Config{
+//		Mode: recorder.ModeReplay,
+//		Path: "testdata/broker.json",
+//	})
+//	config.Transport = rec
+//	client, err := v2.NewClient(config)
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	v2 "sigs.k8s.io/go-open-service-broker-client/v2"
+)
+
+// Mode is the mode of a Recorder.
+type Mode int
+
+const (
+	// ModeRecord sends requests to the broker and records each request and
+	// response to the cassette.
+	ModeRecord Mode = iota
+	// ModeReplay serves responses from the cassette without contacting the
+	// broker.
+	ModeReplay
+)
+
+// RedactedValue replaces the values of redacted headers and body fields in a
+// cassette.
+const RedactedValue = "REDACTED"
+
+// defaultRedactedHeaders are the headers that are always redacted.  The
+// originating identity header carries the identity of the platform user, such
+// as a user name or email address.
+var defaultRedactedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	v2.OriginatingIdentityHeader,
+}
+
+// defaultRedactedFields are the JSON body fields that are always redacted:
+// the credentials of bindings, the secrets of dashboard clients in catalogs,
+// and passwords.
+var defaultRedactedFields = []string{
+	"credentials",
+	"secret",
+	"password",
+}
+
+// Config is the configuration of a Recorder.
+type Config struct {
+	// Mode is whether the Recorder records or replays.
+	Mode Mode
+	// Path is the path of the cassette file.  In ModeRecord it is
+	// overwritten; in ModeReplay it must exist.
+	Path string
+	// Transport is the http.RoundTripper used to send requests in
+	// ModeRecord.  Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+	// RedactHeaders are headers whose values are replaced with RedactedValue
+	// in the cassette, in addition to Authorization, Proxy-Authorization,
+	// Cookie, Set-Cookie and X-Broker-API-Originating-Identity.
+	RedactHeaders []string
+	// RedactFields are the names of fields of JSON request and response
+	// bodies whose values are redacted in the cassette, in addition to
+	// credentials, secret and password.  Fields are matched at any depth and
+	// regardless of case.  Every string, number and boolean in the value of
+	// a redacted field is replaced with RedactedValue, keeping the shape of
+	// objects and arrays so that replayed responses still decode.  Bodies
+	// that are not JSON are recorded verbatim.
+	RedactFields []string
+}
+
+// Cassette is the content of a cassette file.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and the response the broker sent.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded request.
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Recorder is an http.RoundTripper that records or replays broker traffic.
+// In ModeRecord it writes the cassette after every request, so the cassette
+// is complete even if the process exits without cleaning up.  In ModeReplay
+// a request is answered by the first interaction not yet replayed with the
+// same method, URL and body; a request that matches none fails.  Recorder is
+// threadsafe.
+type Recorder struct {
+	mode         Mode
+	path         string
+	transport    http.RoundTripper
+	redact       map[string]bool
+	redactFields map[string]bool // lower-cased field names
+
+	mu       sync.Mutex
+	cassette Cassette
+	replayed []bool
+}
+
+var _ http.RoundTripper = &Recorder{}
+
+// New returns a new Recorder with the given Config.  In ModeReplay, it loads
+// the cassette from Config.Path.
+func New(config Config) (*Recorder, error) {
+	if config.Path == "" {
+		return nil, errors.New("recorder: a cassette path is required")
+	}
+
+	r := &Recorder{
+		mode:         config.Mode,
+		path:         config.Path,
+		transport:    config.Transport,
+		redact:       map[string]bool{},
+		redactFields: map[string]bool{},
+	}
+	if r.transport == nil {
+		r.transport = http.DefaultTransport
+	}
+	for _, header := range append(defaultRedactedHeaders, config.RedactHeaders...) {
+		r.redact[http.CanonicalHeaderKey(header)] = true
+	}
+	for _, field := range append(defaultRedactedFields, config.RedactFields...) {
+		r.redactFields[strings.ToLower(field)] = true
+	}
+
+	switch config.Mode {
+	case ModeRecord:
+	case ModeReplay:
+		data, err := ioutil.ReadFile(config.Path)
+		if err != nil {
+			return nil, fmt.Errorf("recorder: reading cassette: %v", err)
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("recorder: parsing cassette %s: %v", config.Path, err)
+		}
+		r.replayed = make([]bool, len(r.cassette.Interactions))
+	default:
+		return nil, fmt.Errorf("recorder: unknown mode %d", config.Mode)
+	}
+
+	return r, nil
+}
+
+// RoundTrip implements http.RoundTripper.  It consumes and closes the body of
+// the request but does not otherwise modify it: in ModeRecord, a copy of the
+// request is sent.
+func (r *Recorder) RoundTrip(request *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(request, body)
+	}
+	return r.record(request, body)
+}
+
+func (r *Recorder) record(request *http.Request, body []byte) (*http.Response, error) {
+	outgoing := request.Clone(request.Context())
+	if body != nil {
+		outgoing.Body = ioutil.NopCloser(bytes.NewReader(body))
+		outgoing.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	response, err := r.transport.RoundTrip(outgoing)
+	if err != nil {
+		return nil, err
+	}
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+
+	interaction := Interaction{
+		Request: Request{
+			Method: request.Method,
+			URL:    request.URL.String(),
+			Header: r.redactHeader(request.Header),
+			Body:   r.redactBody(body),
+		},
+		Response: Response{
+			StatusCode: response.StatusCode,
+			Header:     r.redactHeader(response.Header),
+			Body:       r.redactBody(responseBody),
+		},
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(r.path, data, 0644); err != nil {
+		return nil, fmt.Errorf("recorder: writing cassette: %v", err)
+	}
+
+	return response, nil
+}
+
+func (r *Recorder) replay(request *http.Request, body []byte) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Recorded request bodies are redacted, so the body of the request is
+	// redacted the same way before it is compared with them.
+	url := request.URL.String()
+	redactedBody := r.redactBody(body)
+	for i, interaction := range r.cassette.Interactions {
+		if r.replayed[i] {
+			continue
+		}
+		if interaction.Request.Method != request.Method || interaction.Request.URL != url || interaction.Request.Body != redactedBody {
+			continue
+		}
+
+		r.replayed[i] = true
+		header := interaction.Response.Header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       request,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("recorder: no recorded interaction matches %s %s", request.Method, url)
+}
+
+// Unreplayed returns the interactions in the cassette that have not been
+// replayed.  It is useful for checking that a test made every request that
+// was recorded.
+func (r *Recorder) Unreplayed() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var interactions []Interaction
+	for i, interaction := range r.cassette.Interactions {
+		if !r.replayed[i] {
+			interactions = append(interactions, interaction)
+		}
+	}
+	return interactions
+}
+
+func (r *Recorder) redactHeader(header http.Header) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+
+	redacted := header.Clone()
+	for key := range redacted {
+		if r.redact[http.CanonicalHeaderKey(key)] {
+			redacted[key] = []string{RedactedValue}
+		}
+	}
+	return redacted
+}
+
+// redactBody returns the body to record for the given request or response
+// body.  If the body is JSON with redacted fields, it is re-encoded with
+// their values redacted; otherwise it is returned verbatim.
+func (r *Recorder) redactBody(body []byte) string {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return string(body)
+	}
+
+	redacted, changed := r.redactFieldsIn(value)
+	if !changed {
+		return string(body)
+	}
+	data, err := json.Marshal(redacted)
+	if err != nil {
+		return string(body)
+	}
+	return string(data)
+}
+
+// redactFieldsIn returns the given decoded JSON value with the values of
+// redacted fields redacted, and whether any were.
+func (r *Recorder) redactFieldsIn(value interface{}) (interface{}, bool) {
+	changed := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if r.redactFields[strings.ToLower(key)] {
+				v[key] = redactValue(field)
+				changed = true
+				continue
+			}
+			var fieldChanged bool
+			v[key], fieldChanged = r.redactFieldsIn(field)
+			changed = changed || fieldChanged
+		}
+	case []interface{}:
+		for i, element := range v {
+			var elementChanged bool
+			v[i], elementChanged = r.redactFieldsIn(element)
+			changed = changed || elementChanged
+		}
+	}
+	return value, changed
+}
+
+// redactValue returns the given decoded JSON value with every string, number
+// and boolean in it replaced with RedactedValue.
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			v[key] = redactValue(field)
+		}
+		return v
+	case []interface{}:
+		for i, element := range v {
+			v[i] = redactValue(element)
+		}
+		return v
+	case nil:
+		return nil
+	default:
+		return RedactedValue
+	}
+}
+
+// readRequestBody reads and closes the body of the request.  The request
+// itself is left unchanged, as the http.RoundTripper contract requires.
+func readRequestBody(request *http.Request) ([]byte, error) {
+	if request.Body == nil || request.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	request.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	return body, nil
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+// newRequestIdentity returns a random (version 4) UUID to identify a request
+// that was made without a RequestIdentity.
+func newRequestIdentity() (string, error) {
+	uuid := make([]byte, 16)
+	if _, err := rand.Read(uuid); err != nil {
+		return "", fmt.Errorf("error generating request identity: %v", err)
+	}
+	// Set the version (4) and variant (RFC 4122) bits.
+	uuid[6] = uuid[6]&^0xf0 | 0x40
+	uuid[8] = uuid[8]&^0xc0 | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:]), nil
+}
+
+// generateRequestIdentity returns the identity of a request made without a
+// RequestIdentity.
+func (c *client) generateRequestIdentity() (string, error) {
+	if c.requestIdentityFunc != nil {
+		return c.requestIdentityFunc()
+	}
+	return newRequestIdentity()
+}
+
+// requestIdentity returns the request identity reported for the given
+// response: the one the broker echoed in the response header, which is what
+// the broker logged the request under, or, if the broker did not echo one,
+// the one the client sent, so that the request can still be found in the
+// platform's logs.
+func requestIdentity(response *http.Response) string {
+	if echoed := response.Header.Get(RequestIdentityHeader); echoed != "" {
+		return echoed
+	}
+	if response.Request == nil {
+		return ""
+	}
+	return response.Request.Header.Get(RequestIdentityHeader)
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestRequestIdentity(t *testing.T) {
+	cases := []struct {
+		name            string
+		version         APIVersion
+		requestIdentity string
+		echoed          string
+		status          int
+		expectGenerated bool
+		expectedHeader  string
+		expected        string
+	}{
+		{
+			name:            "given identity",
+			version:         Version2_15(),
+			requestIdentity: "test-request-identity",
+			status:          http.StatusOK,
+			expectedHeader:  "test-request-identity",
+			expected:        "test-request-identity",
+		},
+		{
+			name:            "generated identity",
+			version:         Version2_15(),
+			status:          http.StatusOK,
+			expectGenerated: true,
+		},
+		{
+			name:            "given identity on error",
+			version:         Version2_15(),
+			requestIdentity: "test-request-identity",
+			status:          http.StatusInternalServerError,
+			expectedHeader:  "test-request-identity",
+			expected:        "test-request-identity",
+		},
+		{
+			name:            "echoed identity",
+			version:         Version2_15(),
+			requestIdentity: "test-request-identity",
+			echoed:          "broker-request-identity",
+			status:          http.StatusOK,
+			expectedHeader:  "test-request-identity",
+			expected:        "broker-request-identity",
+		},
+		{
+			name:            "echoed identity on error",
+			version:         Version2_15(),
+			requestIdentity: "test-request-identity",
+			echoed:          "broker-request-identity",
+			status:          http.StatusInternalServerError,
+			expectedHeader:  "test-request-identity",
+			expected:        "broker-request-identity",
+		},
+		{
+			name:            "not sent before 2.15",
+			version:         Version2_14(),
+			requestIdentity: "test-request-identity",
+			status:          http.StatusOK,
+		},
+	}
+
+	for _, tc := range cases {
+		var header string
+		klient := newTestClient(t, tc.name, tc.version, false, httpChecks{}, httpReaction{})
+		klient.requestIdentityFunc = nil
+		klient.doRequestFunc = func(request *http.Request) (*http.Response, error) {
+			header = request.Header.Get(RequestIdentityHeader)
+			response := &http.Response{
+				StatusCode: tc.status,
+				Header:     http.Header{},
+				Body:       closer(`{}`),
+				Request:    request,
+			}
+			if tc.echoed != "" {
+				response.Header.Set(RequestIdentityHeader, tc.echoed)
+			}
+			return response, nil
+		}
+
+		request := defaultGetInstanceRequest()
+		request.RequestIdentity = tc.requestIdentity
+		response, err := klient.GetInstance(request)
+
+		expected := tc.expected
+		if tc.expectGenerated {
+			if !uuidPattern.MatchString(header) {
+				t.Errorf("%v: expected a generated UUID request identity, got %q", tc.name, header)
+			}
+			expected = header
+		} else if e, a := tc.expectedHeader, header; e != a {
+			t.Errorf("%v: unexpected request identity header; expected %q, got %q", tc.name, e, a)
+		}
+
+		var requestIdentity string
+		if tc.status == http.StatusOK {
+			if err != nil {
+				t.Fatalf("%v: unexpected error: %v", tc.name, err)
+			}
+			requestIdentity = response.RequestIdentity
+		} else {
+			httpErr, ok := IsHTTPError(err)
+			if !ok {
+				t.Fatalf("%v: expected an HTTP status code error, got %v", tc.name, err)
+			}
+			requestIdentity = httpErr.RequestIdentity
+		}
+		if e, a := expected, requestIdentity; e != a {
+			t.Errorf("%v: unexpected request identity; expected %q, got %q", tc.name, e, a)
+		}
+	}
+}
+
+func TestNewRequestIdentity(t *testing.T) {
+	first, err := newRequestIdentity()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := newRequestIdentity()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, identity := range []string{first, second} {
+		if !uuidPattern.MatchString(identity) {
+			t.Errorf("expected a version 4 UUID, got %q", identity)
+		}
+	}
+	if first == second {
+		t.Errorf("expected distinct request identities, got %q twice", first)
+	}
+}
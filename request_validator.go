@@ -0,0 +1,28 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+// RequestValidator validates provision, update and bind requests before a
+// client sends them to the broker, such as a schema.ParametersValidator
+// validating their parameters against the schemas of their plans.  A request
+// that fails validation is not sent, and the client returns the error of the
+// validator.  The methods may be called concurrently.
+type RequestValidator interface {
+	ValidateProvisionRequest(r *ProvisionRequest) error
+	ValidateUpdateInstanceRequest(r *UpdateInstanceRequest) error
+	ValidateBindRequest(r *BindRequest) error
+}
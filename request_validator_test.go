@@ -0,0 +1,100 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// testRequestValidator is a RequestValidator that records the requests it
+// validates and returns err for each of them.
+type testRequestValidator struct {
+	err       error
+	validated []interface{}
+}
+
+func (v *testRequestValidator) ValidateProvisionRequest(r *ProvisionRequest) error {
+	v.validated = append(v.validated, r)
+	return v.err
+}
+
+func (v *testRequestValidator) ValidateUpdateInstanceRequest(r *UpdateInstanceRequest) error {
+	v.validated = append(v.validated, r)
+	return v.err
+}
+
+func (v *testRequestValidator) ValidateBindRequest(r *BindRequest) error {
+	v.validated = append(v.validated, r)
+	return v.err
+}
+
+func TestRequestValidator(t *testing.T) {
+	validationErr := errors.New("invalid parameters")
+
+	cases := []struct {
+		name string
+		err  error
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name: "invalid",
+			err:  validationErr,
+		},
+	}
+
+	for _, tc := range cases {
+		validator := &testRequestValidator{err: tc.err}
+		klient := newTestClient(t, tc.name, Version2_14(), false, httpChecks{}, httpReaction{})
+		klient.RequestValidator = validator
+		sent := 0
+		klient.doRequestFunc = func(request *http.Request) (*http.Response, error) {
+			sent++
+			return &http.Response{StatusCode: http.StatusOK, Body: closer(`{}`)}, nil
+		}
+
+		provisionRequest := defaultProvisionRequest()
+		updateRequest := defaultUpdateInstanceRequest()
+		bindRequest := defaultBindRequest()
+		_, provisionErr := klient.ProvisionInstance(provisionRequest)
+		_, updateErr := klient.UpdateInstance(updateRequest)
+		_, bindErr := klient.Bind(bindRequest)
+
+		for _, err := range []error{provisionErr, updateErr, bindErr} {
+			if e, a := tc.err, err; e != a {
+				t.Errorf("%v: unexpected error; expected %v, got %v", tc.name, e, a)
+			}
+		}
+		if e, a := 3, len(validator.validated); e != a {
+			t.Fatalf("%v: expected %v validated requests, got %v", tc.name, e, a)
+		}
+		if validator.validated[0] != provisionRequest || validator.validated[1] != updateRequest || validator.validated[2] != bindRequest {
+			t.Errorf("%v: expected the requests to be validated, got %v", tc.name, validator.validated)
+		}
+
+		expectedSent := 3
+		if tc.err != nil {
+			expectedSent = 0
+		}
+		if e, a := expectedSent, sent; e != a {
+			t.Errorf("%v: expected %v requests to be sent, got %v", tc.name, e, a)
+		}
+	}
+}
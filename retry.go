@@ -0,0 +1,178 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// doWithRetry executes the given request with doRequestFunc, retrying it
+// according to the client's RetryPolicy.  The response or error of the last
+// attempt is returned.
+func (c *client) doWithRetry(request *http.Request) (*http.Response, error) {
+	policy := c.RetryPolicy
+	if policy == nil || policy.MaxAttempts < 2 {
+		return c.doAttempt(request, 1)
+	}
+
+	ctx := request.Context()
+	for attempt := 1; ; attempt++ {
+		attemptRequest, written, err := newAttemptRequest(request)
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := c.doAttempt(attemptRequest, attempt)
+		if attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			return response, err
+		}
+
+		var retry bool
+		var delay time.Duration
+		if err != nil {
+			retry = request.Method == http.MethodGet || atomic.LoadInt32(written) == 0
+			delay = policy.backoff(attempt)
+		} else {
+			retry, delay = policy.retryResponse(request.Method, response, attempt)
+		}
+		if !retry {
+			return response, err
+		}
+
+		retryAttempt := RetryAttempt{
+			Method:  request.Method,
+			URL:     request.URL.String(),
+			Attempt: attempt,
+			Err:     err,
+			Delay:   delay,
+		}
+		if response != nil {
+			retryAttempt.StatusCode = response.StatusCode
+			_ = drainReader(response.Body)
+			response.Body.Close()
+		}
+
+		c.logWarning("request attempt failed; retrying",
+			"attempt", attempt, "maxAttempts", policy.MaxAttempts, "method", request.Method, "url", retryAttempt.URL,
+			"status", retryAttempt.StatusCode, "error", err, "delay", delay)
+		if policy.OnRetry != nil {
+			policy.OnRetry(retryAttempt)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// newAttemptRequest returns a copy of request with a fresh body, along with a
+// flag that is set once the request headers have been written to the broker.
+func newAttemptRequest(request *http.Request) (*http.Request, *int32, error) {
+	written := new(int32)
+	trace := &httptrace.ClientTrace{
+		WroteHeaders: func() {
+			atomic.StoreInt32(written, 1)
+		},
+	}
+
+	attemptRequest := request.Clone(httptrace.WithClientTrace(request.Context(), trace))
+	if request.GetBody != nil {
+		body, err := request.GetBody()
+		if err != nil {
+			return nil, nil, err
+		}
+		attemptRequest.Body = body
+	}
+
+	return attemptRequest, written, nil
+}
+
+// retryResponse returns whether a request that received the given response
+// should be retried, and the delay before the next attempt.
+func (p *RetryPolicy) retryResponse(method string, response *http.Response, attempt int) (bool, time.Duration) {
+	if method != http.MethodGet {
+		return false, 0
+	}
+
+	switch response.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		if delay, ok := parseRetryAfter(response.Header.Get(PollingDelayHeader)); ok {
+			if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+				return false, 0
+			}
+			return true, delay
+		}
+		return true, p.backoff(attempt)
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return true, p.backoff(attempt)
+	default:
+		return false, 0
+	}
+}
+
+// backoff returns the delay to wait after the given failed attempt.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (2*rand.Float64() - 1)
+	}
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+
+	return time.Duration(delay)
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either
+// a number of seconds or an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,353 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptrace"
+	"testing"
+	"time"
+)
+
+// attemptReaction is the outcome of a single attempt in a retry test.
+type attemptReaction struct {
+	status       int
+	header       http.Header
+	err          error
+	wroteHeaders bool
+}
+
+func testRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     10 * time.Millisecond,
+		Multiplier:     2,
+	}
+}
+
+// doAttempts returns a doRequestFunc that replies to successive attempts with
+// the given reactions and records the request body of each attempt.
+func doAttempts(t *testing.T, name string, reactions []attemptReaction, bodies *[]string) doRequestFunc {
+	attempt := 0
+	return func(request *http.Request) (*http.Response, error) {
+		if attempt >= len(reactions) {
+			t.Errorf("%v: unexpected attempt %d", name, attempt+1)
+			return nil, errWalkingGhost
+		}
+		reaction := reactions[attempt]
+		attempt++
+
+		body := ""
+		if request.Body != nil {
+			bodyBytes, err := ioutil.ReadAll(request.Body)
+			if err != nil {
+				t.Errorf("%v: error reading request body: %v", name, err)
+				return nil, errWalkingGhost
+			}
+			body = string(bodyBytes)
+		}
+		*bodies = append(*bodies, body)
+
+		if reaction.wroteHeaders {
+			if trace := httptrace.ContextClientTrace(request.Context()); trace != nil && trace.WroteHeaders != nil {
+				trace.WroteHeaders()
+			}
+		}
+		if reaction.err != nil {
+			return nil, reaction.err
+		}
+
+		return &http.Response{
+			StatusCode: reaction.status,
+			Header:     reaction.header,
+			Body:       closer(`{}`),
+		}, nil
+	}
+}
+
+func TestRetry(t *testing.T) {
+	connectionErr := errors.New("connection reset by peer")
+
+	cases := []struct {
+		name             string
+		policy           *RetryPolicy
+		method           string
+		reactions        []attemptReaction
+		expectedStatus   int
+		expectedErr      error
+		expectedAttempts int
+	}{
+		{
+			name:   "no policy",
+			method: http.MethodGet,
+			reactions: []attemptReaction{
+				{status: http.StatusServiceUnavailable},
+			},
+			expectedStatus:   http.StatusServiceUnavailable,
+			expectedAttempts: 1,
+		},
+		{
+			name:   "GET retried after 502",
+			policy: testRetryPolicy(),
+			method: http.MethodGet,
+			reactions: []attemptReaction{
+				{status: http.StatusBadGateway},
+				{status: http.StatusOK},
+			},
+			expectedStatus:   http.StatusOK,
+			expectedAttempts: 2,
+		},
+		{
+			name:   "GET retried after connection error",
+			policy: testRetryPolicy(),
+			method: http.MethodGet,
+			reactions: []attemptReaction{
+				{err: connectionErr, wroteHeaders: true},
+				{status: http.StatusOK},
+			},
+			expectedStatus:   http.StatusOK,
+			expectedAttempts: 2,
+		},
+		{
+			name:   "GET gives up after max attempts",
+			policy: testRetryPolicy(),
+			method: http.MethodGet,
+			reactions: []attemptReaction{
+				{status: http.StatusServiceUnavailable},
+				{status: http.StatusServiceUnavailable},
+				{status: http.StatusServiceUnavailable},
+			},
+			expectedStatus:   http.StatusServiceUnavailable,
+			expectedAttempts: 3,
+		},
+		{
+			name:   "GET not retried after 500",
+			policy: testRetryPolicy(),
+			method: http.MethodGet,
+			reactions: []attemptReaction{
+				{status: http.StatusInternalServerError},
+			},
+			expectedStatus:   http.StatusInternalServerError,
+			expectedAttempts: 1,
+		},
+		{
+			name:   "GET honors Retry-After",
+			policy: testRetryPolicy(),
+			method: http.MethodGet,
+			reactions: []attemptReaction{
+				{status: http.StatusTooManyRequests, header: http.Header{PollingDelayHeader: []string{"0"}}},
+				{status: http.StatusOK},
+			},
+			expectedStatus:   http.StatusOK,
+			expectedAttempts: 2,
+		},
+		{
+			name:   "GET not retried when Retry-After exceeds max backoff",
+			policy: testRetryPolicy(),
+			method: http.MethodGet,
+			reactions: []attemptReaction{
+				{status: http.StatusServiceUnavailable, header: http.Header{PollingDelayHeader: []string{"60"}}},
+			},
+			expectedStatus:   http.StatusServiceUnavailable,
+			expectedAttempts: 1,
+		},
+		{
+			name:   "PUT retried after connection error before request was written",
+			policy: testRetryPolicy(),
+			method: http.MethodPut,
+			reactions: []attemptReaction{
+				{err: connectionErr},
+				{status: http.StatusCreated},
+			},
+			expectedStatus:   http.StatusCreated,
+			expectedAttempts: 2,
+		},
+		{
+			name:   "PUT not retried after connection error once request was written",
+			policy: testRetryPolicy(),
+			method: http.MethodPut,
+			reactions: []attemptReaction{
+				{err: connectionErr, wroteHeaders: true},
+			},
+			expectedErr:      connectionErr,
+			expectedAttempts: 1,
+		},
+		{
+			name:   "DELETE not retried after 503",
+			policy: testRetryPolicy(),
+			method: http.MethodDelete,
+			reactions: []attemptReaction{
+				{status: http.StatusServiceUnavailable},
+			},
+			expectedStatus:   http.StatusServiceUnavailable,
+			expectedAttempts: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		var bodies []string
+		var retries []RetryAttempt
+		if tc.policy != nil {
+			tc.policy.OnRetry = func(a RetryAttempt) {
+				retries = append(retries, a)
+			}
+		}
+
+		klient := newTestClient(t, tc.name, Version2_14(), false, httpChecks{}, httpReaction{})
+		klient.RetryPolicy = tc.policy
+		klient.doRequestFunc = doAttempts(t, tc.name, tc.reactions, &bodies)
+
+		var body interface{}
+		if tc.method != http.MethodGet {
+			body = map[string]string{"service_id": testServiceID}
+		}
+
+		response, err := klient.prepareAndDo(context.Background(), OperationGetCatalog, tc.method, klient.URL, nil, body, nil, "")
+		if tc.expectedErr != nil {
+			if err != tc.expectedErr {
+				t.Errorf("%v: unexpected error; expected %v, got %v", tc.name, tc.expectedErr, err)
+			}
+		} else if err != nil {
+			t.Errorf("%v: unexpected error: %v", tc.name, err)
+		} else if e, a := tc.expectedStatus, response.StatusCode; e != a {
+			t.Errorf("%v: unexpected status; expected %v, got %v", tc.name, e, a)
+		}
+
+		if e, a := tc.expectedAttempts, len(bodies); e != a {
+			t.Errorf("%v: unexpected number of attempts; expected %v, got %v", tc.name, e, a)
+		}
+		if e, a := tc.expectedAttempts-1, len(retries); e != a {
+			t.Errorf("%v: unexpected number of retries; expected %v, got %v", tc.name, e, a)
+		}
+		for i := range bodies {
+			if e, a := bodies[0], bodies[i]; e != a {
+				t.Errorf("%v: unexpected body for attempt %d; expected %q, got %q", tc.name, i+1, e, a)
+			}
+		}
+	}
+}
+
+func TestRetryContextCanceledDuringBackoff(t *testing.T) {
+	var bodies []string
+	ctx, cancel := context.WithCancel(context.Background())
+
+	policy := testRetryPolicy()
+	policy.InitialBackoff = time.Hour
+	policy.MaxBackoff = 0
+	policy.OnRetry = func(RetryAttempt) {
+		cancel()
+	}
+
+	klient := newTestClient(t, "canceled during backoff", Version2_14(), false, httpChecks{}, httpReaction{})
+	klient.RetryPolicy = policy
+	klient.doRequestFunc = doAttempts(t, "canceled during backoff", []attemptReaction{
+		{status: http.StatusServiceUnavailable},
+	}, &bodies)
+
+	_, err := klient.GetCatalogWithContext(ctx)
+	if !IsCanceledError(err) {
+		t.Fatalf("expected canceled error, got %v", err)
+	}
+	if e, a := 1, len(bodies); e != a {
+		t.Errorf("unexpected number of attempts; expected %v, got %v", e, a)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := &RetryPolicy{
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+	}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}
+	for i, e := range expected {
+		if a := policy.backoff(i + 1); e != a {
+			t.Errorf("attempt %d: expected %v, got %v", i+1, e, a)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if a := policy.backoff(1); a < 500*time.Millisecond || a > 1500*time.Millisecond {
+			t.Fatalf("jittered backoff out of range: %v", a)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	cases := []struct {
+		name     string
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{
+			name:  "empty",
+			value: "",
+		},
+		{
+			name:     "seconds",
+			value:    "5",
+			expected: 5 * time.Second,
+			ok:       true,
+		},
+		{
+			name:  "negative seconds",
+			value: "-5",
+		},
+		{
+			name:     "date in the past",
+			value:    "Wed, 21 Oct 2015 07:28:00 GMT",
+			expected: 0,
+			ok:       true,
+		},
+		{
+			name:  "garbage",
+			value: "soon",
+		},
+	}
+
+	for _, tc := range cases {
+		delay, ok := parseRetryAfter(tc.value)
+		if e, a := tc.ok, ok; e != a {
+			t.Errorf("%v: unexpected ok; expected %v, got %v", tc.name, e, a)
+		}
+		if e, a := tc.expected, delay; e != a {
+			t.Errorf("%v: unexpected delay; expected %v, got %v", tc.name, e, a)
+		}
+	}
+}
+
+func TestNewClientRetryPolicyValidation(t *testing.T) {
+	config := DefaultClientConfiguration()
+	config.URL = "https://example.com"
+	config.RetryPolicy = DefaultRetryPolicy()
+
+	if _, err := NewClient(config); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	config.RetryPolicy.Jitter = 2
+	if _, err := NewClient(config); err == nil {
+		t.Fatal("expected error for invalid jitter")
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	v2 "sigs.k8s.io/go-open-service-broker-client/v2"
+)
+
+// The schemas of a plan that parameters are validated against.
+const (
+	ServiceInstanceCreate = "service_instance.create"
+	ServiceInstanceUpdate = "service_instance.update"
+	ServiceBindingCreate  = "service_binding.create"
+)
+
+// ValidationError is returned for parameters that do not conform to the
+// schema of their plan.
+type ValidationError struct {
+	// ServiceID and PlanID identify the plan.
+	ServiceID string
+	PlanID    string
+	// Schema is the schema of the plan the parameters were validated
+	// against, such as ServiceInstanceCreate.
+	Schema string
+	// Errors are the parameters that do not conform to the schema.
+	Errors []FieldError
+}
+
+func (e ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("parameters do not conform to the %v schema of plan %q: %v", e.Schema, e.PlanID, strings.Join(messages, "; "))
+}
+
+// IsValidationError returns whether the error is a ValidationError.
+func IsValidationError(err error) (*ValidationError, bool) {
+	var validationError ValidationError
+	if errors.As(err, &validationError) {
+		return &validationError, true
+	}
+	return nil, false
+}
+
+// ParametersValidator validates the parameters of provision, update and bind
+// requests against the schemas of the plans in a catalog, so that invalid
+// parameters are reported before a request is sent to the broker.  It can
+// be set as the RequestValidator of a v2.ClientConfiguration, or called
+// directly.  ParametersValidator is threadsafe.
+type ParametersValidator struct {
+	plans map[planKey]*planSchemas
+}
+
+var _ v2.RequestValidator = &ParametersValidator{}
+
+type planKey struct {
+	serviceID string
+	planID    string
+}
+
+// planSchemas are the compiled schemas of a plan; a nil schema accepts any
+// parameters.
+type planSchemas struct {
+	instanceCreate *Schema
+	instanceUpdate *Schema
+	bindingCreate  *Schema
+}
+
+// NewParametersValidator returns a ParametersValidator for the plans in the
+// given catalog.  It returns an error if one of the schemas of a plan cannot
+// be compiled.
+func NewParametersValidator(catalog *v2.CatalogResponse) (*ParametersValidator, error) {
+	v := &ParametersValidator{plans: map[planKey]*planSchemas{}}
+	for _, service := range catalog.Services {
+		for _, plan := range service.Plans {
+			schemas := &planSchemas{}
+			if plan.Schemas != nil {
+				var err error
+				if instance := plan.Schemas.ServiceInstance; instance != nil {
+					if schemas.instanceCreate, err = compileParametersSchema(instance.Create); err != nil {
+						return nil, fmt.Errorf("plan %q: %v schema: %v", plan.ID, ServiceInstanceCreate, err)
+					}
+					if schemas.instanceUpdate, err = compileParametersSchema(instance.Update); err != nil {
+						return nil, fmt.Errorf("plan %q: %v schema: %v", plan.ID, ServiceInstanceUpdate, err)
+					}
+				}
+				if binding := plan.Schemas.ServiceBinding; binding != nil {
+					if schemas.bindingCreate, err = compileParametersSchema(binding.Create); err != nil {
+						return nil, fmt.Errorf("plan %q: %v schema: %v", plan.ID, ServiceBindingCreate, err)
+					}
+				}
+			}
+			v.plans[planKey{serviceID: service.ID, planID: plan.ID}] = schemas
+		}
+	}
+	return v, nil
+}
+
+func compileParametersSchema(schema *v2.InputParametersSchema) (*Schema, error) {
+	if schema == nil || schema.Parameters == nil {
+		return nil, nil
+	}
+	return Compile(schema.Parameters)
+}
+
+// ValidateProvisionRequest validates the parameters of a provision request
+// against the service_instance.create schema of its plan.
+func (v *ParametersValidator) ValidateProvisionRequest(r *v2.ProvisionRequest) error {
+	schemas, err := v.planSchemas(r.ServiceID, r.PlanID)
+	if err != nil {
+		return err
+	}
+	return validateParameters(schemas.instanceCreate, r.ServiceID, r.PlanID, ServiceInstanceCreate, r.Parameters)
+}
+
+// ValidateUpdateInstanceRequest validates the parameters of an update request
+// against the service_instance.update schema of the plan the instance is
+// updated to or, if the plan is not changed, of its previous plan.  If
+// neither plan is given, the parameters are not validated.
+func (v *ParametersValidator) ValidateUpdateInstanceRequest(r *v2.UpdateInstanceRequest) error {
+	var planID string
+	if r.PlanID != nil {
+		planID = *r.PlanID
+	} else if r.PreviousValues != nil {
+		planID = r.PreviousValues.PlanID
+	}
+	if planID == "" {
+		return nil
+	}
+
+	schemas, err := v.planSchemas(r.ServiceID, planID)
+	if err != nil {
+		return err
+	}
+	return validateParameters(schemas.instanceUpdate, r.ServiceID, planID, ServiceInstanceUpdate, r.Parameters)
+}
+
+// ValidateBindRequest validates the parameters of a bind request against the
+// service_binding.create schema of its plan.
+func (v *ParametersValidator) ValidateBindRequest(r *v2.BindRequest) error {
+	schemas, err := v.planSchemas(r.ServiceID, r.PlanID)
+	if err != nil {
+		return err
+	}
+	return validateParameters(schemas.bindingCreate, r.ServiceID, r.PlanID, ServiceBindingCreate, r.Parameters)
+}
+
+func (v *ParametersValidator) planSchemas(serviceID, planID string) (*planSchemas, error) {
+	schemas, ok := v.plans[planKey{serviceID: serviceID, planID: planID}]
+	if !ok {
+		return nil, fmt.Errorf("plan %q of service %q is not in the catalog", planID, serviceID)
+	}
+	return schemas, nil
+}
+
+// validateParameters validates parameters against a schema.  Requests without
+// parameters are validated as if their parameters were empty.
+func validateParameters(schema *Schema, serviceID, planID, name string, parameters map[string]interface{}) error {
+	if schema == nil {
+		return nil
+	}
+	if parameters == nil {
+		parameters = map[string]interface{}{}
+	}
+	if errs := schema.Validate(parameters); len(errs) > 0 {
+		return ValidationError{
+			ServiceID: serviceID,
+			PlanID:    planID,
+			Schema:    name,
+			Errors:    errs,
+		}
+	}
+	return nil
+}
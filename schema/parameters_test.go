@@ -0,0 +1,306 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	v2 "sigs.k8s.io/go-open-service-broker-client/v2"
+	"sigs.k8s.io/go-open-service-broker-client/v2/schema"
+)
+
+const testCatalog = `{
+  "services": [{
+    "id": "service-id",
+    "name": "database",
+    "description": "a database",
+    "bindable": true,
+    "plans": [{
+      "id": "plan-id",
+      "name": "standard",
+      "description": "a standard database",
+      "schemas": {
+        "service_instance": {
+          "create": {
+            "parameters": {
+              "$schema": "http://json-schema.org/draft-04/schema#",
+              "type": "object",
+              "properties": {
+                "size": {"type": "integer", "minimum": 1, "maximum": 10},
+                "region": {"type": "string", "enum": ["us", "eu"]}
+              },
+              "required": ["region"],
+              "additionalProperties": false
+            }
+          },
+          "update": {
+            "parameters": {
+              "type": "object",
+              "properties": {"size": {"type": "integer", "minimum": 1}}
+            }
+          }
+        },
+        "service_binding": {
+          "create": {
+            "parameters": {
+              "type": "object",
+              "properties": {"role": {"enum": ["read", "write"]}}
+            }
+          }
+        }
+      }
+    }, {
+      "id": "schemaless-plan-id",
+      "name": "schemaless",
+      "description": "a plan without schemas"
+    }]
+  }]
+}`
+
+func newTestValidator(t *testing.T) *schema.ParametersValidator {
+	catalog := &v2.CatalogResponse{}
+	if err := json.Unmarshal([]byte(testCatalog), catalog); err != nil {
+		t.Fatalf("invalid catalog: %v", err)
+	}
+	validator, err := schema.NewParametersValidator(catalog)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return validator
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestValidateProvisionRequest(t *testing.T) {
+	validator := newTestValidator(t)
+
+	cases := []struct {
+		name       string
+		planID     string
+		parameters map[string]interface{}
+		expected   []schema.FieldError
+		unknown    bool
+	}{
+		{
+			name:       "valid",
+			planID:     "plan-id",
+			parameters: map[string]interface{}{"size": 2, "region": "eu"},
+		},
+		{
+			name:       "invalid",
+			planID:     "plan-id",
+			parameters: map[string]interface{}{"size": 20, "zone": "a"},
+			expected: []schema.FieldError{
+				{Field: "region", Keyword: "required", Message: "is required"},
+				{Field: "size", Keyword: "maximum", Message: "must be less than or equal to 10"},
+				{Field: "zone", Keyword: "additionalProperties", Message: "is not allowed"},
+			},
+		},
+		{
+			name:     "no parameters",
+			planID:   "plan-id",
+			expected: []schema.FieldError{{Field: "region", Keyword: "required", Message: "is required"}},
+		},
+		{
+			name:       "plan without schemas",
+			planID:     "schemaless-plan-id",
+			parameters: map[string]interface{}{"anything": true},
+		},
+		{
+			name:    "unknown plan",
+			planID:  "unknown-plan-id",
+			unknown: true,
+		},
+	}
+
+	for _, tc := range cases {
+		err := validator.ValidateProvisionRequest(&v2.ProvisionRequest{
+			ServiceID:  "service-id",
+			PlanID:     tc.planID,
+			Parameters: tc.parameters,
+		})
+
+		if tc.unknown {
+			if err == nil {
+				t.Errorf("%v: expected an error for a plan that is not in the catalog", tc.name)
+			} else if _, ok := schema.IsValidationError(err); ok {
+				t.Errorf("%v: expected an error that is not a validation error, got %v", tc.name, err)
+			}
+			continue
+		}
+		if tc.expected == nil {
+			if err != nil {
+				t.Errorf("%v: unexpected error: %v", tc.name, err)
+			}
+			continue
+		}
+
+		validationErr, ok := schema.IsValidationError(err)
+		if !ok {
+			t.Errorf("%v: expected a validation error, got %v", tc.name, err)
+			continue
+		}
+		if e, a := schema.ServiceInstanceCreate, validationErr.Schema; e != a {
+			t.Errorf("%v: unexpected schema; expected %v, got %v", tc.name, e, a)
+		}
+		if e, a := tc.expected, validationErr.Errors; !reflect.DeepEqual(e, a) {
+			t.Errorf("%v: unexpected field errors;\nexpected %+v\ngot      %+v", tc.name, e, a)
+		}
+	}
+}
+
+func TestValidateUpdateInstanceRequest(t *testing.T) {
+	validator := newTestValidator(t)
+
+	cases := []struct {
+		name    string
+		request *v2.UpdateInstanceRequest
+		invalid bool
+	}{
+		{
+			name: "new plan",
+			request: &v2.UpdateInstanceRequest{
+				ServiceID:  "service-id",
+				PlanID:     strPtr("plan-id"),
+				Parameters: map[string]interface{}{"size": 0},
+			},
+			invalid: true,
+		},
+		{
+			name: "previous plan",
+			request: &v2.UpdateInstanceRequest{
+				ServiceID:      "service-id",
+				PreviousValues: &v2.PreviousValues{PlanID: "plan-id"},
+				Parameters:     map[string]interface{}{"size": 0},
+			},
+			invalid: true,
+		},
+		{
+			name: "update schema allows parameters the create schema does not",
+			request: &v2.UpdateInstanceRequest{
+				ServiceID:  "service-id",
+				PlanID:     strPtr("plan-id"),
+				Parameters: map[string]interface{}{"size": 20},
+			},
+		},
+		{
+			name: "unknown plan",
+			request: &v2.UpdateInstanceRequest{
+				ServiceID:  "service-id",
+				Parameters: map[string]interface{}{"size": 0},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		err := validator.ValidateUpdateInstanceRequest(tc.request)
+		if _, ok := schema.IsValidationError(err); ok != tc.invalid {
+			t.Errorf("%v: expected a validation error: %v, got %v", tc.name, tc.invalid, err)
+		}
+	}
+}
+
+func TestValidateBindRequest(t *testing.T) {
+	validator := newTestValidator(t)
+
+	err := validator.ValidateBindRequest(&v2.BindRequest{
+		ServiceID:  "service-id",
+		PlanID:     "plan-id",
+		Parameters: map[string]interface{}{"role": "admin"},
+	})
+	validationErr, ok := schema.IsValidationError(err)
+	if !ok {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	if e, a := schema.ServiceBindingCreate, validationErr.Schema; e != a {
+		t.Errorf("unexpected schema; expected %v, got %v", e, a)
+	}
+	if e, a := `parameters do not conform to the service_binding.create schema of plan "plan-id": role: must be one of ["read","write"]`, err.Error(); e != a {
+		t.Errorf("unexpected error message;\nexpected %v\ngot      %v", e, a)
+	}
+}
+
+func TestNewParametersValidatorInvalidSchema(t *testing.T) {
+	catalog := &v2.CatalogResponse{
+		Services: []v2.Service{{
+			ID: "service-id",
+			Plans: []v2.Plan{{
+				ID: "plan-id",
+				Schemas: &v2.Schemas{
+					ServiceInstance: &v2.ServiceInstanceSchema{
+						Create: &v2.InputParametersSchema{
+							Parameters: map[string]interface{}{"pattern": "("},
+						},
+					},
+				},
+			}},
+		}},
+	}
+	if _, err := schema.NewParametersValidator(catalog); err == nil {
+		t.Error("expected an error for a plan with an invalid schema")
+	}
+}
+
+func TestClientRequestValidator(t *testing.T) {
+	sent := 0
+	config := v2.DefaultClientConfiguration()
+	config.URL = "http://broker.example.com"
+	config.RequestValidator = newTestValidator(t)
+	config.Transport = v2.RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		sent++
+		return &http.Response{
+			StatusCode: http.StatusCreated,
+			Body:       ioutil.NopCloser(strings.NewReader("{}")),
+		}, nil
+	})
+	client, err := v2.NewClient(config)
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+
+	request := &v2.ProvisionRequest{
+		InstanceID:       "instance-id",
+		ServiceID:        "service-id",
+		PlanID:           "plan-id",
+		OrganizationGUID: "organization-guid",
+		SpaceGUID:        "space-guid",
+		Parameters:       map[string]interface{}{"size": 20},
+	}
+	if _, err := client.ProvisionInstance(request); err == nil {
+		t.Error("expected a validation error for invalid parameters")
+	} else if _, ok := schema.IsValidationError(err); !ok {
+		t.Errorf("expected a validation error, got %v", err)
+	}
+	if e, a := 0, sent; e != a {
+		t.Errorf("expected %v requests to be sent for invalid parameters, got %v", e, a)
+	}
+
+	request.Parameters = map[string]interface{}{"size": 2, "region": "eu"}
+	if _, err := client.ProvisionInstance(request); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if e, a := 1, sent; e != a {
+		t.Errorf("expected %v request to be sent for valid parameters, got %v", e, a)
+	}
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package schema validates values against the JSON Schemas that brokers
+// advertise for the parameters of their plans.
+//
+// It implements the validation keywords of JSON Schema drafts 04, 06, 07 and
+// 2019-09, including if/then/else, dependencies and their 2019-09 forms
+// dependentRequired and dependentSchemas, and a set of common formats.  A
+// schema without $schema is validated as draft-04, the draft the Open Service
+// Broker API specifies.  References are resolved within the schema they
+// appear in; references to other documents, unevaluatedItems and
+// unevaluatedProperties are not supported.
+//
+// A ParametersValidator is a v2.RequestValidator, so that a client validates
+// the parameters of its requests before sending them:
+//
+//	validator, err := schema.NewParametersValidator(catalog)
+//	config.RequestValidator = validator
+package schema
+
+import (
+	"sigs.k8s.io/go-open-service-broker-client/v2/internal/jsonschema"
+)
+
+// Schema is a compiled JSON Schema.  Its Validate method validates a value
+// against the schema and returns the values that do not conform to it, or nil
+// if the value is valid.
+type Schema = jsonschema.Schema
+
+// FieldError is a value that does not conform to a schema.  Its Field is the
+// dot-separated path of the value, with the elements of arrays identified by
+// their index, such as "disks.0.size", and is empty for the validated value
+// itself.  Its Keyword is the schema keyword that the value does not conform
+// to, such as "maximum" or "required".
+type FieldError = jsonschema.FieldError
+
+// Compile compiles a JSON Schema, such as the Parameters of a
+// v2.InputParametersSchema.  It returns an error if the schema is not a JSON
+// object or boolean, if one of its patterns is not a valid regular
+// expression, or if one of its references cannot be resolved.
+func Compile(schema interface{}) (*Schema, error) {
+	return jsonschema.Compile(schema)
+}
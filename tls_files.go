@@ -0,0 +1,193 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// configureCertificateFiles sets up tlsConfig to load the client certificate
+// named in the configuration from files, reloading it when they change.
+func configureCertificateFiles(config *ClientConfiguration, tlsConfig *tls.Config) error {
+	if (config.CertFile == "") != (config.KeyFile == "") {
+		return errors.New("CertFile and KeyFile must be specified together")
+	}
+	if config.CertFile == "" {
+		return nil
+	}
+
+	if len(tlsConfig.Certificates) != 0 || tlsConfig.GetClientCertificate != nil {
+		return errors.New("Cannot specify CertFile together with client certificates in TLSConfig")
+	}
+	certificate, err := newCertificateReloader(config.CertFile, config.KeyFile)
+	if err != nil {
+		return err
+	}
+	tlsConfig.GetClientCertificate = certificate.GetClientCertificate
+
+	return nil
+}
+
+// certificateReloader provides the client certificate in a pair of PEM files,
+// parsing them again when they change.
+type certificateReloader struct {
+	certFile *watchedFile
+	keyFile  *watchedFile
+
+	mu          sync.Mutex
+	certPEM     []byte
+	keyPEM      []byte
+	certificate *tls.Certificate
+}
+
+func newCertificateReloader(certPath, keyPath string) (*certificateReloader, error) {
+	certFile, err := newWatchedFile(certPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading client certificate: %v", err)
+	}
+	keyFile, err := newWatchedFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading client key: %v", err)
+	}
+
+	r := &certificateReloader{certFile: certFile, keyFile: keyFile}
+	if _, err := r.GetClientCertificate(nil); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetClientCertificate returns the current client certificate.  If the files
+// cannot be parsed, as happens when only one of them has been replaced so far,
+// the last certificate that could be parsed is returned.
+func (r *certificateReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	certPEM, certErr := r.certFile.read()
+	keyPEM, keyErr := r.keyFile.read()
+	if certErr == nil && keyErr == nil && bytes.Equal(certPEM, r.certPEM) && bytes.Equal(keyPEM, r.keyPEM) {
+		return r.certificate, nil
+	}
+
+	err := certErr
+	if err == nil {
+		err = keyErr
+	}
+	if err == nil {
+		var certificate tls.Certificate
+		certificate, err = tls.X509KeyPair(certPEM, keyPEM)
+		if err == nil {
+			r.certPEM, r.keyPEM, r.certificate = certPEM, keyPEM, &certificate
+			return r.certificate, nil
+		}
+	}
+
+	if r.certificate != nil {
+		return r.certificate, nil
+	}
+	return nil, fmt.Errorf("loading client certificate: %v", err)
+}
+
+// rootCAReloadingTransport sends requests through an http.Transport that
+// trusts the root CAs in a PEM file and in CAData.  The root CAs of an
+// http.Transport cannot change once it is in use, so when the file changes
+// the transport is replaced with a copy trusting the new root CAs.
+type rootCAReloadingTransport struct {
+	caFile *watchedFile
+	caData []byte
+	base   *http.Transport
+
+	mu        sync.Mutex
+	caPEM     []byte
+	transport *http.Transport
+}
+
+var _ http.RoundTripper = &rootCAReloadingTransport{}
+
+func newRootCAReloadingTransport(caPath string, caData []byte, base *http.Transport) (*rootCAReloadingTransport, error) {
+	caFile, err := newWatchedFile(caPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading root CAs: %v", err)
+	}
+
+	t := &rootCAReloadingTransport{caFile: caFile, caData: caData, base: base}
+	if _, err := t.current(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *rootCAReloadingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	transport, err := t.current()
+	if err != nil {
+		return nil, err
+	}
+	return transport.RoundTrip(request)
+}
+
+// CloseIdleConnections closes the idle connections of the current transport,
+// so that http.Client.CloseIdleConnections works as it does for the default
+// transport.
+func (t *rootCAReloadingTransport) CloseIdleConnections() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.transport != nil {
+		t.transport.CloseIdleConnections()
+	}
+}
+
+// current returns the transport trusting the current root CAs.  If the file
+// cannot be parsed, as happens while it is being rewritten, the last transport
+// is returned.
+func (t *rootCAReloadingTransport) current() (*http.Transport, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	caPEM, err := t.caFile.read()
+	if err == nil && t.transport != nil && bytes.Equal(caPEM, t.caPEM) {
+		return t.transport, nil
+	}
+
+	if err == nil {
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM(t.caData)
+		if pool.AppendCertsFromPEM(caPEM) {
+			transport := t.base.Clone()
+			transport.TLSClientConfig.RootCAs = pool
+			if t.transport != nil {
+				t.transport.CloseIdleConnections()
+			}
+			t.caPEM, t.transport = caPEM, transport
+			return t.transport, nil
+		}
+		err = errors.New("no certificates found")
+	}
+
+	if t.transport != nil {
+		return t.transport, nil
+	}
+	return nil, fmt.Errorf("loading root CAs from %s: %v", t.caFile.path, err)
+}
@@ -0,0 +1,357 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testCertificate is a certificate and key generated for a test.
+type testCertificate struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	certPEM     []byte
+	keyPEM      []byte
+}
+
+// newTestCertificate returns a certificate with the given common name, signed
+// by parent, or a self-signed CA certificate if parent is nil.
+func newTestCertificate(t *testing.T, commonName string, parent *testCertificate) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.certificate, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("unexpected error creating certificate: %v", err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("unexpected error parsing certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling key: %v", err)
+	}
+
+	return &testCertificate{
+		certificate: certificate,
+		key:         key,
+		certPEM:     pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:      pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func (c *testCertificate) tlsCertificate(t *testing.T) *tls.Certificate {
+	certificate, err := tls.X509KeyPair(c.certPEM, c.keyPEM)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return &certificate
+}
+
+func TestCertificateAndCAFileRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls-files")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	firstCA := newTestCertificate(t, "first-ca", nil)
+	secondCA := newTestCertificate(t, "second-ca", nil)
+	clientCA := newTestCertificate(t, "client-ca", nil)
+
+	// The server's certificate, and the client certificate it last saw.
+	var (
+		mu                sync.Mutex
+		serverCertificate = newTestCertificate(t, "server", firstCA).tlsCertificate(t)
+		clientName        string
+	)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		clientName = r.TLS.PeerCertificates[0].Subject.CommonName
+		mu.Unlock()
+		w.Write([]byte(`{"services":[]}`))
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCA.certificate)
+	server.TLS = &tls.Config{
+		// httptest adds its own certificate to the configuration, and
+		// crypto/tls ignores GetCertificate for clients connecting to an IP
+		// address, so the certificate is provided with the whole
+		// configuration instead.
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return &tls.Config{
+				Certificates: []tls.Certificate{*serverCertificate},
+				ClientAuth:   tls.RequireAndVerifyClientCert,
+				ClientCAs:    clientCAs,
+			}, nil
+		},
+	}
+	// Make a new connection for each request, so that each request shows
+	// which certificates are in use.
+	server.Config.SetKeepAlivesEnabled(false)
+	server.StartTLS()
+	defer server.Close()
+
+	certPath := filepath.Join(dir, "tls.crt")
+	keyPath := filepath.Join(dir, "tls.key")
+	caPath := filepath.Join(dir, "ca.crt")
+	modTime := time.Now().Add(-time.Hour)
+	writeFiles := func(client *testCertificate, ca *testCertificate) {
+		modTime = modTime.Add(time.Minute)
+		writeCredentialFile(t, certPath, string(client.certPEM), modTime)
+		writeCredentialFile(t, keyPath, string(client.keyPEM), modTime)
+		writeCredentialFile(t, caPath, string(ca.certPEM), modTime)
+	}
+	writeFiles(newTestCertificate(t, "first-client", clientCA), firstCA)
+
+	config := DefaultClientConfiguration()
+	config.URL = server.URL
+	config.CertFile = certPath
+	config.KeyFile = keyPath
+	config.CAFile = caPath
+	klient, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectClient := func(expected string) {
+		t.Helper()
+		if _, err := klient.GetCatalog(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if e, a := expected, clientName; e != a {
+			t.Errorf("unexpected client certificate; expected %v, got %v", e, a)
+		}
+	}
+
+	expectClient("first-client")
+
+	writeFiles(newTestCertificate(t, "second-client", clientCA), firstCA)
+	expectClient("second-client")
+
+	// The broker's certificate is rotated to a new CA before the client
+	// trusts it.
+	mu.Lock()
+	serverCertificate = newTestCertificate(t, "server", secondCA).tlsCertificate(t)
+	mu.Unlock()
+	if _, err := klient.GetCatalog(); err == nil {
+		t.Fatal("expected an error for a broker certificate from an untrusted CA")
+	}
+
+	writeFiles(newTestCertificate(t, "third-client", clientCA), secondCA)
+	expectClient("third-client")
+
+	// A partially written file does not break the client.
+	modTime = modTime.Add(time.Minute)
+	writeCredentialFile(t, caPath, "-----BEGIN CERTIFICATE-----\n", modTime)
+	writeCredentialFile(t, keyPath, "", modTime)
+	expectClient("third-client")
+}
+
+func TestNewClientTLSFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls-files")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca := newTestCertificate(t, "ca", nil)
+	client := newTestCertificate(t, "client", ca)
+	certPath := filepath.Join(dir, "tls.crt")
+	keyPath := filepath.Join(dir, "tls.key")
+	caPath := filepath.Join(dir, "ca.crt")
+	for path, data := range map[string][]byte{certPath: client.certPEM, keyPath: client.keyPEM, caPath: ca.certPEM} {
+		if err := ioutil.WriteFile(path, data, 0600); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	cases := []struct {
+		name   string
+		config func(*ClientConfiguration)
+	}{
+		{
+			name:   "cert without key",
+			config: func(c *ClientConfiguration) { c.CertFile = certPath },
+		},
+		{
+			name: "missing cert",
+			config: func(c *ClientConfiguration) {
+				c.CertFile = filepath.Join(dir, "missing")
+				c.KeyFile = keyPath
+			},
+		},
+		{
+			name: "mismatched cert and key",
+			config: func(c *ClientConfiguration) {
+				c.CertFile = certPath
+				c.KeyFile = caPath
+			},
+		},
+		{
+			name: "CA file without certificates",
+			config: func(c *ClientConfiguration) {
+				c.CAFile = keyPath
+			},
+		},
+		{
+			name: "CA file and insecure",
+			config: func(c *ClientConfiguration) {
+				c.CAFile = caPath
+				c.Insecure = true
+			},
+		},
+		{
+			name: "CA file and TLSConfig root CAs",
+			config: func(c *ClientConfiguration) {
+				c.CAFile = caPath
+				c.TLSConfig = &tls.Config{RootCAs: x509.NewCertPool()}
+			},
+		},
+		{
+			name: "CA data and TLSConfig root CAs",
+			config: func(c *ClientConfiguration) {
+				c.CAData = ca.certPEM
+				c.TLSConfig = &tls.Config{RootCAs: x509.NewCertPool()}
+			},
+		},
+		{
+			name: "cert file and Transport",
+			config: func(c *ClientConfiguration) {
+				c.CertFile = certPath
+				c.KeyFile = keyPath
+				c.Transport = http.DefaultTransport
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		config := DefaultClientConfiguration()
+		config.URL = "https://example.com"
+		tc.config(config)
+		if _, err := NewClient(config); err == nil {
+			t.Errorf("%v: expected error, got none", tc.name)
+		}
+	}
+}
+
+func TestNewClientSharedTLSConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls-files")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca := newTestCertificate(t, "ca", nil)
+	clientCertificate := newTestCertificate(t, "client", ca)
+	certPath := filepath.Join(dir, "tls.crt")
+	keyPath := filepath.Join(dir, "tls.key")
+	for path, data := range map[string][]byte{certPath: clientCertificate.certPEM, keyPath: clientCertificate.keyPEM} {
+		if err := ioutil.WriteFile(path, data, 0600); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	newClient := func(configure func(*ClientConfiguration)) *tls.Config {
+		t.Helper()
+		config := DefaultClientConfiguration()
+		config.URL = "https://example.com"
+		config.TLSConfig = tlsConfig
+		configure(config)
+		klient, err := NewClient(config)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return klient.(*client).httpClient.Transport.(*http.Transport).TLSClientConfig
+	}
+
+	first := newClient(func(c *ClientConfiguration) {
+		c.CertFile = certPath
+		c.KeyFile = keyPath
+		c.CAData = ca.certPEM
+	})
+	// A second client with its own certificate files can share the
+	// configuration.
+	second := newClient(func(c *ClientConfiguration) {
+		c.CertFile = certPath
+		c.KeyFile = keyPath
+	})
+	third := newClient(func(c *ClientConfiguration) {
+		c.Insecure = true
+	})
+
+	if tlsConfig.GetClientCertificate != nil || tlsConfig.RootCAs != nil || tlsConfig.InsecureSkipVerify {
+		t.Errorf("expected the shared TLSConfig not to be modified, got %+v", tlsConfig)
+	}
+	if first.GetClientCertificate == nil || first.RootCAs == nil {
+		t.Error("expected the first client to have a client certificate and root CAs")
+	}
+	if second.GetClientCertificate == nil || second.RootCAs != nil {
+		t.Error("expected the second client to have a client certificate and no root CAs")
+	}
+	if third.GetClientCertificate != nil || third.RootCAs != nil || !third.InsecureSkipVerify {
+		t.Error("expected the third client to skip verification without a client certificate or root CAs")
+	}
+	for i, config := range []*tls.Config{first, second, third} {
+		if e, a := uint16(tls.VersionTLS12), config.MinVersion; e != a {
+			t.Errorf("client %v: expected MinVersion %v from the shared TLSConfig, got %v", i, e, a)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+)
+
+const (
+	// TraceparentHeader is the W3C Trace Context header identifying the span
+	// a request to the broker is part of.
+	TraceparentHeader = "traceparent"
+	// TracestateHeader is the W3C Trace Context header carrying
+	// vendor-specific trace information.
+	TracestateHeader = "tracestate"
+)
+
+// Span attribute keys set by the client.
+const (
+	// AttributeBrokerName is the name of the client, as set in
+	// ClientConfiguration.
+	AttributeBrokerName = "osb.broker.name"
+	// AttributeAPIVersion is the API version the client sent.
+	AttributeAPIVersion = "osb.api_version"
+	// AttributeInstanceID is the ID of the instance an operation is for.
+	AttributeInstanceID = "osb.instance_id"
+	// AttributeBindingID is the ID of the binding an operation is for.
+	AttributeBindingID = "osb.binding_id"
+	// AttributeServiceID is the ID of the service in the request.
+	AttributeServiceID = "osb.service_id"
+	// AttributePlanID is the ID of the plan in the request.
+	AttributePlanID = "osb.plan_id"
+	// AttributeHTTPStatusCode is the status code of the broker's response.
+	// If the request was retried, it is the status code of the last
+	// attempt.
+	AttributeHTTPStatusCode = "http.status_code"
+)
+
+// Tracer starts spans for the operations of a client, so that the requests
+// made to a broker can be correlated with the work that caused them.  It is a
+// minimal interface that any tracing library can be adapted to; the
+// sigs.k8s.io/go-open-service-broker-client/v2/otel module adapts
+// OpenTelemetry.
+type Tracer interface {
+	// Start starts a span with the given name as a child of any span in
+	// ctx, and returns a context containing the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	// SetAttribute sets an attribute of the span.  The value is a string or
+	// an int.
+	SetAttribute(key string, value interface{})
+	// RecordError records that the operation failed with the given error.
+	RecordError(err error)
+	// End ends the span.
+	End()
+	// SpanContext returns the identity of the span, which is sent to the
+	// broker in the W3C Trace Context headers.
+	SpanContext() SpanContext
+}
+
+// SpanContext identifies a span for W3C Trace Context propagation.
+type SpanContext struct {
+	// TraceID is the ID of the trace the span belongs to.
+	TraceID [16]byte
+	// SpanID is the ID of the span.
+	SpanID [8]byte
+	// Sampled is whether the trace is sampled.
+	Sampled bool
+	// TraceState is the value of the tracestate header, if any.
+	TraceState string
+}
+
+// IsValid returns whether the SpanContext has a trace ID and a span ID, which
+// is required to propagate it.
+func (s SpanContext) IsValid() bool {
+	return s.TraceID != [16]byte{} && s.SpanID != [8]byte{}
+}
+
+// Traceparent returns the value of the traceparent header for the span.
+func (s SpanContext) Traceparent() string {
+	flags := "00"
+	if s.Sampled {
+		flags = "01"
+	}
+	return "00-" + hex.EncodeToString(s.TraceID[:]) + "-" + hex.EncodeToString(s.SpanID[:]) + "-" + flags
+}
+
+// noopTracer is the Tracer used when none is configured.
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, interface{}) {}
+func (noopSpan) RecordError(error)                {}
+func (noopSpan) End()                             {}
+func (noopSpan) SpanContext() SpanContext         { return SpanContext{} }
+
+// spanIDs are the IDs in a request that are set as attributes of its span.
+type spanIDs struct {
+	instanceID string
+	bindingID  string
+	serviceID  string
+	planID     string
+}
+
+type spanKey struct{}
+
+// startSpan starts a span for an operation with the client's Tracer.  The
+// returned context carries the span to prepareAndDo, which sets the status
+// code of the response and propagates the span to the broker.
+func (c *client) startSpan(ctx context.Context, operation Operation, ids spanIDs) (context.Context, Span) {
+	tracer := c.Tracer
+	if tracer == nil {
+		tracer = noopTracer{}
+	}
+
+	ctx, span := tracer.Start(ctx, string(operation))
+	span.SetAttribute(AttributeBrokerName, c.Name)
+	span.SetAttribute(AttributeAPIVersion, c.APIVersion.HeaderValue())
+	for _, attribute := range []struct{ key, value string }{
+		{AttributeInstanceID, ids.instanceID},
+		{AttributeBindingID, ids.bindingID},
+		{AttributeServiceID, ids.serviceID},
+		{AttributePlanID, ids.planID},
+	} {
+		if attribute.value != "" {
+			span.SetAttribute(attribute.key, attribute.value)
+		}
+	}
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// endSpan ends the span of an operation, recording the error it returned.
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+// spanFromContext returns the span started by startSpan, or a no-op span.
+func spanFromContext(ctx context.Context) Span {
+	if span, ok := ctx.Value(spanKey{}).(Span); ok {
+		return span
+	}
+	return noopSpan{}
+}
+
+// injectTraceContext sets the W3C Trace Context headers of the request to
+// identify the span in its context.
+func injectTraceContext(request *http.Request) {
+	spanContext := spanFromContext(request.Context()).SpanContext()
+	if !spanContext.IsValid() {
+		return
+	}
+
+	request.Header.Set(TraceparentHeader, spanContext.Traceparent())
+	if spanContext.TraceState != "" {
+		request.Header.Set(TracestateHeader, spanContext.TraceState)
+	}
+}
+
+// stringValue returns the string s points to, or "" if s is nil.
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// testSpan is a Span that records what is done to it.
+type testSpan struct {
+	name        string
+	attributes  map[string]interface{}
+	errors      []error
+	ended       bool
+	spanContext SpanContext
+}
+
+func (s *testSpan) SetAttribute(key string, value interface{}) { s.attributes[key] = value }
+func (s *testSpan) RecordError(err error)                      { s.errors = append(s.errors, err) }
+func (s *testSpan) End()                                       { s.ended = true }
+func (s *testSpan) SpanContext() SpanContext                   { return s.spanContext }
+
+// testTracer is a Tracer that records the spans it starts, giving each span
+// the next span ID in the same trace.
+type testTracer struct {
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &testSpan{
+		name:       name,
+		attributes: map[string]interface{}{},
+		spanContext: SpanContext{
+			TraceID:    [16]byte{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+			SpanID:     [8]byte{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, byte(len(t.spans) + 1)},
+			Sampled:    true,
+			TraceState: "vendor=value",
+		},
+	}
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func TestTracing(t *testing.T) {
+	tracer := &testTracer{}
+	var headers []http.Header
+	klient := newTestClient(t, "tracing", Version2_14(), false, httpChecks{}, httpReaction{})
+	klient.Tracer = tracer
+	klient.doRequestFunc = func(request *http.Request) (*http.Response, error) {
+		headers = append(headers, request.Header)
+		if request.Method == http.MethodPut {
+			return &http.Response{StatusCode: http.StatusAccepted, Body: closer(`{"operation":"op"}`)}, nil
+		}
+		return &http.Response{StatusCode: http.StatusGone, Body: closer(`{}`)}, nil
+	}
+
+	if _, err := klient.ProvisionInstance(defaultAsyncProvisionRequest()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := klient.PollLastOperation(defaultLastOperationRequest()); err == nil {
+		t.Fatal("expected an error for a 410 response to polling")
+	}
+
+	if e, a := 2, len(tracer.spans); e != a {
+		t.Fatalf("expected %v spans, got %v", e, a)
+	}
+
+	provision := tracer.spans[0]
+	expectedAttributes := map[string]interface{}{
+		AttributeBrokerName:     klient.Name,
+		AttributeAPIVersion:     Version2_14().HeaderValue(),
+		AttributeInstanceID:     testInstanceID,
+		AttributeServiceID:      testServiceID,
+		AttributePlanID:         testPlanID,
+		AttributeHTTPStatusCode: http.StatusAccepted,
+	}
+	if e, a := string(OperationProvisionInstance), provision.name; e != a {
+		t.Errorf("unexpected span name; expected %v, got %v", e, a)
+	}
+	if e, a := expectedAttributes, provision.attributes; !reflect.DeepEqual(e, a) {
+		t.Errorf("unexpected span attributes:\nexpected %v\ngot      %v", e, a)
+	}
+	if !provision.ended || len(provision.errors) != 0 {
+		t.Errorf("expected the span to end without errors; ended: %v, errors: %v", provision.ended, provision.errors)
+	}
+
+	poll := tracer.spans[1]
+	if e, a := string(OperationPollLastOperation), poll.name; e != a {
+		t.Errorf("unexpected span name; expected %v, got %v", e, a)
+	}
+	if e, a := http.StatusGone, poll.attributes[AttributeHTTPStatusCode]; e != a {
+		t.Errorf("unexpected status code attribute; expected %v, got %v", e, a)
+	}
+	if !poll.ended || len(poll.errors) != 1 {
+		t.Errorf("expected the span to end with an error; ended: %v, errors: %v", poll.ended, poll.errors)
+	}
+
+	for i, expected := range []string{
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba90201-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba90202-01",
+	} {
+		if e, a := expected, headers[i].Get(TraceparentHeader); e != a {
+			t.Errorf("request %v: unexpected traceparent header; expected %v, got %v", i, e, a)
+		}
+		if e, a := "vendor=value", headers[i].Get(TracestateHeader); e != a {
+			t.Errorf("request %v: unexpected tracestate header; expected %v, got %v", i, e, a)
+		}
+	}
+}
+
+func TestNoTracer(t *testing.T) {
+	klient := newTestClient(t, "no tracer", Version2_14(), false, httpChecks{}, httpReaction{})
+	klient.doRequestFunc = func(request *http.Request) (*http.Response, error) {
+		if header := request.Header.Get(TraceparentHeader); header != "" {
+			t.Errorf("expected no traceparent header without a tracer, got %v", header)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: closer(`{"services":[]}`)}, nil
+	}
+
+	if _, err := klient.GetCatalog(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSpanContextTraceparent(t *testing.T) {
+	spanContext := SpanContext{
+		TraceID: [16]byte{0x0a, 0xf7, 0x65, 0x19, 0x16, 0xcd, 0x43, 0xdd, 0x84, 0x48, 0xeb, 0x21, 0x1c, 0x80, 0x31, 0x9c},
+		SpanID:  [8]byte{0xb7, 0xad, 0x6b, 0x71, 0x69, 0x20, 0x33, 0x31},
+	}
+	if e, a := "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-00", spanContext.Traceparent(); e != a {
+		t.Errorf("unexpected traceparent; expected %v, got %v", e, a)
+	}
+	if !spanContext.IsValid() {
+		t.Error("expected span context to be valid")
+	}
+	if (SpanContext{}).IsValid() {
+		t.Error("expected empty span context to be invalid")
+	}
+}
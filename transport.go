@@ -0,0 +1,49 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"net/http"
+)
+
+// TransportMiddleware wraps the http.RoundTripper used to send requests to
+// the broker, for example to sign requests, add headers or trace them.  It
+// is given the next http.RoundTripper in the chain and returns the one the
+// client should use in its place.  As with any http.RoundTripper, the
+// returned RoundTripper should not modify the request it is given; to add
+// headers, clone the request first.
+type TransportMiddleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is an adapter allowing an ordinary function to be used as
+// an http.RoundTripper, which is convenient when writing TransportMiddleware.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls f(request).
+func (f RoundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+// chainMiddleware wraps transport with the given middleware.  The first
+// middleware is the outermost, so it sees each request first and each
+// response last.
+func chainMiddleware(transport http.RoundTripper, middleware []TransportMiddleware) http.RoundTripper {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		transport = middleware[i](transport)
+	}
+
+	return transport
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// recordingMiddleware returns a TransportMiddleware that appends name to
+// calls before and after each request, and sets a header named after it.
+func recordingMiddleware(name string, calls *[]string) TransportMiddleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			*calls = append(*calls, name+" request")
+			request = request.Clone(request.Context())
+			request.Header.Set("X-"+name, "true")
+			response, err := next.RoundTrip(request)
+			*calls = append(*calls, name+" response")
+			return response, err
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var calls []string
+	statuses := []int{http.StatusServiceUnavailable, http.StatusOK}
+	base := RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		calls = append(calls, "transport")
+		for _, header := range []string{"X-First", "X-Second"} {
+			if request.Header.Get(header) != "true" {
+				t.Errorf("expected header %v to be set by middleware", header)
+			}
+		}
+
+		status := statuses[0]
+		statuses = statuses[1:]
+		return &http.Response{
+			StatusCode: status,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(`{"services":[]}`)),
+		}, nil
+	})
+
+	config := DefaultClientConfiguration()
+	config.URL = "https://example.com"
+	config.Transport = base
+	config.Middleware = []TransportMiddleware{
+		recordingMiddleware("First", &calls),
+		recordingMiddleware("Second", &calls),
+	}
+	config.RetryPolicy = &RetryPolicy{
+		MaxAttempts:    2,
+		InitialBackoff: time.Millisecond,
+	}
+
+	klient, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := klient.GetCatalog(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	attempt := []string{"First request", "Second request", "transport", "Second response", "First response"}
+	if e, a := append(attempt, attempt...), calls; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected each attempt to pass through the middleware in order:\nexpected %v\ngot      %v", e, a)
+	}
+}
+
+func TestMiddlewareWrapsDefaultTransport(t *testing.T) {
+	var wrapped http.RoundTripper
+	config := DefaultClientConfiguration()
+	config.URL = "https://example.com"
+	config.Insecure = true
+	config.Middleware = []TransportMiddleware{
+		func(next http.RoundTripper) http.RoundTripper {
+			wrapped = next
+			return next
+		},
+	}
+
+	if _, err := NewClient(config); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	transport, ok := wrapped.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected middleware to wrap an *http.Transport, got %T", wrapped)
+	}
+	if !transport.TLSClientConfig.InsecureSkipVerify {
+		t.Error("expected the TLS configuration to be kept when middleware is used")
+	}
+}
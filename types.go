@@ -127,7 +127,8 @@ type Plan struct {
 	// MaximumPollingDuration is a duration, in seconds, that the should
 	// be used as the Service's maximum polling duration.
 	MaximumPollingDuration *int64 `json:"maximum_polling_duration,omitempty"`
-	// MaintenanceInfo requires alpha features flag to be enabled.
+	// MaintenanceInfo requires a client API version >= 2.15 or the alpha
+	// features flag to be enabled.
 	//
 	// MaintenanceInfo represents maintenance information for a Service
 	// Instance which is provisioned using the Service Plan. Optional;
@@ -135,6 +136,10 @@ type Plan struct {
 	MaintenanceInfo *MaintenanceInfo `json:"maintenance_info,omitempty"`
 }
 
+// MaintenanceInfo identifies the maintenance version of a plan. Platforms
+// send the MaintenanceInfo of the plan they expect an instance to be on with
+// provision and update requests; a broker whose catalog has moved on rejects
+// them with a MaintenanceInfoConflict error.
 type MaintenanceInfo struct {
 	Version     string `json:"version"`
 	Description string `json:"description,omitempty"`
@@ -188,7 +193,8 @@ type InputParametersSchema struct {
 // OriginatingIdentity requires a client API version >=2.13.
 //
 // OriginatingIdentity is used to pass to the broker service an identity from
-// the platform
+// the platform.  NewKubernetesOriginatingIdentity and
+// NewCloudFoundryOriginatingIdentity build one for those platforms.
 type OriginatingIdentity struct {
 	// The name of the platform to which the user belongs
 	Platform string
@@ -200,6 +206,10 @@ type OriginatingIdentity struct {
 // CatalogResponse is sent as the response to catalog requests.
 type CatalogResponse struct {
 	Services []Service `json:"services"`
+	// RequestIdentity requires a client API version >= 2.15.
+	//
+	// RequestIdentity is the identity of the request this is a response to.
+	RequestIdentity string `json:"-"`
 }
 
 // ProvisionRequest represents a request to provision a new instance of a
@@ -231,13 +241,26 @@ type ProvisionRequest struct {
 	// Context requires a client API version >= 2.12.
 	//
 	// Context is platform-specific contextual information under which the
-	// service instance is to be provisioned.
+	// service instance is to be provisioned.  KubernetesContext and
+	// CloudFoundryContext convert to and from this form.
 	Context map[string]interface{} `json:"context,omitempty"`
 	// OriginatingIdentity requires a client API version >= 2.13.
 	//
 	// OriginatingIdentity is the identity on the platform of the user making
 	// this request.
 	OriginatingIdentity *OriginatingIdentity `json:"originatingIdentity,omitempty"`
+	// MaintenanceInfo requires a client API version >= 2.15 or the alpha
+	// features flag to be enabled.
+	//
+	// MaintenanceInfo is the maintenance information of the plan, as
+	// advertised in the catalog, that the platform expects the new instance
+	// to be provisioned with. Optional.
+	MaintenanceInfo *MaintenanceInfo `json:"maintenance_info,omitempty"`
+	// RequestIdentity requires a client API version >= 2.15.
+	//
+	// RequestIdentity identifies this request in the logs of the platform
+	// and the broker. If it is empty, the client generates a UUID.
+	RequestIdentity string `json:"requestIdentity,omitempty"`
 }
 
 // ProvisionResponse is sent in response to a provision call.
@@ -251,6 +274,38 @@ type ProvisionResponse struct {
 	// OperationKey is an extra identifier supplied by the broker to identify
 	// asynchronous operations.
 	OperationKey *OperationKey `json:"operation,omitempty"`
+	// Metadata requires a client API version >= 2.15.
+	//
+	// Metadata is a set of labels and attributes returned by the broker for
+	// the service instance.
+	Metadata *InstanceMetadata `json:"metadata,omitempty"`
+	// RequestIdentity requires a client API version >= 2.15.
+	//
+	// RequestIdentity is the identity of the request this is a response to.
+	RequestIdentity string `json:"-"`
+}
+
+// InstanceMetadata requires a client API version >= 2.15.
+//
+// InstanceMetadata holds information about a service instance returned by
+// the broker.
+type InstanceMetadata struct {
+	// Labels are key/value pairs that are meaningful to platform users and
+	// that platforms may use to identify and select service instances.
+	Labels map[string]interface{} `json:"labels,omitempty"`
+	// Attributes are key/value pairs describing the service instance that
+	// are meaningful to platform users but not used for identification.
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// BindingMetadata requires a client API version >= 2.15.
+//
+// BindingMetadata holds information about a service binding returned by the
+// broker.
+type BindingMetadata struct {
+	// ExpiresAt is the date and time, in ISO 8601 format, after which the
+	// binding's credentials are no longer valid.  Optional.
+	ExpiresAt string `json:"expires_at,omitempty"`
 }
 
 // OperationKey is an extra identifier from the broker in order to provide extra
@@ -284,13 +339,26 @@ type UpdateInstanceRequest struct {
 	// Context requires a client API version >= 2.12.
 	//
 	// Context is platform-specific contextual information under which the
-	// service instance was created.
+	// service instance was created.  KubernetesContext and
+	// CloudFoundryContext convert to and from this form.
 	Context map[string]interface{} `json:"context,omitempty"`
 	// OriginatingIdentity requires a client API version >= 2.13.
 	//
 	// OriginatingIdentity is the identity on the platform of the user making
 	// this request.
 	OriginatingIdentity *OriginatingIdentity `json:"originatingIdentity,omitempty"`
+	// MaintenanceInfo requires a client API version >= 2.15 or the alpha
+	// features flag to be enabled.
+	//
+	// MaintenanceInfo is the maintenance information of the plan, as
+	// advertised in the catalog, that the instance should be updated to.
+	// Optional.
+	MaintenanceInfo *MaintenanceInfo `json:"maintenance_info,omitempty"`
+	// RequestIdentity requires a client API version >= 2.15.
+	//
+	// RequestIdentity identifies this request in the logs of the platform
+	// and the broker. If it is empty, the client generates a UUID.
+	RequestIdentity string `json:"requestIdentity,omitempty"`
 }
 
 // PreviousValues represents information about the service instance prior to the update.
@@ -311,6 +379,11 @@ type PreviousValues struct {
 	// in the top-level field context. ID of the space specified for the service
 	// instance. If present, MUST be a non-empty string.
 	SpaceID string `json:"space_id,omitempty"`
+	// MaintenanceInfo requires a client API version >= 2.15 or the alpha
+	// features flag to be enabled.
+	//
+	// MaintenanceInfo of the service instance prior to the update.
+	MaintenanceInfo *MaintenanceInfo `json:"maintenance_info,omitempty"`
 }
 
 // UpdateInstanceResponse represents a broker's response to an update instance
@@ -327,6 +400,15 @@ type UpdateInstanceResponse struct {
 	// OperationKey is an extra identifier supplied by the broker to identify
 	// asynchronous operations.
 	OperationKey *OperationKey `json:"operation,omitempty"`
+	// Metadata requires a client API version >= 2.15.
+	//
+	// Metadata is a set of labels and attributes returned by the broker for
+	// the service instance.
+	Metadata *InstanceMetadata `json:"metadata,omitempty"`
+	// RequestIdentity requires a client API version >= 2.15.
+	//
+	// RequestIdentity is the identity of the request this is a response to.
+	RequestIdentity string `json:"-"`
 }
 
 // DeprovisionRequest represents a request to deprovision an instance of a
@@ -349,6 +431,11 @@ type DeprovisionRequest struct {
 	// OriginatingIdentity is the identity on the platform of the user making
 	// this request.
 	OriginatingIdentity *OriginatingIdentity `json:"originatingIdentity,omitempty"`
+	// RequestIdentity requires a client API version >= 2.15.
+	//
+	// RequestIdentity identifies this request in the logs of the platform
+	// and the broker. If it is empty, the client generates a UUID.
+	RequestIdentity string `json:"requestIdentity,omitempty"`
 }
 
 // GetInstanceRequest represents a request to do a GET on a particular instance
@@ -356,6 +443,11 @@ type DeprovisionRequest struct {
 type GetInstanceRequest struct {
 	// InstanceID is the ID of the instance
 	InstanceID string `json:"instance_id"`
+	// RequestIdentity requires a client API version >= 2.15.
+	//
+	// RequestIdentity identifies this request in the logs of the platform
+	// and the broker. If it is empty, the client generates a UUID.
+	RequestIdentity string `json:"requestIdentity,omitempty"`
 }
 
 // GetInstanceResponse is sent as the response to doing a GET on a particular
@@ -370,6 +462,15 @@ type GetInstanceResponse struct {
 	DashboardURL string `json:"dashboard_url,omitempty"`
 	// Parameters is a set of configuration options for the instance.
 	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	// Metadata requires a client API version >= 2.15.
+	//
+	// Metadata is a set of labels and attributes returned by the broker for
+	// the service instance.
+	Metadata *InstanceMetadata `json:"metadata,omitempty"`
+	// RequestIdentity requires a client API version >= 2.15.
+	//
+	// RequestIdentity is the identity of the request this is a response to.
+	RequestIdentity string `json:"-"`
 }
 
 // DeprovisionResponse represents a broker's response to a deprovision request.
@@ -380,6 +481,10 @@ type DeprovisionResponse struct {
 	// OperationKey is an extra identifier supplied by the broker to identify
 	// asynchronous operations.
 	OperationKey *OperationKey `json:"operation,omitempty"`
+	// RequestIdentity requires a client API version >= 2.15.
+	//
+	// RequestIdentity is the identity of the request this is a response to.
+	RequestIdentity string `json:"-"`
 }
 
 // LastOperationRequest represents a request to a broker to give the state of
@@ -403,6 +508,11 @@ type LastOperationRequest struct {
 	// OriginatingIdentity is the identity on the platform of the user making
 	// this request.
 	OriginatingIdentity *OriginatingIdentity `json:"originatingIdentity,omitempty"`
+	// RequestIdentity requires a client API version >= 2.15.
+	//
+	// RequestIdentity identifies this request in the logs of the platform
+	// and the broker. If it is empty, the client generates a UUID.
+	RequestIdentity string `json:"requestIdentity,omitempty"`
 }
 
 // BindingLastOperationRequest represents a request to a broker to give the
@@ -428,6 +538,11 @@ type BindingLastOperationRequest struct {
 	// OriginatingIdentity is the identity on the platform of the user making
 	// this request.
 	OriginatingIdentity *OriginatingIdentity `json:"originatingIdentity,omitempty"`
+	// RequestIdentity requires a client API version >= 2.15.
+	//
+	// RequestIdentity identifies this request in the logs of the platform
+	// and the broker. If it is empty, the client generates a UUID.
+	RequestIdentity string `json:"requestIdentity,omitempty"`
 }
 
 // LastOperationResponse represents the broker response with the state of a
@@ -442,6 +557,23 @@ type LastOperationResponse struct {
 	// API >= 1.15 indicating how long the client should wait before retrying
 	// polling for the operation result again.
 	PollDelay *time.Duration `json:"-"`
+	// Metadata requires a client API version >= 2.15.
+	//
+	// Metadata is a set of labels and attributes returned by the broker for
+	// the service instance whose last operation was polled.  It is only set
+	// by PollLastOperation.
+	Metadata *InstanceMetadata `json:"metadata,omitempty"`
+	// BindingMetadata requires a client API version >= 2.15.
+	//
+	// BindingMetadata holds information about the binding whose last
+	// operation was polled, returned by the broker in the same metadata
+	// field as instance metadata.  It is only set by
+	// PollBindingLastOperation.
+	BindingMetadata *BindingMetadata `json:"-"`
+	// RequestIdentity requires a client API version >= 2.15.
+	//
+	// RequestIdentity is the identity of the request this is a response to.
+	RequestIdentity string `json:"-"`
 }
 
 // LastOperationState is a typedef representing the state of an ongoing
@@ -485,13 +617,19 @@ type BindRequest struct {
 	// Context requires a client API version >= 2.13.
 	//
 	// Context is platform-specific contextual information under which the
-	// service binding is to be created.
+	// service binding is to be created.  KubernetesContext and
+	// CloudFoundryContext convert to and from this form.
 	Context map[string]interface{} `json:"context,omitempty"`
 	// OriginatingIdentity requires a client API version >= 2.13.
 	//
 	// OriginatingIdentity is the identity on the platform of the user making
 	// this request.
 	OriginatingIdentity *OriginatingIdentity `json:"originatingIdentity,omitempty"`
+	// RequestIdentity requires a client API version >= 2.15.
+	//
+	// RequestIdentity identifies this request in the logs of the platform
+	// and the broker. If it is empty, the client generates a UUID.
+	RequestIdentity string `json:"requestIdentity,omitempty"`
 }
 
 // BindResource contains data for platform resources associated with a
@@ -549,6 +687,14 @@ type BindResponse struct {
 	// The network endpoints that the Application uses to connect to the
 	// Service Instance.
 	Endpoints *[]Endpoint `json:"endpoints,omitempty"`
+	// Metadata requires a client API version >= 2.15.
+	//
+	// Metadata holds information about the binding returned by the broker.
+	Metadata *BindingMetadata `json:"metadata,omitempty"`
+	// RequestIdentity requires a client API version >= 2.15.
+	//
+	// RequestIdentity is the identity of the request this is a response to.
+	RequestIdentity string `json:"-"`
 }
 
 // UnbindRequest represents a request to unbind a particular binding.
@@ -574,6 +720,11 @@ type UnbindRequest struct {
 	// OriginatingIdentity is the identity on the platform of the user making
 	// this request.
 	OriginatingIdentity *OriginatingIdentity `json:"originatingIdentity,omitempty"`
+	// RequestIdentity requires a client API version >= 2.15.
+	//
+	// RequestIdentity identifies this request in the logs of the platform
+	// and the broker. If it is empty, the client generates a UUID.
+	RequestIdentity string `json:"requestIdentity,omitempty"`
 }
 
 // UnbindResponse represents a broker's response to an UnbindRequest.
@@ -588,6 +739,10 @@ type UnbindResponse struct {
 	// OperationKey is an extra identifier supplied by the broker to identify
 	// asynchronous operations.
 	OperationKey *OperationKey `json:"operation,omitempty"`
+	// RequestIdentity requires a client API version >= 2.15.
+	//
+	// RequestIdentity is the identity of the request this is a response to.
+	RequestIdentity string `json:"-"`
 }
 
 // GetBindingRequest represents a request to do a GET on a particular binding.
@@ -596,6 +751,11 @@ type GetBindingRequest struct {
 	InstanceID string `json:"instance_id"`
 	// BindingID is the ID of the binding to delete.
 	BindingID string `json:"binding_id"`
+	// RequestIdentity requires a client API version >= 2.15.
+	//
+	// RequestIdentity identifies this request in the logs of the platform
+	// and the broker. If it is empty, the client generates a UUID.
+	RequestIdentity string `json:"requestIdentity,omitempty"`
 }
 
 // GetBindingResponse is sent as the response to doing a GET on a particular
@@ -623,4 +783,12 @@ type GetBindingResponse struct {
 	// The network endpoints that the Application uses to connect to the
 	// Service Instance.
 	Endpoints *[]Endpoint `json:"endpoints,omitempty"`
+	// Metadata requires a client API version >= 2.15.
+	//
+	// Metadata holds information about the binding returned by the broker.
+	Metadata *BindingMetadata `json:"metadata,omitempty"`
+	// RequestIdentity requires a client API version >= 2.15.
+	//
+	// RequestIdentity is the identity of the request this is a response to.
+	RequestIdentity string `json:"-"`
 }
@@ -17,10 +17,9 @@ limitations under the License.
 package v2
 
 import (
+	"context"
 	"fmt"
 	"net/http"
-
-	"k8s.io/klog/v2"
 )
 
 type unbindSuccessResponseBody struct {
@@ -28,6 +27,13 @@ type unbindSuccessResponseBody struct {
 }
 
 func (c *client) Unbind(r *UnbindRequest) (*UnbindResponse, error) {
+	return c.UnbindWithContext(context.Background(), r)
+}
+
+func (c *client) UnbindWithContext(ctx context.Context, r *UnbindRequest) (_ *UnbindResponse, err error) {
+	ctx, span := c.startSpan(ctx, OperationUnbind, spanIDs{instanceID: r.InstanceID, bindingID: r.BindingID, serviceID: r.ServiceID, planID: r.PlanID})
+	defer func() { endSpan(span, err) }()
+
 	if r.AcceptsIncomplete {
 		if err := c.validateClientVersionIsAtLeast(Version2_14()); err != nil {
 			return nil, AsyncBindingOperationsNotAllowedError{
@@ -48,7 +54,7 @@ func (c *client) Unbind(r *UnbindRequest) (*UnbindResponse, error) {
 		params[AcceptsIncomplete] = "true"
 	}
 
-	response, err := c.prepareAndDo(http.MethodDelete, fullURL, params, nil, r.OriginatingIdentity)
+	response, err := c.prepareAndDo(ctx, OperationUnbind, http.MethodDelete, fullURL, params, nil, r.OriginatingIdentity, r.RequestIdentity)
 	if err != nil {
 		return nil, err
 	}
@@ -62,9 +68,10 @@ func (c *client) Unbind(r *UnbindRequest) (*UnbindResponse, error) {
 	case http.StatusOK, http.StatusGone:
 		userResponse := &UnbindResponse{}
 		if err := c.unmarshalResponse(response, userResponse); err != nil {
-			return nil, HTTPStatusCodeError{StatusCode: response.StatusCode, ResponseError: err}
+			return nil, unmarshalError(response, err)
 		}
 
+		userResponse.RequestIdentity = requestIdentity(response)
 		return userResponse, nil
 	case http.StatusAccepted:
 		if !r.AcceptsIncomplete {
@@ -73,7 +80,7 @@ func (c *client) Unbind(r *UnbindRequest) (*UnbindResponse, error) {
 
 		responseBodyObj := &unbindSuccessResponseBody{}
 		if err := c.unmarshalResponse(response, responseBodyObj); err != nil {
-			return nil, HTTPStatusCodeError{StatusCode: response.StatusCode, ResponseError: err}
+			return nil, unmarshalError(response, err)
 		}
 
 		var opPtr *OperationKey
@@ -87,12 +94,11 @@ func (c *client) Unbind(r *UnbindRequest) (*UnbindResponse, error) {
 			OperationKey: opPtr,
 		}
 		if response.StatusCode == http.StatusAccepted {
-			if c.Verbose {
-				klog.Infof("broker %q: received asynchronous response", c.Name)
-			}
+			c.logAt(LogLevelRequests).Info("received asynchronous response")
 			userResponse.Async = true
 		}
 
+		userResponse.RequestIdentity = requestIdentity(response)
 		return userResponse, nil
 	default:
 		return nil, c.handleFailureResponse(response)
@@ -97,7 +97,11 @@ func TestUnbind(t *testing.T) {
 				status: http.StatusAccepted,
 				body:   successAsyncUnbindResponseBody,
 			},
-			expectedResponse: successUnbindResponseAsync(),
+			expectedResponse: func() *UnbindResponse {
+				response := successUnbindResponseAsync()
+				response.RequestIdentity = testRequestIdentity
+				return response
+			}(),
 		},
 		{
 			name: "http error",
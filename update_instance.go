@@ -17,6 +17,7 @@ limitations under the License.
 package v2
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 )
@@ -24,22 +25,36 @@ import (
 // internal message body types
 
 type updateInstanceRequestBody struct {
-	ServiceID      string                 `json:"service_id"`
-	PlanID         *string                `json:"plan_id,omitempty"`
-	Parameters     map[string]interface{} `json:"parameters,omitempty"`
-	Context        map[string]interface{} `json:"context,omitempty"`
-	PreviousValues *PreviousValues        `json:"previous_values,omitempty"`
+	ServiceID       string                 `json:"service_id"`
+	PlanID          *string                `json:"plan_id,omitempty"`
+	Parameters      map[string]interface{} `json:"parameters,omitempty"`
+	Context         map[string]interface{} `json:"context,omitempty"`
+	PreviousValues  *PreviousValues        `json:"previous_values,omitempty"`
+	MaintenanceInfo *MaintenanceInfo       `json:"maintenance_info,omitempty"`
 }
 
 type updateInstanceResponseBody struct {
-	DashboardURL *string `json:"dashboard_url"`
-	Operation    *string `json:"operation"`
+	DashboardURL *string           `json:"dashboard_url"`
+	Operation    *string           `json:"operation"`
+	Metadata     *InstanceMetadata `json:"metadata"`
 }
 
 func (c *client) UpdateInstance(r *UpdateInstanceRequest) (*UpdateInstanceResponse, error) {
+	return c.UpdateInstanceWithContext(context.Background(), r)
+}
+
+func (c *client) UpdateInstanceWithContext(ctx context.Context, r *UpdateInstanceRequest) (_ *UpdateInstanceResponse, err error) {
+	ctx, span := c.startSpan(ctx, OperationUpdateInstance, spanIDs{instanceID: r.InstanceID, serviceID: r.ServiceID, planID: stringValue(r.PlanID)})
+	defer func() { endSpan(span, err) }()
+
 	if err := validateUpdateInstanceRequest(r); err != nil {
 		return nil, err
 	}
+	if c.RequestValidator != nil {
+		if err := c.RequestValidator.ValidateUpdateInstanceRequest(r); err != nil {
+			return nil, err
+		}
+	}
 
 	fullURL := fmt.Sprintf(serviceInstanceURLFmt, c.URL, r.InstanceID)
 	params := map[string]string{}
@@ -58,7 +73,15 @@ func (c *client) UpdateInstance(r *UpdateInstanceRequest) (*UpdateInstanceRespon
 		requestBody.Context = r.Context
 	}
 
-	response, err := c.prepareAndDo(http.MethodPatch, fullURL, params, requestBody, r.OriginatingIdentity)
+	if c.maintenanceInfoAllowed() {
+		requestBody.MaintenanceInfo = r.MaintenanceInfo
+	} else if r.PreviousValues != nil && r.PreviousValues.MaintenanceInfo != nil {
+		previousValues := *r.PreviousValues
+		previousValues.MaintenanceInfo = nil
+		requestBody.PreviousValues = &previousValues
+	}
+
+	response, err := c.prepareAndDo(ctx, OperationUpdateInstance, http.MethodPatch, fullURL, params, requestBody, r.OriginatingIdentity, r.RequestIdentity)
 	if err != nil {
 		return nil, err
 	}
@@ -72,7 +95,7 @@ func (c *client) UpdateInstance(r *UpdateInstanceRequest) (*UpdateInstanceRespon
 	case http.StatusOK:
 		responseBodyObj := &updateInstanceResponseBody{}
 		if err := c.unmarshalResponse(response, responseBodyObj); err != nil {
-			return nil, HTTPStatusCodeError{StatusCode: response.StatusCode, ResponseError: err}
+			return nil, unmarshalError(response, err)
 		}
 
 		userResponse := &UpdateInstanceResponse{
@@ -82,7 +105,11 @@ func (c *client) UpdateInstance(r *UpdateInstanceRequest) (*UpdateInstanceRespon
 		if c.APIVersion.AtLeast(Version2_14()) {
 			userResponse.DashboardURL = responseBodyObj.DashboardURL
 		}
+		if c.APIVersion.AtLeast(Version2_15()) {
+			userResponse.Metadata = responseBodyObj.Metadata
+		}
 
+		userResponse.RequestIdentity = requestIdentity(response)
 		return userResponse, nil
 	case http.StatusAccepted:
 		if !r.AcceptsIncomplete {
@@ -93,7 +120,7 @@ func (c *client) UpdateInstance(r *UpdateInstanceRequest) (*UpdateInstanceRespon
 
 		responseBodyObj := &updateInstanceResponseBody{}
 		if err := c.unmarshalResponse(response, responseBodyObj); err != nil {
-			return nil, HTTPStatusCodeError{StatusCode: response.StatusCode, ResponseError: err}
+			return nil, unmarshalError(response, err)
 		}
 
 		var opPtr *OperationKey
@@ -110,9 +137,13 @@ func (c *client) UpdateInstance(r *UpdateInstanceRequest) (*UpdateInstanceRespon
 		if c.APIVersion.AtLeast(Version2_14()) {
 			userResponse.DashboardURL = responseBodyObj.DashboardURL
 		}
+		if c.APIVersion.AtLeast(Version2_15()) {
+			userResponse.Metadata = responseBodyObj.Metadata
+		}
 
 		// TODO: fix op key handling
 
+		userResponse.RequestIdentity = requestIdentity(response)
 		return userResponse, nil
 	default:
 		return nil, c.handleFailureResponse(response)
@@ -74,10 +74,53 @@ func successUpdateInstanceResponeAsyncWithDashboard() *UpdateInstanceResponse {
 	return r
 }
 
+const metadataUpdateInstanceResponseBody = `{
+  "metadata": {
+    "labels": {"team": "data"},
+    "attributes": {"region": "eu-west-1"}
+  }
+}`
+
+const metadataAsyncUpdateInstanceResponseBody = `{
+  "operation": "test-operation-key",
+  "metadata": {
+    "labels": {"team": "data"},
+    "attributes": {"region": "eu-west-1"}
+  }
+}`
+
+func successUpdateInstanceResponseWithMetadata() *UpdateInstanceResponse {
+	r := successUpdateInstanceResponse()
+	r.Metadata = testInstanceMetadata()
+	return r
+}
+
+func successUpdateInstanceResponseAsyncWithMetadata() *UpdateInstanceResponse {
+	r := successUpdateInstanceResponseAsync()
+	r.Metadata = testInstanceMetadata()
+	return r
+}
+
 const contextUpdateInstanceRequestBody = `{"service_id":"test-service-id","plan_id":"test-plan-id","context":{"foo":"bar"}}`
 
 const previousValuesUpdateInstanceRequestBody = `{"service_id":"test-service-id","plan_id":"test-plan-id","previous_values":{"plan_id":"previous-plan-id"}}`
 
+const maintenanceInfoUpdateInstanceRequestBody = `{"service_id":"test-service-id","plan_id":"test-plan-id","previous_values":{"plan_id":"previous-plan-id","maintenance_info":{"version":"1.2.3"}},"maintenance_info":{"version":"1.2.4"}}`
+
+func maintenanceInfoUpdateInstanceRequest() *UpdateInstanceRequest {
+	r := defaultUpdateInstanceRequest()
+	r.MaintenanceInfo = &MaintenanceInfo{
+		Version: "1.2.4",
+	}
+	r.PreviousValues = &PreviousValues{
+		PlanID: "previous-plan-id",
+		MaintenanceInfo: &MaintenanceInfo{
+			Version: "1.2.3",
+		},
+	}
+	return r
+}
+
 func TestUpdateInstanceInstance(t *testing.T) {
 	cases := []struct {
 		name                string
@@ -270,7 +313,11 @@ func TestUpdateInstanceInstance(t *testing.T) {
 				status: http.StatusOK,
 				body:   successUpdateInstanceResponseBodyWithNewDashboardURL,
 			},
-			expectedResponse: successUpdateInstanceResponseWithDashboard(),
+			expectedResponse: func() *UpdateInstanceResponse {
+				response := successUpdateInstanceResponseWithDashboard()
+				response.RequestIdentity = testRequestIdentity
+				return response
+			}(),
 		},
 		{
 			name:    "success with updated dashboard url - async",
@@ -285,7 +332,91 @@ func TestUpdateInstanceInstance(t *testing.T) {
 				status: http.StatusAccepted,
 				body:   successAsyncUpdateInstanceResponseBodyWithNewDashboardURL,
 			},
-			expectedResponse: successUpdateInstanceResponeAsyncWithDashboard(),
+			expectedResponse: func() *UpdateInstanceResponse {
+				response := successUpdateInstanceResponeAsyncWithDashboard()
+				response.RequestIdentity = testRequestIdentity
+				return response
+			}(),
+		},
+		{
+			name:    "maintenance info - 2.15",
+			version: Version2_15(),
+			request: maintenanceInfoUpdateInstanceRequest(),
+			httpChecks: httpChecks{
+				body: maintenanceInfoUpdateInstanceRequestBody,
+			},
+			httpReaction: httpReaction{
+				status: http.StatusOK,
+				body:   successUpdateInstanceResponseBody,
+			},
+			expectedResponse: func() *UpdateInstanceResponse {
+				response := successUpdateInstanceResponse()
+				response.RequestIdentity = testRequestIdentity
+				return response
+			}(),
+		},
+		{
+			name:        "maintenance info - alpha",
+			version:     Version2_14(),
+			enableAlpha: true,
+			request:     maintenanceInfoUpdateInstanceRequest(),
+			httpChecks: httpChecks{
+				body: maintenanceInfoUpdateInstanceRequestBody,
+			},
+			httpReaction: httpReaction{
+				status: http.StatusOK,
+				body:   successUpdateInstanceResponseBody,
+			},
+			expectedResponse: successUpdateInstanceResponse(),
+		},
+		{
+			name:    "maintenance info not sent unless API version >= 2.15 or alpha",
+			version: Version2_14(),
+			request: maintenanceInfoUpdateInstanceRequest(),
+			httpChecks: httpChecks{
+				body: previousValuesUpdateInstanceRequestBody,
+			},
+			httpReaction: httpReaction{
+				status: http.StatusOK,
+				body:   successUpdateInstanceResponseBody,
+			},
+			expectedResponse: successUpdateInstanceResponse(),
+		},
+		{
+			name:    "metadata - 2.15",
+			version: Version2_15(),
+			httpReaction: httpReaction{
+				status: http.StatusOK,
+				body:   metadataUpdateInstanceResponseBody,
+			},
+			expectedResponse: func() *UpdateInstanceResponse {
+				response := successUpdateInstanceResponseWithMetadata()
+				response.RequestIdentity = testRequestIdentity
+				return response
+			}(),
+		},
+		{
+			name:    "metadata - 2.15 async",
+			version: Version2_15(),
+			request: defaultAsyncUpdateInstanceRequest(),
+			httpReaction: httpReaction{
+				status: http.StatusAccepted,
+				body:   metadataAsyncUpdateInstanceResponseBody,
+			},
+			expectedResponse: func() *UpdateInstanceResponse {
+				response := successUpdateInstanceResponseAsyncWithMetadata()
+				response.RequestIdentity = testRequestIdentity
+				return response
+			}(),
+		},
+		{
+			name:    "metadata not returned unless API version >= 2.15",
+			version: Version2_14(),
+			httpReaction: httpReaction{
+				status: http.StatusOK,
+				body:   metadataUpdateInstanceResponseBody,
+			},
+			expectedResponse: successUpdateInstanceResponse(),
 		},
 		{
 			name:    "dashboard url not sent unless API version >= 2.14",
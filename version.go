@@ -45,7 +45,7 @@ func (v APIVersion) IsLessThan(other APIVersion) bool {
 // LatestAPIVersion returns the latest supported API version in the current
 // release of this library.
 func LatestAPIVersion() APIVersion {
-	return Version2_14()
+	return Version2_15()
 }
 
 // APIVersions returns a list of the APIVersions supported by this library, with
@@ -56,6 +56,7 @@ func APIVersions() []APIVersion {
 		Version2_12(),
 		Version2_13(),
 		Version2_14(),
+		Version2_15(),
 	}
 }
 
@@ -75,6 +76,10 @@ const (
 	// internalAPIVersion2_14 represents the 2.14 version of the Open Service
 	// Broker API.
 	internalAPIVersion2_14 = "2.14"
+
+	// internalAPIVersion2_15 represents the 2.15 version of the Open Service
+	// Broker API.
+	internalAPIVersion2_15 = "2.15"
 )
 
 // Version2_11 returns an APIVersion struct with the internal API version set to "2.11"
@@ -96,3 +101,8 @@ func Version2_13() APIVersion {
 func Version2_14() APIVersion {
 	return APIVersion{label: internalAPIVersion2_14, order: 3}
 }
+
+// Version2_15 returns an APIVersion struct with the internal API version set to "2.15"
+func Version2_15() APIVersion {
+	return APIVersion{label: internalAPIVersion2_15, order: 4}
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+	"time"
+)
+
+// DefaultPollInterval is the delay between two polls of the last operation
+// endpoint used when neither WaitOptions.PollInterval nor the broker specify
+// one.
+const DefaultPollInterval = 5 * time.Second
+
+// WaitOptions configures WaitForInstanceOperation and
+// WaitForBindingOperation.
+type WaitOptions struct {
+	// PollInterval is the delay between two polls when the broker does not
+	// request one with a Retry-After header.  Defaults to
+	// DefaultPollInterval.
+	PollInterval time.Duration
+	// MaximumPollingDuration is the maximum amount of time to wait for the
+	// operation to finish.  If unset, the MaximumPollingDuration of Plan is
+	// used.  If neither is set, the wait is only bounded by the context.
+	MaximumPollingDuration time.Duration
+	// Plan is the plan of the instance the operation is for.  Optional.
+	Plan *Plan
+	// Delete indicates that the operation being waited for is a
+	// deprovision or an unbind, in which case an HTTP GONE response from the
+	// broker means that the operation succeeded.
+	Delete bool
+}
+
+// WaitResult is the outcome of an asynchronous operation that the broker
+// finished, successfully or not.
+type WaitResult struct {
+	// State is the final state of the operation: either StateSucceeded or
+	// StateFailed.
+	State LastOperationState
+	// Description is the last description of the operation returned by the
+	// broker, if any.
+	Description *string
+	// Gone is true if the broker reported that the resource was gone, which
+	// is how a delete operation may finish.
+	Gone bool
+	// Polls is the number of requests made to the last operation endpoint.
+	Polls int
+}
+
+// WaitForInstanceOperation polls the last operation of a service instance
+// until the broker reports that the operation succeeded or failed, and
+// returns the final result.  The delay between polls is the PollDelay
+// returned by the broker, if any, or the PollInterval of the options.
+//
+// Polls that time out, whose connection is refused or reset, or that get a
+// 5xx response are repeated; other errors are returned immediately.  If the
+// operation does not finish within the maximum polling duration a
+// WaitTimeoutError is returned, and if ctx is done first a ContextError is
+// returned.  If client implements ClientWithContext, ctx is also passed to
+// each poll.
+func WaitForInstanceOperation(ctx context.Context, client Client, r *LastOperationRequest, opts *WaitOptions) (*WaitResult, error) {
+	return waitForOperation(ctx, opts, func(ctx context.Context) (*LastOperationResponse, error) {
+		if contextClient, ok := client.(ClientWithContext); ok {
+			return contextClient.PollLastOperationWithContext(ctx, r)
+		}
+		return client.PollLastOperation(r)
+	})
+}
+
+// WaitForBindingOperation polls the last operation of a service binding
+// until the broker reports that the operation succeeded or failed, and
+// returns the final result.  It behaves like WaitForInstanceOperation.
+func WaitForBindingOperation(ctx context.Context, client Client, r *BindingLastOperationRequest, opts *WaitOptions) (*WaitResult, error) {
+	return waitForOperation(ctx, opts, func(ctx context.Context) (*LastOperationResponse, error) {
+		if contextClient, ok := client.(ClientWithContext); ok {
+			return contextClient.PollBindingLastOperationWithContext(ctx, r)
+		}
+		return client.PollBindingLastOperation(r)
+	})
+}
+
+func waitForOperation(ctx context.Context, opts *WaitOptions, poll func(context.Context) (*LastOperationResponse, error)) (*WaitResult, error) {
+	if opts == nil {
+		opts = &WaitOptions{}
+	}
+	maximumPollingDuration := opts.maximumPollingDuration()
+
+	start := time.Now()
+	result := &WaitResult{}
+	for {
+		response, err := poll(ctx)
+		result.Polls++
+
+		if err != nil {
+			if opts.Delete && IsGoneError(err) {
+				result.State = StateSucceeded
+				result.Gone = true
+				return result, nil
+			}
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return result, ContextError{Err: ctxErr}
+			}
+			if !isTransientPollError(err) {
+				return result, err
+			}
+		} else {
+			result.State = response.State
+			result.Description = response.Description
+			if response.State == StateSucceeded || response.State == StateFailed {
+				return result, nil
+			}
+		}
+
+		delay := opts.PollInterval
+		if delay <= 0 {
+			delay = DefaultPollInterval
+		}
+		if response != nil && response.PollDelay != nil {
+			delay = *response.PollDelay
+		}
+
+		if maximumPollingDuration > 0 {
+			remaining := maximumPollingDuration - time.Since(start)
+			if remaining <= 0 {
+				return result, WaitTimeoutError{
+					MaximumPollingDuration: maximumPollingDuration,
+					LastState:              result.State,
+				}
+			}
+			if delay > remaining {
+				delay = remaining
+			}
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return result, ContextError{Err: ctx.Err()}
+		}
+	}
+}
+
+// maximumPollingDuration returns the maximum polling duration from the
+// options or, if unset, from the plan.
+func (o *WaitOptions) maximumPollingDuration() time.Duration {
+	if o.MaximumPollingDuration > 0 {
+		return o.MaximumPollingDuration
+	}
+	if o.Plan != nil && o.Plan.MaximumPollingDuration != nil {
+		return time.Duration(*o.Plan.MaximumPollingDuration) * time.Second
+	}
+	return 0
+}
+
+// isTransientPollError returns whether a failed poll should be repeated:
+// whether it timed out, the connection to the broker was refused or reset, or
+// the broker returned a 5xx response.  Other errors, such as DNS failures,
+// TLS verification errors and invalid URLs, are not going to go away by
+// polling again and are returned immediately.
+func isTransientPollError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+
+	statusCodeError, ok := IsHTTPError(err)
+	if !ok {
+		return false
+	}
+
+	return statusCodeError.StatusCode >= 500
+}
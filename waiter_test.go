@@ -0,0 +1,235 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// pollError returns the error returned by an HTTP client for a poll that
+// failed with the given error.
+func pollError(err error) error {
+	return &url.Error{Op: http.MethodGet, URL: "https://example.com", Err: err}
+}
+
+// doPolls returns a doRequestFunc that replies to successive polls with the
+// given reactions, repeating the last one once they are exhausted.
+func doPolls(reactions []httpReaction, polls *int) doRequestFunc {
+	return func(request *http.Request) (*http.Response, error) {
+		reaction := reactions[len(reactions)-1]
+		if *polls < len(reactions) {
+			reaction = reactions[*polls]
+		}
+		*polls++
+
+		if reaction.err != nil {
+			return nil, reaction.err
+		}
+		return &http.Response{
+			StatusCode: reaction.status,
+			Header:     reaction.header,
+			Body:       closer(reaction.body),
+		}, nil
+	}
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
+
+func TestWaitForInstanceOperation(t *testing.T) {
+	cases := []struct {
+		name            string
+		opts            *WaitOptions
+		reactions       []httpReaction
+		expectedResult  *WaitResult
+		expectedTimeout bool
+		expectedErr     bool
+		expectedPolls   int
+	}{
+		{
+			name: "succeeded",
+			reactions: []httpReaction{
+				{status: http.StatusOK, body: inProgressLastOperationResponseBody},
+				{status: http.StatusOK, body: inProgressLastOperationResponseBody},
+				{status: http.StatusOK, body: successLastOperationResponseBody},
+			},
+			expectedResult: &WaitResult{State: StateSucceeded, Description: strPtr("test description"), Polls: 3},
+		},
+		{
+			name: "failed",
+			reactions: []httpReaction{
+				{status: http.StatusOK, body: inProgressLastOperationResponseBody},
+				{status: http.StatusOK, body: failedLastOperationResponseBody},
+			},
+			expectedResult: &WaitResult{State: StateFailed, Description: strPtr("test description"), Polls: 2},
+		},
+		{
+			name: "transient errors are retried",
+			reactions: []httpReaction{
+				{err: pollError(&net.OpError{Op: "read", Net: "tcp", Err: &os.SyscallError{Syscall: "read", Err: syscall.ECONNRESET}})},
+				{err: pollError(&net.OpError{Op: "dial", Net: "tcp", Err: &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED}})},
+				{err: pollError(timeoutError{})},
+				{status: http.StatusBadGateway, body: conventionalFailureResponseBody},
+				{status: http.StatusOK, body: successLastOperationResponseBody},
+			},
+			expectedResult: &WaitResult{State: StateSucceeded, Description: strPtr("test description"), Polls: 5},
+		},
+		{
+			name: "DNS errors are returned",
+			reactions: []httpReaction{
+				{err: pollError(&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "broker.invalid", IsNotFound: true}})},
+			},
+			expectedErr:   true,
+			expectedPolls: 1,
+		},
+		{
+			name: "TLS verification errors are returned",
+			reactions: []httpReaction{
+				{err: pollError(x509.UnknownAuthorityError{})},
+			},
+			expectedErr:   true,
+			expectedPolls: 1,
+		},
+		{
+			name: "other errors are returned",
+			reactions: []httpReaction{
+				{status: http.StatusBadRequest, body: conventionalFailureResponseBody},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "gone for delete",
+			opts: &WaitOptions{Delete: true},
+			reactions: []httpReaction{
+				{status: http.StatusOK, body: inProgressLastOperationResponseBody},
+				{status: http.StatusGone, body: `{}`},
+			},
+			expectedResult: &WaitResult{State: StateSucceeded, Description: strPtr("test description"), Gone: true, Polls: 2},
+		},
+		{
+			name: "gone for non-delete",
+			reactions: []httpReaction{
+				{status: http.StatusGone, body: `{}`},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "maximum polling duration",
+			opts: &WaitOptions{MaximumPollingDuration: 20 * time.Millisecond},
+			reactions: []httpReaction{
+				{status: http.StatusOK, body: inProgressLastOperationResponseBody},
+			},
+			expectedTimeout: true,
+		},
+		{
+			name: "zero maximum polling duration from plan means no limit",
+			opts: &WaitOptions{Plan: &Plan{MaximumPollingDuration: int64Ptr(0)}, MaximumPollingDuration: 0},
+			reactions: []httpReaction{
+				{status: http.StatusOK, body: inProgressLastOperationResponseBody},
+				{status: http.StatusOK, body: successLastOperationResponseBody},
+			},
+			expectedResult: &WaitResult{State: StateSucceeded, Description: strPtr("test description"), Polls: 2},
+		},
+	}
+
+	for _, tc := range cases {
+		if tc.opts == nil {
+			tc.opts = &WaitOptions{}
+		}
+		tc.opts.PollInterval = time.Millisecond
+
+		polls := 0
+		klient := newTestClient(t, tc.name, Version2_14(), true, httpChecks{}, httpReaction{})
+		klient.doRequestFunc = doPolls(tc.reactions, &polls)
+
+		result, err := WaitForInstanceOperation(context.Background(), klient, &LastOperationRequest{InstanceID: testInstanceID}, tc.opts)
+
+		if tc.expectedTimeout {
+			if !IsWaitTimeoutError(err) {
+				t.Errorf("%v: expected timeout error, got %v", tc.name, err)
+			}
+			continue
+		}
+		if tc.expectedErr {
+			if err == nil {
+				t.Errorf("%v: expected error, got none", tc.name)
+			}
+			if tc.expectedPolls > 0 && tc.expectedPolls != polls {
+				t.Errorf("%v: expected %v polls, got %v", tc.name, tc.expectedPolls, polls)
+			}
+			continue
+		}
+
+		doResponseChecks(t, tc.name, result, err, tc.expectedResult, "", nil)
+	}
+}
+
+func TestWaitForInstanceOperationPlanTimeout(t *testing.T) {
+	polls := 0
+	klient := newTestClient(t, "plan timeout", Version2_14(), true, httpChecks{}, httpReaction{})
+	klient.doRequestFunc = doPolls([]httpReaction{
+		{
+			status: http.StatusOK,
+			body:   inProgressLastOperationResponseBody,
+			header: http.Header{PollingDelayHeader: []string{"5"}},
+		},
+	}, &polls)
+
+	start := time.Now()
+	_, err := WaitForInstanceOperation(context.Background(), klient, &LastOperationRequest{InstanceID: testInstanceID}, &WaitOptions{
+		Plan: &Plan{MaximumPollingDuration: int64Ptr(1)},
+	})
+	if !IsWaitTimeoutError(err) {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("expected the wait to be bounded by the plan; took %v", elapsed)
+	}
+	if e, a := 2, polls; e != a {
+		t.Errorf("unexpected number of polls; expected %v, got %v", e, a)
+	}
+}
+
+func TestWaitForBindingOperationContext(t *testing.T) {
+	polls := 0
+	klient := newTestClient(t, "context", Version2_14(), false, httpChecks{}, httpReaction{})
+	klient.doRequestFunc = doPolls([]httpReaction{
+		{status: http.StatusOK, body: inProgressLastOperationResponseBody},
+	}, &polls)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	result, err := WaitForBindingOperation(ctx, klient, &BindingLastOperationRequest{InstanceID: testInstanceID, BindingID: testBindingID}, &WaitOptions{
+		PollInterval: time.Millisecond,
+	})
+	if !IsDeadlineExceededError(err) {
+		t.Fatalf("expected deadline exceeded error, got %v", err)
+	}
+	if e, a := StateInProgress, result.State; e != a {
+		t.Errorf("unexpected state; expected %v, got %v", e, a)
+	}
+}